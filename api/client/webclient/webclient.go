@@ -64,6 +64,11 @@ type Config struct {
 	IgnoreHTTPProxy bool
 	// Timeout is a timeout for requests.
 	Timeout time.Duration
+	// VerifyConnection is an optional callback invoked by the TLS stack for
+	// every connection, including ones made with Insecure set. It lets
+	// callers enforce their own checks, such as certificate pinning, when
+	// the standard CA verification is disabled.
+	VerifyConnection func(tls.ConnectionState) error
 }
 
 // CheckAndSetDefaults checks and sets defaults
@@ -90,6 +95,7 @@ func newWebClient(cfg *Config) (*http.Client, error) {
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: cfg.Insecure,
 			RootCAs:            cfg.Pool,
+			VerifyConnection:   cfg.VerifyConnection,
 		},
 	}
 	if !cfg.IgnoreHTTPProxy {
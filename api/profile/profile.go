@@ -90,6 +90,10 @@ type Profile struct {
 	// TLSRoutingEnabled indicates that proxy supports ALPN SNI server where
 	// all proxy services are exposed on a single TLS listener (Proxy Web Listener).
 	TLSRoutingEnabled bool `yaml:"tls_routing_enabled,omitempty"`
+
+	// NodeLogins remembers the last successful login used per node or label
+	// set, so subsequent connections can default to it.
+	NodeLogins map[string]string `yaml:"node_logins,omitempty"`
 }
 
 // Name returns the name of the profile.
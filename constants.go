@@ -285,6 +285,9 @@ const (
 	// Text means text serialization format
 	Text = "text"
 
+	// CSV means comma-separated values serialization format
+	CSV = "csv"
+
 	// PTY is a raw pty session capture format
 	PTY = "pty"
 
@@ -649,6 +652,19 @@ const (
 	// requirement information should be printed.
 	EnvSSHSessionDisplayParticipantRequirements = "TELEPORT_SESSION_PARTICIPANT_REQUIREMENTS"
 
+	// EnvSSHSessionUser is injected into sessions with the name of the
+	// Teleport user that started the session, when session environment
+	// injection is enabled.
+	EnvSSHSessionUser = "TELEPORT_USER"
+
+	// EnvSSHSessionRoles is injected into sessions with a comma separated
+	// list of the Teleport roles held by the user that started the session.
+	EnvSSHSessionRoles = "TELEPORT_ROLES"
+
+	// EnvSSHSessionCluster is injected into sessions with the name of the
+	// Teleport cluster the session is running in.
+	EnvSSHSessionCluster = "TELEPORT_CLUSTER"
+
 	// SSHSessionJoinPrincipal is the SSH principal used when joining sessions.
 	// This starts with a hyphen so it isn't a valid unix login.
 	SSHSessionJoinPrincipal = "-teleport-internal-join"
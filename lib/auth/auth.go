@@ -3227,6 +3227,13 @@ func (a *Server) isMFARequired(ctx context.Context, checker services.AccessCheck
 			services.AccessMFAParams{},
 			services.NewWindowsLoginMatcher(t.WindowsDesktop.GetLogin()))
 
+	case nil:
+		// Some targets (application access) have no way to be expressed in
+		// the request. They only honor the cluster-wide requirement checked
+		// above; role-driven MFA requirements for them are enforced at
+		// access time by the serving component.
+		return &proto.IsMFARequiredResponse{Required: false}, nil
+
 	default:
 		return nil, trace.BadParameter("unknown Target %T", req.Target)
 	}
@@ -18,6 +18,9 @@ package native
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -113,6 +116,89 @@ type keyPair struct {
 	pubBytes []byte
 }
 
+const (
+	// KeyAlgorithmRSA2048 generates 2048-bit RSA keys, the default.
+	KeyAlgorithmRSA2048 = "rsa-2048"
+	// KeyAlgorithmRSA4096 generates 4096-bit RSA keys.
+	KeyAlgorithmRSA4096 = "rsa-4096"
+	// KeyAlgorithmECDSAP256 generates ECDSA keys on the NIST P-256 curve.
+	KeyAlgorithmECDSAP256 = "ecdsa-p256"
+	// KeyAlgorithmEd25519 generates Ed25519 keys.
+	KeyAlgorithmEd25519 = "ed25519"
+)
+
+// SupportedKeyAlgorithms is the list of algorithms accepted by
+// GenerateKeyPairWithAlgorithm, in the order they should be presented
+// to users.
+var SupportedKeyAlgorithms = []string{
+	KeyAlgorithmRSA2048,
+	KeyAlgorithmRSA4096,
+	KeyAlgorithmECDSAP256,
+	KeyAlgorithmEd25519,
+}
+
+// GenerateKeyPairWithAlgorithm returns a fresh priv/pub keypair using the
+// requested algorithm. An empty algorithm or KeyAlgorithmRSA2048 is served
+// from the precomputed cache via GenerateKeyPair, other algorithms are
+// generated on demand.
+func GenerateKeyPairWithAlgorithm(algorithm string) ([]byte, []byte, error) {
+	switch algorithm {
+	case "", KeyAlgorithmRSA2048:
+		return GenerateKeyPair()
+	case KeyAlgorithmRSA4096:
+		priv, err := rsa.GenerateKey(rand.Reader, 4096)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		privPem := pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(priv),
+		})
+		return marshalWithPublicKey(privPem, &priv.PublicKey)
+	case KeyAlgorithmECDSAP256:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		privDer, err := x509.MarshalECPrivateKey(priv)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		privPem := pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: privDer,
+		})
+		return marshalWithPublicKey(privPem, &priv.PublicKey)
+	case KeyAlgorithmEd25519:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		privDer, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		privPem := pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: privDer,
+		})
+		return marshalWithPublicKey(privPem, pub)
+	default:
+		return nil, nil, trace.BadParameter("unsupported key algorithm %q, supported algorithms: %v",
+			algorithm, SupportedKeyAlgorithms)
+	}
+}
+
+// marshalWithPublicKey pairs a PEM encoded private key with its public key
+// in SSH authorized_keys format.
+func marshalWithPublicKey(privPem []byte, pubKey interface{}) ([]byte, []byte, error) {
+	pub, err := ssh.NewPublicKey(pubKey)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return privPem, ssh.MarshalAuthorizedKey(pub), nil
+}
+
 // keygen is a key generator that precomputes keys to provide quick access to
 // public/private key pairs.
 type Keygen struct {
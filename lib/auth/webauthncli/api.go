@@ -102,6 +102,7 @@ func crossPlatformLogin(
 		return FIDO2Login(ctx, origin, assertion, prompt, opts)
 	}
 
+	log.Debug("FIDO2 unavailable in current build, falling back to U2F (CTAP2-only devices won't work)")
 	prompt.PromptTouch()
 	resp, err := U2FLogin(ctx, origin, assertion)
 	return resp, "" /* credentialUser */, err
@@ -82,6 +82,46 @@ func IsFIDO2Available() bool {
 	return !ok || val == "1"
 }
 
+// fido2Devices implements FIDO2Devices.
+func fido2Devices(ctx context.Context) ([]FIDO2DeviceInfo, error) {
+	locs, err := fidoDeviceLocations()
+	if err != nil {
+		return nil, trace.Wrap(err, "device locations")
+	}
+
+	var devs []FIDO2DeviceInfo
+	for _, loc := range locs {
+		if err := ctx.Err(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+
+		fd := FIDO2DeviceInfo{
+			Path:         loc.Path,
+			Manufacturer: loc.Manufacturer,
+			Product:      loc.Product,
+		}
+		dev, err := fidoNewDevice(loc.Path)
+		if err != nil {
+			return nil, trace.Wrap(err, "device %v: open", loc.Path)
+		}
+
+		// U2F-only devices don't answer CTAP2 GetInfo, report them without
+		// version information instead of failing the listing.
+		info, err := dev.Info()
+		if err != nil {
+			log.WithError(err).Debugf("FIDO2: Device %v: info", loc.Path)
+		} else {
+			fd.Versions = info.Versions
+			fd.Options = make(map[string]string, len(info.Options))
+			for _, opt := range info.Options {
+				fd.Options[opt.Name] = string(opt.Value)
+			}
+		}
+		devs = append(devs, fd)
+	}
+	return devs, nil
+}
+
 // fido2Login implements FIDO2Login.
 func fido2Login(
 	ctx context.Context,
@@ -78,6 +78,28 @@ func FIDO2Register(
 	return fido2Register(ctx, origin, cc, prompt)
 }
 
+// FIDO2DeviceInfo describes a connected FIDO2/U2F USB HID authenticator.
+type FIDO2DeviceInfo struct {
+	// Path is the HID path of the device.
+	Path string
+	// Manufacturer and Product identify the device.
+	Manufacturer string
+	Product      string
+	// Versions are the protocol versions supported by the device (e.g.,
+	// "U2F_V2", "FIDO_2_0").
+	Versions []string
+	// Options are the authenticator options reported by the device (e.g.,
+	// "clientPin", "rk").
+	Options map[string]string
+}
+
+// FIDO2Devices enumerates connected FIDO2/U2F USB HID authenticators.
+// It is useful for diagnosing headless setups where no browser is available
+// to mediate WebAuthn.
+func FIDO2Devices(ctx context.Context) ([]FIDO2DeviceInfo, error) {
+	return fido2Devices(ctx)
+}
+
 type FIDO2DiagResult struct {
 	Available                           bool
 	RegisterSuccessful, LoginSuccessful bool
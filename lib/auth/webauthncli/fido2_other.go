@@ -40,6 +40,10 @@ func fido2Login(
 	return nil, "", errFIDO2Unavailable
 }
 
+func fido2Devices(ctx context.Context) ([]FIDO2DeviceInfo, error) {
+	return nil, errFIDO2Unavailable
+}
+
 func fido2Register(
 	ctx context.Context,
 	origin string, cc *wanlib.CredentialCreation, prompt RegisterPrompt,
@@ -21,9 +21,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math/rand"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -56,10 +59,22 @@ type Config struct {
 	Command []string
 	// Interactive turns on interactive sessions
 	Interactive bool
+	// URL, when set, makes the benchmark issue HTTP GET requests against
+	// the URL instead of running SSH commands.
+	URL string
+	// HTTPClient is the client used for HTTP benchmarks.
+	HTTPClient *http.Client
 	// MinimumWindow is the min duration
 	MinimumWindow time.Duration
 	// MinimumMeasurments is the min amount of requests
 	MinimumMeasurements int
+	// ChaosRate is the percentage [0, 100] of requests that are killed
+	// mid-flight, to exercise reconnect and error behavior of the proxies
+	// under test. Zero disables chaos injection.
+	ChaosRate int
+	// ChaosMaxDelay bounds how long after a request starts its chaos kill
+	// fires. Zero defaults to one second.
+	ChaosMaxDelay time.Duration
 }
 
 // Result is a result of the benchmark
@@ -68,10 +83,22 @@ type Result struct {
 	RequestsOriginated int
 	// RequestsFailed is amount of requests failed
 	RequestsFailed int
+	// RequestsKilled is the amount of requests killed mid-flight by chaos
+	// injection. Killed requests are not counted as failures.
+	RequestsKilled int
 	// Histogram holds the response duration values
 	Histogram *hdrhistogram.Histogram
+	// FailuresByClass counts failed requests by error class, see the
+	// ErrorClass constants.
+	FailuresByClass map[string]int
+	// HistogramsByClass holds the response duration values of failed
+	// requests, keyed by error class.
+	HistogramsByClass map[string]*hdrhistogram.Histogram
 	// LastError contains last recorded error
 	LastError error
+	// StatusCodes counts HTTP responses by status code (HTTP benchmarks
+	// only).
+	StatusCodes map[int]int
 	// Duration it takes for the whole benchmark to run
 	Duration time.Duration
 }
@@ -127,6 +154,29 @@ func Run(ctx context.Context, lg *Linear, cmd, host, login, proxy string) ([]Res
 func ExportLatencyProfile(path string, h *hdrhistogram.Histogram, ticks int32, valueScale float64) (string, error) {
 	timeStamp := time.Now().Format("2006-01-02_15:04:05")
 	suffix := fmt.Sprintf("latency_profile_%s.txt", timeStamp)
+	return exportHistogram(path, suffix, h, ticks, valueScale)
+}
+
+// ExportClassLatencyProfiles exports one latency profile per error class and
+// returns the paths of the written files.
+func ExportClassLatencyProfiles(path string, histograms map[string]*hdrhistogram.Histogram, ticks int32, valueScale float64) ([]string, error) {
+	timeStamp := time.Now().Format("2006-01-02_15:04:05")
+	var paths []string
+	for class, h := range histograms {
+		suffix := fmt.Sprintf("latency_profile_%s_%s.txt", class, timeStamp)
+		fullPath, err := exportHistogram(path, suffix, h, ticks, valueScale)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		paths = append(paths, fullPath)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// exportHistogram writes the percentile distribution of a histogram to a
+// file under path and returns the full path of the written file.
+func exportHistogram(path, suffix string, h *hdrhistogram.Histogram, ticks int32, valueScale float64) (string, error) {
 	if path != "." {
 		if err := os.MkdirAll(path, 0700); err != nil {
 			return "", trace.Wrap(err)
@@ -156,6 +206,14 @@ func ExportLatencyProfile(path string, h *hdrhistogram.Histogram, ticks int32, v
 // to benchmark spec. It returns benchmark result when completed.
 // This is a blocking function that can be cancelled via context argument.
 func (c *Config) Benchmark(ctx context.Context, tc *client.TeleportClient) (Result, error) {
+	if c.ChaosRate < 0 || c.ChaosRate > 100 {
+		return Result{}, trace.BadParameter("chaos rate must be between 0 and 100")
+	}
+	chaosMaxDelay := c.ChaosMaxDelay
+	if chaosMaxDelay == 0 {
+		chaosMaxDelay = time.Second
+	}
+
 	tc.Stdout = io.Discard
 	tc.Stderr = io.Discard
 	tc.Stdin = &bytes.Buffer{}
@@ -170,6 +228,7 @@ func (c *Config) Benchmark(ctx context.Context, tc *client.TeleportClient) (Resu
 		interval := time.Duration(1 / float64(c.Rate) * float64(time.Second))
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 		start := time.Now()
 		for {
 			select {
@@ -183,6 +242,12 @@ func (c *Config) Benchmark(ctx context.Context, tc *client.TeleportClient) (Resu
 					command:       c.Command,
 					client:        tc,
 					interactive:   c.Interactive,
+					url:           c.URL,
+					httpClient:    c.HTTPClient,
+				}
+				if c.ChaosRate > 0 && rng.Intn(100) < c.ChaosRate {
+					measure.chaos = true
+					measure.chaosDelay = time.Duration(rng.Int63n(int64(chaosMaxDelay))) + time.Millisecond
 				}
 				go work(ctx, measure, resultC)
 			case <-ctx.Done():
@@ -194,6 +259,9 @@ func (c *Config) Benchmark(ctx context.Context, tc *client.TeleportClient) (Resu
 
 	var result Result
 	result.Histogram = hdrhistogram.New(minValue, maxValue, significantFigures)
+	result.StatusCodes = make(map[int]int)
+	result.FailuresByClass = make(map[string]int)
+	result.HistogramsByClass = make(map[string]*hdrhistogram.Histogram)
 	statusTicker := time.NewTicker(1 * time.Second)
 	timeElapsed := false
 	start := time.Now()
@@ -205,12 +273,28 @@ func (c *Config) Benchmark(ctx context.Context, tc *client.TeleportClient) (Resu
 		case measure := <-resultC:
 			result.Histogram.RecordValue(int64(measure.End.Sub(measure.ResponseStart) / time.Millisecond))
 			result.RequestsOriginated++
+			if measure.statusCode != 0 {
+				result.StatusCodes[measure.statusCode]++
+			}
 			if timeElapsed && result.RequestsOriginated >= c.MinimumMeasurements {
 				cancel()
 			}
-			if measure.Error != nil {
+			switch {
+			case measure.chaosKilled:
+				// Errors caused by a chaos kill are expected and tracked
+				// separately from real failures.
+				result.RequestsKilled++
+			case measure.Error != nil:
 				result.RequestsFailed++
 				result.LastError = measure.Error
+				class := classifyError(measure.Error)
+				result.FailuresByClass[class]++
+				classHistogram, ok := result.HistogramsByClass[class]
+				if !ok {
+					classHistogram = hdrhistogram.New(minValue, maxValue, significantFigures)
+					result.HistogramsByClass[class] = classHistogram
+				}
+				classHistogram.RecordValue(int64(measure.End.Sub(measure.ResponseStart) / time.Millisecond))
 			}
 		case <-ctx.Done():
 			result.Duration = time.Since(start)
@@ -229,10 +313,18 @@ type benchMeasure struct {
 	client        *client.TeleportClient
 	command       []string
 	interactive   bool
+	url           string
+	httpClient    *http.Client
+	statusCode    int
+	// chaos marks the request for a mid-flight kill after chaosDelay.
+	chaos      bool
+	chaosDelay time.Duration
+	// chaosKilled is set when the kill fired before the request finished.
+	chaosKilled bool
 }
 
 func work(ctx context.Context, m benchMeasure, send chan<- benchMeasure) {
-	m.Error = execute(m)
+	m.Error = execute(&m)
 	m.End = time.Now()
 	select {
 	case send <- m:
@@ -241,12 +333,28 @@ func work(ctx context.Context, m benchMeasure, send chan<- benchMeasure) {
 	}
 }
 
-func execute(m benchMeasure) error {
+func execute(m *benchMeasure) error {
+	// do not use parent context that will cancel in flight requests
+	// because we give test some time to gracefully wrap up
+	// the in-flight connections to avoid extra errors. Requests marked for
+	// chaos injection get their own context that is canceled mid-flight.
+	ctx := context.TODO()
+	if m.chaos {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		timer := time.AfterFunc(m.chaosDelay, cancel)
+		defer timer.Stop()
+		defer func() {
+			// Only count the kill if it fired before the request finished.
+			m.chaosKilled = ctx.Err() != nil
+		}()
+	}
+	if m.url != "" {
+		return executeHTTP(ctx, m)
+	}
 	if !m.interactive {
-		// do not use parent context that will cancel in flight requests
-		// because we give test some time to gracefully wrap up
-		// the in-flight connections to avoid extra errors
-		return m.client.SSH(context.TODO(), m.command, false)
+		return m.client.SSH(ctx, m.command, false)
 	}
 	config := m.client.Config
 	client, err := client.NewClient(&config)
@@ -260,7 +368,7 @@ func execute(m benchMeasure) error {
 	out := &utils.SyncBuffer{}
 	client.Stdout = out
 	client.Stderr = out
-	err = m.client.SSH(context.TODO(), nil, false)
+	err = m.client.SSH(ctx, nil, false)
 	if err != nil {
 		return err
 	}
@@ -268,6 +376,28 @@ func execute(m benchMeasure) error {
 	return nil
 }
 
+// executeHTTP issues a single HTTP GET request against the benchmark URL.
+// Responses with a 4xx or 5xx status code are counted as failures.
+func executeHTTP(ctx context.Context, m *benchMeasure) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	m.statusCode = resp.StatusCode
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return trace.Errorf("received HTTP status %v", resp.StatusCode)
+	}
+	return nil
+}
+
 // makeTeleportClient creates an instance of a teleport client
 func makeTeleportClient(host, login, proxy string) (*client.TeleportClient, error) {
 	c := client.Config{Host: host}
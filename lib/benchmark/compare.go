@@ -0,0 +1,78 @@
+/*
+Copyright 2022 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+)
+
+// Comparison holds results of running an identical workload against two
+// targets, e.g. a node with session recording enabled and one without, to
+// quantify recording overhead for capacity planning.
+type Comparison struct {
+	// Baseline is the result from the primary target.
+	Baseline Result
+	// Compared is the result from the comparison target.
+	Compared Result
+}
+
+// Compare runs the benchmark sequentially against both clients with a pause
+// in between so the runs do not contend for client-side resources. The same
+// Config (rate, window, command) is applied to both targets.
+func (c *Config) Compare(ctx context.Context, baselineTC, comparedTC *client.TeleportClient) (Comparison, error) {
+	var cmp Comparison
+	var err error
+	cmp.Baseline, err = c.Benchmark(ctx, baselineTC)
+	if err != nil {
+		return cmp, trace.Wrap(err)
+	}
+	select {
+	case <-time.After(pauseTimeBetweenBenchmarks):
+	case <-ctx.Done():
+		return cmp, trace.ConnectionProblem(ctx.Err(), "context canceled or timed out")
+	}
+	cmp.Compared, err = c.Benchmark(ctx, comparedTC)
+	if err != nil {
+		return cmp, trace.Wrap(err)
+	}
+	return cmp, nil
+}
+
+// LatencyDeltaAtQuantile returns the response duration difference in
+// milliseconds between the baseline and the comparison target at the given
+// quantile. A positive value means the baseline is slower.
+func (c *Comparison) LatencyDeltaAtQuantile(quantile float64) int64 {
+	return c.Baseline.Histogram.ValueAtQuantile(quantile) - c.Compared.Histogram.ValueAtQuantile(quantile)
+}
+
+// ThroughputDelta returns the difference in successful requests per second
+// between the baseline and the comparison target. A negative value means the
+// baseline sustained lower throughput.
+func (c *Comparison) ThroughputDelta() float64 {
+	return throughput(c.Baseline) - throughput(c.Compared)
+}
+
+func throughput(r Result) float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.RequestsOriginated-r.RequestsFailed) / r.Duration.Seconds()
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+)
+
+// Error classes that benchmark failures are aggregated under, so load test
+// reports show what actually broke instead of a single failure count.
+const (
+	// ErrorClassDialTimeout groups timeouts establishing or using the
+	// connection.
+	ErrorClassDialTimeout = "dial-timeout"
+	// ErrorClassAuth groups authentication and authorization failures.
+	ErrorClassAuth = "auth"
+	// ErrorClassExec groups remote commands that ran but exited abnormally.
+	ErrorClassExec = "exec"
+	// ErrorClassPrematureClose groups connections dropped mid-request.
+	ErrorClassPrematureClose = "premature-close"
+	// ErrorClassOther groups failures that match no other class.
+	ErrorClassOther = "other"
+)
+
+// classifyError maps a request failure to one of the error classes above.
+// Some transports only surface string errors, so classification falls back
+// to message matching when no typed error is available.
+func classifyError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassDialTimeout
+	case trace.IsAccessDenied(err):
+		return ErrorClassAuth
+	}
+	var exitErr *ssh.ExitError
+	var exitMissingErr *ssh.ExitMissingError
+	if errors.As(err, &exitErr) || errors.As(err, &exitMissingErr) {
+		return ErrorClassExec
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorClassPrematureClose
+	}
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "i/o timeout"),
+		strings.Contains(message, "timed out"):
+		return ErrorClassDialTimeout
+	case strings.Contains(message, "unable to authenticate"),
+		strings.Contains(message, "access denied"),
+		strings.Contains(message, "permission denied"):
+		return ErrorClassAuth
+	case strings.Contains(message, "exited with status"),
+		strings.Contains(message, "exec request failed"):
+		return ErrorClassExec
+	case strings.Contains(message, "connection reset by peer"),
+		strings.Contains(message, "broken pipe"),
+		strings.Contains(message, "use of closed network connection"),
+		strings.Contains(message, "unexpected eof"):
+		return ErrorClassPrematureClose
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Gravitational, Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		class string
+	}{
+		{
+			name:  "deadline exceeded",
+			err:   context.DeadlineExceeded,
+			class: ErrorClassDialTimeout,
+		},
+		{
+			name:  "io timeout message",
+			err:   errors.New("dial tcp 10.0.0.1:3022: i/o timeout"),
+			class: ErrorClassDialTimeout,
+		},
+		{
+			name:  "access denied",
+			err:   trace.AccessDenied("access denied to node"),
+			class: ErrorClassAuth,
+		},
+		{
+			name:  "ssh auth message",
+			err:   errors.New("ssh: unable to authenticate, attempted methods [publickey]"),
+			class: ErrorClassAuth,
+		},
+		{
+			name:  "exec exit status",
+			err:   errors.New("Process exited with status 127"),
+			class: ErrorClassExec,
+		},
+		{
+			name:  "premature eof",
+			err:   io.EOF,
+			class: ErrorClassPrematureClose,
+		},
+		{
+			name:  "connection reset",
+			err:   errors.New("read tcp 127.0.0.1:1024: connection reset by peer"),
+			class: ErrorClassPrematureClose,
+		},
+		{
+			name:  "unclassified",
+			err:   errors.New("something unexpected"),
+			class: ErrorClassOther,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.class, classifyError(tt.err))
+		})
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -106,6 +107,23 @@ const (
 	ForwardAgentLocal
 )
 
+// TTYRequestMode describes when a pseudo-terminal is allocated on the
+// remote machine, mirroring the OpenSSH RequestTTY option.
+type TTYRequestMode int
+
+const (
+	// TTYRequestAuto allocates a TTY for interactive shell sessions only.
+	// This is the default behavior.
+	TTYRequestAuto TTYRequestMode = iota
+	// TTYRequestYes allocates a TTY for remote command execution as well,
+	// as long as stdin is a terminal.
+	TTYRequestYes
+	// TTYRequestForce allocates a TTY even when stdin is not a terminal.
+	TTYRequestForce
+	// TTYRequestNo never allocates a TTY, including for shell sessions.
+	TTYRequestNo
+)
+
 var log = logrus.WithFields(logrus.Fields{
 	trace.Component: teleport.ComponentClient,
 })
@@ -189,6 +207,10 @@ type Config struct {
 	// HostLogin is a user login on a remote host
 	HostLogin string
 
+	// NodeLogins remembers the last successful login used per node or label
+	// set, keyed by the node name as given on the command line.
+	NodeLogins map[string]string
+
 	// HostPort is a remote host port to connect to. This is used for **explicit**
 	// port setting via -p flag, otherwise '0' is passed which means "use server default"
 	HostPort int
@@ -221,6 +243,13 @@ type Config struct {
 	// InsecureSkipVerify is an option to skip HTTPS cert check
 	InsecureSkipVerify bool
 
+	// TOFU enables trust-on-first-use pinning of the proxy TLS certificate:
+	// the certificate fingerprint is recorded on the first connection and
+	// enforced on every TLS connection the client makes after that, failing
+	// the handshake when it changes. It implies InsecureSkipVerify for CA
+	// verification, trust comes from the pin instead.
+	TOFU bool
+
 	// SkipLocalAuth tells the client to use AuthMethods parameter for authentication and NOT
 	// use its own SSH agent or ask user for passwords. This is used by external programs linking
 	// against Teleport client and obtaining credentials from elsewhere.
@@ -284,6 +313,14 @@ type Config struct {
 	// (parameters to -L ssh flag).
 	LocalForwardPorts ForwardedPorts
 
+	// ForwardConnLimit caps the number of concurrent connections accepted on
+	// each forwarded port, zero means no limit.
+	ForwardConnLimit int
+
+	// ForwardBytesPerSecond caps the throughput of each forwarded port in
+	// bytes per second, zero means no limit.
+	ForwardBytesPerSecond int64
+
 	// DynamicForwardedPorts are the list of ports tsh listens on for dynamic
 	// port forwarding (parameters to -D ssh flag).
 	DynamicForwardedPorts DynamicForwardedPorts
@@ -300,10 +337,26 @@ type Config struct {
 	// Env is a map of environmnent variables to send when opening session
 	Env map[string]string
 
+	// InjectSessionEnv, when set, injects Teleport session metadata
+	// (TELEPORT_USER, TELEPORT_ROLES, TELEPORT_CLUSTER) as environment
+	// variables into started sessions.
+	InjectSessionEnv bool
+
+	// TraitsToEnv maps user trait names to environment variable names that
+	// are injected into started sessions. A trait with multiple values is
+	// joined with commas.
+	TraitsToEnv map[string]string
+
 	// Interactive, when set to true, tells tsh to launch a remote command
 	// in interactive mode, i.e. attaching the temrinal to it
 	Interactive bool
 
+	// RequestTTY controls when a pseudo-terminal is allocated on the remote
+	// node, mirroring the OpenSSH RequestTTY option. The zero value
+	// (TTYRequestAuto) preserves the historic behavior of allocating a TTY
+	// for shell sessions, and for commands when Interactive is set.
+	RequestTTY TTYRequestMode
+
 	// ClientAddr (if set) specifies the true client IP. Usually it's not needed (since the server
 	// can look at the connecting address to determine client's IP) but for cases when the
 	// client is web-based, this must be set to HTTP's remote addr
@@ -316,6 +369,10 @@ type Config struct {
 	// CertificateFormat is the format of the SSH certificate.
 	CertificateFormat string
 
+	// KeyAlgorithm is the algorithm used to generate the client key pair on
+	// login. Empty means the default algorithm.
+	KeyAlgorithm string
+
 	// AuthConnector is the name of the authentication connector to use.
 	AuthConnector string
 
@@ -352,6 +409,15 @@ type Config struct {
 	// MockSSOLogin is used in tests for mocking the SSO login response.
 	MockSSOLogin SSOLoginFunc
 
+	// PromptUI overrides how interactive prompts (passwords, OTP tokens,
+	// MFA challenges, confirmations) are presented to the user. When nil
+	// the prompts are read from the process terminal.
+	PromptUI PromptUI
+
+	// StrictHostChecking displays the node identity on the first connection
+	// to a node and requires confirmation before connecting.
+	StrictHostChecking bool
+
 	// HomePath is where tsh stores profiles
 	HomePath string
 
@@ -863,6 +929,7 @@ func (c *Config) LoadProfile(profileDir string, proxyName string) error {
 	c.MySQLProxyAddr = cp.MySQLProxyAddr
 	c.MongoProxyAddr = cp.MongoProxyAddr
 	c.TLSRoutingEnabled = cp.TLSRoutingEnabled
+	c.NodeLogins = cp.NodeLogins
 	c.KeysDir = profileDir
 
 	c.LocalForwardPorts, err = ParsePortForwardSpec(cp.ForwardedPorts)
@@ -898,6 +965,7 @@ func (c *Config) SaveProfile(dir string, makeCurrent bool) error {
 	cp.ForwardedPorts = c.LocalForwardPorts.String()
 	cp.SiteName = c.SiteName
 	cp.TLSRoutingEnabled = c.TLSRoutingEnabled
+	cp.NodeLogins = c.NodeLogins
 
 	if err := cp.SaveToDir(dir, makeCurrent); err != nil {
 		return trace.Wrap(err)
@@ -1128,6 +1196,11 @@ type TeleportClient struct {
 	// Note: there's no mutex guarding this or localAgent, making
 	// TeleportClient NOT safe for concurrent use.
 	lastPing *webclient.PingResponse
+
+	// resolver resolves dialed addresses, honoring the optional hosts
+	// override file in the profile directory. It is created on first use.
+	resolver     *addrResolver
+	resolverOnce sync.Once
 }
 
 // ShellCreatedCallback can be supplied for every teleport client. It will
@@ -1165,6 +1238,13 @@ func NewClient(c *Config) (tc *TeleportClient, err error) {
 	}
 	c.Namespace = types.ProcessNamespace(c.Namespace)
 
+	// TOFU replaces CA verification with fingerprint pinning: the standard
+	// certificate chain check is skipped, and the pin is enforced on every
+	// connection through a VerifyConnection callback instead.
+	if c.TOFU {
+		c.InsecureSkipVerify = true
+	}
+
 	tc = &TeleportClient{Config: *c}
 
 	if tc.Stdout == nil {
@@ -1219,6 +1299,9 @@ func NewClient(c *Config) (tc *TeleportClient, err error) {
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+		if c.PromptUI != nil {
+			tc.localAgent.hostPromptFunc = tc.promptHostKey
+		}
 		if tc.HostKeyCallback == nil {
 			tc.HostKeyCallback = tc.localAgent.CheckHostSignature
 		}
@@ -1440,6 +1523,13 @@ func (tc *TeleportClient) WithRootClusterClient(ctx context.Context, do func(clt
 //
 // Returns nil if successful, or (possibly) *exec.ExitError
 func (tc *TeleportClient) SSH(ctx context.Context, command []string, runLocally bool) error {
+	ctx, span := startSpan(ctx, "teleportClient/SSH")
+	err := tc.ssh(ctx, command, runLocally)
+	span(err)
+	return trace.Wrap(err)
+}
+
+func (tc *TeleportClient) ssh(ctx context.Context, command []string, runLocally bool) error {
 	// connect to proxy first:
 	if !tc.Config.ProxySpecified() {
 		return trace.BadParameter("proxy server is not specified")
@@ -1524,7 +1614,8 @@ func (tc *TeleportClient) startPortForwarding(ctx context.Context, nodeClient *N
 				log.Errorf("Failed to bind to %v: %v.", addr, err)
 				continue
 			}
-			go nodeClient.listenAndForward(ctx, socket, net.JoinHostPort(fp.DestHost, strconv.Itoa(fp.DestPort)))
+			stats := nodeClient.trackForward(fp.ToString(), false)
+			go nodeClient.listenAndForward(ctx, socket, net.JoinHostPort(fp.DestHost, strconv.Itoa(fp.DestPort)), stats)
 		}
 	}
 	if len(tc.Config.DynamicForwardedPorts) > 0 {
@@ -1535,7 +1626,8 @@ func (tc *TeleportClient) startPortForwarding(ctx context.Context, nodeClient *N
 				log.Errorf("Failed to bind to %v: %v.", addr, err)
 				continue
 			}
-			go nodeClient.dynamicListenAndForward(ctx, socket)
+			stats := nodeClient.trackForward(fp.ToString(), true)
+			go nodeClient.dynamicListenAndForward(ctx, socket, stats)
 		}
 	}
 }
@@ -1809,6 +1901,12 @@ func (tc *TeleportClient) SCP(ctx context.Context, args []string, port int, flag
 		ProgressWriter: progressWriter,
 		Flags:          flags,
 	}
+	if flags.Recursive || (flags.Update && isRemoteDest(last)) {
+		// The manifest lets retried attempts skip files that were already
+		// acknowledged by the remote side. With --update it additionally
+		// pre-records files whose destination checksum already matches.
+		tpl.Manifest = scp.NewManifest()
+	}
 
 	var config *scpConfig
 	// upload:
@@ -1824,12 +1922,72 @@ func (tc *TeleportClient) SCP(ctx context.Context, args []string, port int, flag
 		}
 	}
 
-	client, err := connectToNode(config.addr, config.hostLogin)
-	if err != nil {
-		return trace.Wrap(err)
+	// With --update, ask the destination node for checksums of the files
+	// about to be uploaded and skip the unchanged ones. The comparison is
+	// best-effort: if it fails for any reason, everything is copied.
+	if flags.Update && isRemoteDest(last) {
+		dest, err := scp.ParseSCPDestination(last)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		client, err := connectToNode(config.addr, config.hostLogin)
+		if err != nil {
+			log.WithError(err).Debug("Failed to connect for destination checksum comparison.")
+		} else {
+			err = markUnchangedFiles(ctx, client, tpl.Manifest, args[:len(args)-1], dest.Path, flags.Recursive, progressWriter)
+			if err != nil {
+				log.WithError(err).Debug("Failed to compare destination checksums, copying everything.")
+			}
+			client.Close()
+		}
 	}
 
-	return onError(client.ExecuteSCP(ctx, config.cmd))
+	attempt := func() error {
+		client, err := connectToNode(config.addr, config.hostLogin)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer client.Close()
+		return trace.Wrap(client.ExecuteSCP(ctx, config.cmd))
+	}
+
+	if !flags.Recursive {
+		return onError(attempt())
+	}
+
+	// Recursive copies can span thousands of files, so a dropped connection
+	// retries with exponential backoff instead of abandoning the transfer.
+	// The manifest ensures completed files are not sent again.
+	backoff := time.Second
+	for attemptNo := 1; ; attemptNo++ {
+		err = attempt()
+		if err == nil || attemptNo >= scpMaxAttempts || !isRetryableSCPError(err) {
+			return onError(err)
+		}
+		log.WithError(err).Warningf("File copy interrupted, retrying in %v.", backoff)
+		select {
+		case <-ctx.Done():
+			return onError(err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// scpMaxAttempts is the maximum number of times a recursive file copy is
+// attempted before giving up.
+const scpMaxAttempts = 3
+
+// isRetryableSCPError returns true if the file copy failed due to a
+// connection problem rather than a protocol or file system error.
+func isRetryableSCPError(err error) bool {
+	if trace.IsConnectionProblem(err) || utils.IsOKNetworkError(err) {
+		return true
+	}
+	// The session ending without an exit status indicates the connection
+	// was dropped mid-transfer.
+	var missingErr *ssh.ExitMissingError
+	return errors.As(err, &missingErr)
 }
 
 func (tc *TeleportClient) uploadConfig(ctx context.Context, tpl scp.Config, port int, args []string) (config *scpConfig, err error) {
@@ -1909,6 +2067,13 @@ func isRemoteDest(name string) bool {
 
 // ListNodesWithFilters returns a list of nodes connected to a proxy
 func (tc *TeleportClient) ListNodesWithFilters(ctx context.Context) ([]types.Server, error) {
+	ctx, span := startSpan(ctx, "teleportClient/ListNodesWithFilters")
+	servers, err := tc.listNodesWithFilters(ctx)
+	span(err)
+	return servers, trace.Wrap(err)
+}
+
+func (tc *TeleportClient) listNodesWithFilters(ctx context.Context) ([]types.Server, error) {
 	// connect to the proxy and ask it to return a full list of servers
 	proxyClient, err := tc.ConnectToProxy(ctx)
 	if err != nil {
@@ -2079,12 +2244,36 @@ func (tc *TeleportClient) runCommandOnNodes(
 
 // runCommand executes a given bash command on an established NodeClient.
 func (tc *TeleportClient) runCommand(ctx context.Context, nodeClient *NodeClient, command []string) error {
-	nodeSession, err := newSession(nodeClient, nil, tc.Config.Env, tc.Stdin, tc.Stdout, tc.Stderr, tc.useLegacyID(nodeClient), tc.EnableEscapeSequences)
+	env := tc.sessionEnvironment()
+	if tc.Config.Reason != "" {
+		env[teleport.EnvSSHSessionReason] = tc.Config.Reason
+	}
+	for key, value := range tc.Config.Env {
+		env[key] = value
+	}
+
+	nodeSession, err := newSession(nodeClient, nil, env, tc.Stdin, tc.Stdout, tc.Stderr, tc.useLegacyID(nodeClient), tc.EnableEscapeSequences)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer nodeSession.Close()
-	if err := nodeSession.runCommand(ctx, types.SessionPeerMode, command, tc.OnShellCreated, tc.Config.Interactive); err != nil {
+
+	// Apply the RequestTTY matrix on top of the Interactive flag: "yes" and
+	// "force" allocate a TTY for the command, "no" never allocates one, and
+	// "force" additionally skips the stdin terminal check.
+	interactive := tc.Config.Interactive
+	forceTTY := false
+	switch tc.Config.RequestTTY {
+	case TTYRequestYes:
+		interactive = true
+	case TTYRequestForce:
+		interactive = true
+		forceTTY = true
+	case TTYRequestNo:
+		interactive = false
+	}
+
+	if err := nodeSession.runCommand(ctx, types.SessionPeerMode, command, tc.OnShellCreated, interactive, forceTTY); err != nil {
 		originErr := trace.Unwrap(err)
 		exitErr, ok := originErr.(*ssh.ExitError)
 		if ok {
@@ -2117,6 +2306,9 @@ func (tc *TeleportClient) runShell(ctx context.Context, nodeClient *NodeClient,
 	}
 
 	env[teleport.EnvSSHSessionInvited] = string(encoded)
+	for key, value := range tc.sessionEnvironment() {
+		env[key] = value
+	}
 	for key, value := range tc.Env {
 		env[key] = value
 	}
@@ -2125,6 +2317,23 @@ func (tc *TeleportClient) runShell(ctx context.Context, nodeClient *NodeClient,
 	if err != nil {
 		return trace.Wrap(err)
 	}
+
+	// RequestTTY=no runs the shell without a server-side terminal, mirroring
+	// the OpenSSH `ssh -T` behavior. This only applies to new sessions, a
+	// joined session always assumes the terminal of the session it joins.
+	if sessToJoin == nil && tc.Config.RequestTTY == TTYRequestNo {
+		if err = nodeSession.runNonInteractiveShell(ctx, tc.OnShellCreated); err != nil {
+			switch e := trace.Unwrap(err).(type) {
+			case *ssh.ExitError:
+				tc.ExitStatus = e.ExitStatus()
+			case *ssh.ExitMissingError:
+				tc.ExitStatus = 1
+			}
+			return trace.Wrap(err)
+		}
+		return nil
+	}
+
 	if err = nodeSession.runShell(ctx, mode, beforeStart, tc.OnShellCreated); err != nil {
 		switch e := trace.Unwrap(err).(type) {
 		case *ssh.ExitError:
@@ -2143,6 +2352,33 @@ func (tc *TeleportClient) runShell(ctx context.Context, nodeClient *NodeClient,
 	return nil
 }
 
+// sessionEnvironment returns environment variables describing the logged in
+// user and cluster, along with any configured trait mappings, to be injected
+// into started sessions. It returns an empty map when injection is disabled
+// or the profile cannot be read.
+func (tc *TeleportClient) sessionEnvironment() map[string]string {
+	env := make(map[string]string)
+	if !tc.InjectSessionEnv && len(tc.TraitsToEnv) == 0 {
+		return env
+	}
+	status, err := StatusCurrent(tc.HomePath, tc.WebProxyAddr)
+	if err != nil {
+		log.WithError(err).Debug("Unable to read profile status for session environment injection.")
+		return env
+	}
+	if tc.InjectSessionEnv {
+		env[teleport.EnvSSHSessionUser] = status.Username
+		env[teleport.EnvSSHSessionRoles] = strings.Join(status.Roles, ",")
+		env[teleport.EnvSSHSessionCluster] = status.Cluster
+	}
+	for trait, evar := range tc.TraitsToEnv {
+		if values := status.Traits[trait]; len(values) > 0 {
+			env[evar] = strings.Join(values, ",")
+		}
+	}
+	return env
+}
+
 // getProxyLogin determines which SSH principal to use when connecting to proxy.
 func (tc *TeleportClient) getProxySSHPrincipal() string {
 	proxyPrincipal := tc.Config.HostLogin
@@ -2171,6 +2407,7 @@ func (tc *TeleportClient) getProxySSHPrincipal() string {
 // successful. If the passed in context is canceled, this function will return
 // a trace.ConnectionProblem right away.
 func (tc *TeleportClient) ConnectToProxy(ctx context.Context) (*ProxyClient, error) {
+	ctx, span := startSpan(ctx, "teleportClient/ConnectToProxy")
 	var err error
 	var proxyClient *ProxyClient
 
@@ -2185,10 +2422,12 @@ func (tc *TeleportClient) ConnectToProxy(ctx context.Context) (*ProxyClient, err
 	select {
 	// ConnectToProxy returned a result, return that back to the caller.
 	case <-connectContext.Done():
+		span(err)
 		return proxyClient, trace.Wrap(err)
 	// The passed in context timed out. This is often due to the network being
 	// down and the user hitting Ctrl-C.
 	case <-ctx.Done():
+		span(ctx.Err())
 		return nil, trace.ConnectionProblem(ctx.Err(), "connection canceled")
 	}
 }
@@ -2301,7 +2540,11 @@ func makeProxySSHClient(ctx context.Context, tc *TeleportClient, sshConfig *ssh.
 	if len(tc.JumpHosts) > 0 {
 		sshProxyAddr = tc.JumpHosts[0].Addr.Addr
 		// Check if JumpHost address is a proxy web address.
-		resp, err := webclient.Find(&webclient.Config{Context: ctx, ProxyAddr: sshProxyAddr, Insecure: tc.InsecureSkipVerify})
+		resp, err := webclient.Find(&webclient.Config{
+			Context:          ctx,
+			ProxyAddr:        sshProxyAddr,
+			Insecure:         tc.InsecureSkipVerify,
+			VerifyConnection: tc.tofuVerifyConnection()})
 		// If JumpHost address is a proxy web port and proxy supports TLSRouting dial proxy with TLSWrapper.
 		if err == nil && resp.Proxy.TLSRoutingEnabled {
 			log.Infof("Connecting to proxy=%v login=%q using TLS Routing JumpHost", sshProxyAddr, sshConfig.User)
@@ -2314,6 +2557,17 @@ func makeProxySSHClient(ctx context.Context, tc *TeleportClient, sshConfig *ssh.
 		}
 	}
 
+	// When both the SSH and the web proxy address are known, dial them in
+	// parallel and keep whichever route connects first, instead of paying for
+	// a sequential probe on every command.
+	if len(tc.JumpHosts) == 0 && tc.Config.WebProxyAddr != "" && tc.Config.WebProxyAddr != sshProxyAddr {
+		client, err := makeProxySSHClientParallel(ctx, tc, sshConfig, sshProxyAddr)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to authenticate with proxy %v", sshProxyAddr)
+		}
+		return client, nil
+	}
+
 	log.Infof("Connecting to proxy=%v login=%q", sshProxyAddr, sshConfig.User)
 	client, err := makeProxySSHClientDirect(tc, sshConfig, sshProxyAddr)
 	if err != nil {
@@ -2323,9 +2577,67 @@ func makeProxySSHClient(ctx context.Context, tc *TeleportClient, sshConfig *ssh.
 	return client, nil
 }
 
+// proxyDialResult is the outcome of a single candidate dial attempted by
+// makeProxySSHClientParallel.
+type proxyDialResult struct {
+	client     *ssh.Client
+	tlsRouting bool
+	err        error
+}
+
+// makeProxySSHClientParallel dials the raw SSH proxy port and the web proxy
+// port (via TLS routing) in parallel, in the spirit of the RFC-8305 "Happy
+// Eyeballs" algorithm, and returns whichever client connects first. The
+// winning route is cached on the client config, so subsequent connections in
+// the same process, and the profile once saved, go straight to it.
+func makeProxySSHClientParallel(ctx context.Context, tc *TeleportClient, sshConfig *ssh.ClientConfig, sshProxyAddr string) (*ssh.Client, error) {
+	log.Infof("Connecting to proxy=%v login=%q, racing against TLS Routing to proxy=%v", sshProxyAddr, sshConfig.User, tc.Config.WebProxyAddr)
+	resultC := make(chan proxyDialResult, 2)
+	go func() {
+		c, err := makeProxySSHClientDirect(tc, sshConfig, sshProxyAddr)
+		resultC <- proxyDialResult{client: c, err: err}
+	}()
+	go func() {
+		c, err := makeProxySSHClientWithTLSWrapper(ctx, tc, sshConfig, tc.Config.WebProxyAddr)
+		resultC <- proxyDialResult{client: c, tlsRouting: true, err: err}
+	}()
+
+	var firstErr error
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-resultC:
+			if r.err != nil {
+				if firstErr == nil {
+					firstErr = r.err
+				}
+				continue
+			}
+			if i == 0 {
+				// Close the slower connection once it resolves.
+				go func() {
+					if loser := <-resultC; loser.client != nil {
+						loser.client.Close()
+					}
+				}()
+			}
+			// Remember the route that won so it is dialed directly next time.
+			tc.Config.TLSRoutingEnabled = r.tlsRouting
+			if r.tlsRouting {
+				log.Infof("Successful auth with proxy %v using TLS Routing.", tc.Config.WebProxyAddr)
+			} else {
+				log.Infof("Successful auth with proxy %v.", sshProxyAddr)
+			}
+			return r.client, nil
+		case <-ctx.Done():
+			return nil, trace.ConnectionProblem(ctx.Err(), "context canceled or timed out")
+		}
+	}
+	return nil, trace.Wrap(firstErr)
+}
+
 func makeProxySSHClientDirect(tc *TeleportClient, sshConfig *ssh.ClientConfig, proxyAddr string) (*ssh.Client, error) {
 	dialer := proxy.DialerFromEnvironment(tc.Config.SSHProxyAddr)
-	return dialer.Dial("tcp", proxyAddr, sshConfig)
+	return dialer.Dial("tcp", tc.resolveAddr(proxyAddr), sshConfig)
 }
 
 func makeProxySSHClientWithTLSWrapper(ctx context.Context, tc *TeleportClient, sshConfig *ssh.ClientConfig, proxyAddr string) (*ssh.Client, error) {
@@ -2335,8 +2647,14 @@ func makeProxySSHClientWithTLSWrapper(ctx context.Context, tc *TeleportClient, s
 	}
 
 	tlsConfig.NextProtos = []string{string(alpncommon.ProtocolProxySSH)}
+	// Pin the server name to the configured proxy host so certificate
+	// validation keeps working when the hosts override file rewrites the
+	// dialed address.
+	if host, _, err := net.SplitHostPort(proxyAddr); err == nil {
+		tlsConfig.ServerName = host
+	}
 	dialer := proxy.DialerFromEnvironment(tc.Config.WebProxyAddr, proxy.WithALPNDialer(tlsConfig))
-	return dialer.Dial("tcp", proxyAddr, sshConfig)
+	return dialer.Dial("tcp", tc.resolveAddr(proxyAddr), sshConfig)
 }
 
 func (tc *TeleportClient) rootClusterName() (string, error) {
@@ -2482,7 +2800,7 @@ func (tc *TeleportClient) PingAndShowMOTD(ctx context.Context) (*webclient.PingR
 
 // GetWebConfig retreives Teleport proxy web config
 func (tc *TeleportClient) GetWebConfig(ctx context.Context) (*webclient.WebConfig, error) {
-	cfg, err := GetWebConfig(ctx, tc.WebProxyAddr, tc.InsecureSkipVerify)
+	cfg, err := GetWebConfig(ctx, tc.WebProxyAddr, tc.InsecureSkipVerify, tc.tofuVerifyConnection())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -2499,6 +2817,13 @@ func (tc *TeleportClient) GetWebConfig(ctx context.Context) (*webclient.WebConfi
 // The returned Key should typically be passed to ActivateKey in order to
 // update local agent state.
 func (tc *TeleportClient) Login(ctx context.Context) (*Key, error) {
+	ctx, span := startSpan(ctx, "teleportClient/Login")
+	key, err := tc.login(ctx)
+	span(err)
+	return key, trace.Wrap(err)
+}
+
+func (tc *TeleportClient) login(ctx context.Context) (*Key, error) {
 	// Ping the endpoint to see if it's up and find the type of authentication
 	// supported, also show the message of the day if available.
 	pr, err := tc.PingAndShowMOTD(ctx)
@@ -2508,7 +2833,7 @@ func (tc *TeleportClient) Login(ctx context.Context) (*Key, error) {
 
 	// generate a new keypair. the public key will be signed via proxy if client's
 	// password+OTP are valid
-	key, err := NewKey()
+	key, err := NewKeyWithAlgorithm(tc.KeyAlgorithm)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -2593,7 +2918,7 @@ func (tc *TeleportClient) Login(ctx context.Context) (*Key, error) {
 }
 
 func (tc *TeleportClient) pwdlessLogin(ctx context.Context, pubKey []byte) (*auth.SSHLoginResponse, error) {
-	webClient, webURL, err := initClient(tc.WebProxyAddr, tc.InsecureSkipVerify, loopbackPool(tc.WebProxyAddr))
+	webClient, webURL, err := initClient(tc.WebProxyAddr, tc.InsecureSkipVerify, loopbackPool(tc.WebProxyAddr), tc.tofuVerifyConnection())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -2702,6 +3027,7 @@ func (tc *TeleportClient) directLogin(ctx context.Context, secondFactorType cons
 			Compatibility:     tc.CertificateFormat,
 			RouteToCluster:    tc.SiteName,
 			KubernetesCluster: tc.KubernetesCluster,
+			VerifyConnection:  tc.tofuVerifyConnection(),
 		},
 		User:     tc.Username,
 		Password: password,
@@ -2728,6 +3054,7 @@ func (tc *TeleportClient) mfaLocalLogin(ctx context.Context, pub []byte) (*auth.
 			Compatibility:     tc.CertificateFormat,
 			RouteToCluster:    tc.SiteName,
 			KubernetesCluster: tc.KubernetesCluster,
+			VerifyConnection:  tc.tofuVerifyConnection(),
 		},
 		User:                    tc.Username,
 		Password:                password,
@@ -2758,6 +3085,7 @@ func (tc *TeleportClient) ssoLogin(ctx context.Context, connectorID string, pub
 			Compatibility:     tc.CertificateFormat,
 			RouteToCluster:    tc.SiteName,
 			KubernetesCluster: tc.KubernetesCluster,
+			VerifyConnection:  tc.tofuVerifyConnection(),
 		},
 		ConnectorID: connectorID,
 		Protocol:    protocol,
@@ -2826,27 +3154,20 @@ func (tc *TeleportClient) Ping(ctx context.Context) (*webclient.PingResponse, er
 		return tc.lastPing, nil
 	}
 	pr, err := webclient.Ping(&webclient.Config{
-		Context:       ctx,
-		ProxyAddr:     tc.WebProxyAddr,
-		Insecure:      tc.InsecureSkipVerify,
-		Pool:          loopbackPool(tc.WebProxyAddr),
-		ConnectorName: tc.AuthConnector,
-		ExtraHeaders:  tc.ExtraProxyHeaders})
+		Context:          ctx,
+		ProxyAddr:        tc.WebProxyAddr,
+		Insecure:         tc.InsecureSkipVerify,
+		Pool:             loopbackPool(tc.WebProxyAddr),
+		ConnectorName:    tc.AuthConnector,
+		ExtraHeaders:     tc.ExtraProxyHeaders,
+		VerifyConnection: tc.tofuVerifyConnection()})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
 	// If version checking was requested and the server advertises a minimum version.
 	if tc.CheckVersions && pr.MinClientVersion != "" {
-		if err := utils.CheckVersion(teleport.Version, pr.MinClientVersion); err != nil && trace.IsBadParameter(err) {
-			fmt.Fprintf(tc.Config.Stderr, `
-			WARNING
-			Detected potentially incompatible client and server versions.
-			Minimum client version supported by the server is %v but you are using %v.
-			Please upgrade tsh to %v or newer or use the --skip-version-check flag to bypass this check.
-			Future versions of tsh will fail when incompatible versions are detected.
-			`, pr.MinClientVersion, teleport.Version, pr.MinClientVersion)
-		}
+		printVersionCheckWarning(tc.Config.Stderr, pr)
 	}
 
 	// Update tc with proxy settings specified in Ping response.
@@ -2864,11 +3185,12 @@ func (tc *TeleportClient) Ping(ctx context.Context) (*webclient.PingResponse, er
 func (tc *TeleportClient) ShowMOTD(ctx context.Context) error {
 	motd, err := webclient.GetMOTD(
 		&webclient.Config{
-			Context:      ctx,
-			ProxyAddr:    tc.WebProxyAddr,
-			Insecure:     tc.InsecureSkipVerify,
-			Pool:         loopbackPool(tc.WebProxyAddr),
-			ExtraHeaders: tc.ExtraProxyHeaders})
+			Context:          ctx,
+			ProxyAddr:        tc.WebProxyAddr,
+			Insecure:         tc.InsecureSkipVerify,
+			Pool:             loopbackPool(tc.WebProxyAddr),
+			ExtraHeaders:     tc.ExtraProxyHeaders,
+			VerifyConnection: tc.tofuVerifyConnection()})
 
 	if err != nil {
 		return trace.Wrap(err)
@@ -3185,8 +3507,8 @@ func loopbackPool(proxyAddr string) *x509.CertPool {
 	return certPool
 }
 
-// connectToSSHAgent connects to the system SSH agent and returns an agent.Agent.
-func connectToSSHAgent() agent.Agent {
+// ConnectToSSHAgent connects to the system SSH agent and returns an agent.Agent.
+func ConnectToSSHAgent() agent.Agent {
 	socketPath := os.Getenv(teleport.SSHAuthSock)
 	conn, err := agentconn.Dial(socketPath)
 	if err != nil {
@@ -3220,13 +3542,13 @@ func Username() (string, error) {
 
 // AskOTP prompts the user to enter the OTP token.
 func (tc *TeleportClient) AskOTP(ctx context.Context) (token string, err error) {
-	return prompt.Password(ctx, tc.Stderr, prompt.Stdin(), "Enter your OTP token")
+	return tc.promptUI().AskOTP(ctx, "Enter your OTP token")
 }
 
 // AskPassword prompts the user to enter the password
 func (tc *TeleportClient) AskPassword(ctx context.Context) (pwd string, err error) {
-	return prompt.Password(
-		ctx, tc.Stderr, prompt.Stdin(), fmt.Sprintf("Enter password for Teleport user %v", tc.Config.Username))
+	return tc.promptUI().AskPassword(
+		ctx, fmt.Sprintf("Enter password for Teleport user %v", tc.Config.Username))
 }
 
 // DELETE IN: 4.1.0
@@ -3274,7 +3596,9 @@ func (tc *TeleportClient) getServerVersion(nodeClient *NodeClient) (string, erro
 func (tc *TeleportClient) loadTLSConfig() (*tls.Config, error) {
 	// if SkipLocalAuth flag is set use an external identity file instead of loading cert from the local agent.
 	if tc.SkipLocalAuth {
-		return tc.TLS.Clone(), nil
+		tlsConfig := tc.TLS.Clone()
+		tc.applyTOFU(tlsConfig)
+		return tlsConfig, nil
 	}
 
 	tlsKey, err := tc.localAgent.GetCoreKey()
@@ -3295,6 +3619,7 @@ func (tc *TeleportClient) loadTLSConfig() (*tls.Config, error) {
 	if err != nil {
 		return nil, trace.Wrap(err, "failed to generate client TLS config")
 	}
+	tc.applyTOFU(tlsConfig)
 	return tlsConfig, nil
 }
 
@@ -3514,6 +3839,12 @@ func playSession(sessionEvents []events.EventFields, stream []byte) error {
 		if err != nil {
 			return trace.Wrap(err)
 		}
+		// Play back on the alternate screen so the replayed output does
+		// not flood the local scrollback; closing the terminal restores
+		// the screen contents from before playback started.
+		if err := term.EnterAlternateScreen(); err != nil {
+			log.WithError(err).Debug("Failed to enter alternate screen.")
+		}
 	}
 
 	player := newSessionPlayer(sessionEvents, stream, term)
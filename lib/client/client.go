@@ -28,6 +28,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -72,6 +73,28 @@ type NodeClient struct {
 	Proxy     *ProxyClient
 	TC        *TeleportClient
 	OnMFA     func()
+
+	// forwardStatsMu guards forwardStats.
+	forwardStatsMu sync.Mutex
+	// forwardStats tracks usage counters for the active port forwards.
+	forwardStats []*ForwardStats
+}
+
+// trackForward registers a port forward for stats tracking and returns its
+// counters.
+func (c *NodeClient) trackForward(spec string, dynamic bool) *ForwardStats {
+	stats := &ForwardStats{Spec: spec, Dynamic: dynamic}
+	c.forwardStatsMu.Lock()
+	c.forwardStats = append(c.forwardStats, stats)
+	c.forwardStatsMu.Unlock()
+	return stats
+}
+
+// ForwardStats returns the stats of all active port forwards.
+func (c *NodeClient) ForwardStats() []*ForwardStats {
+	c.forwardStatsMu.Lock()
+	defer c.forwardStatsMu.Unlock()
+	return append([]*ForwardStats(nil), c.forwardStats...)
 }
 
 // GetSites returns list of the "sites" (AKA teleport clusters) connected to the proxy
@@ -143,6 +166,12 @@ type ReissueParams struct {
 	RouteToApp            proto.RouteToApp
 	RouteToWindowsDesktop proto.RouteToWindowsDesktop
 
+	// RequireMFA forces the single-use MFA certificate flow even when the
+	// server does not report MFA as required for the target. Used for
+	// targets like apps whose per-role MFA requirement cannot be checked
+	// upfront over the protocol.
+	RequireMFA bool
+
 	// ExistingCreds is a gross hack for lib/web/terminal.go to pass in
 	// existing user credentials. The TeleportClient in lib/web/terminal.go
 	// doesn't have a real LocalKeystore and keeps all certs in memory.
@@ -345,20 +374,26 @@ func (proxy *ProxyClient) IssueUserCertsWithMFA(ctx context.Context, params Reis
 	}
 	defer clt.Close()
 
-	requiredCheck, err := clt.IsMFARequired(ctx, params.isMFARequiredRequest(proxy.hostLogin))
-	if err != nil {
-		if trace.IsNotImplemented(err) {
-			// Probably talking to an older server, use the old non-MFA endpoint.
-			log.WithError(err).Debug("Auth server does not implement IsMFARequired.")
-			// SSH certs can be used without reissuing.
-			if params.usage() == proto.UserCertsRequest_SSH && key.Cert != nil {
-				return key, nil
+	required := params.RequireMFA
+	if !required {
+		requiredCheck, err := clt.IsMFARequired(ctx, params.isMFARequiredRequest(proxy.hostLogin))
+		if err != nil {
+			if trace.IsNotImplemented(err) || trace.IsBadParameter(err) {
+				// Probably talking to an older server that either does not
+				// implement IsMFARequired or rejects requests without an
+				// expressible target (apps), use the old non-MFA endpoint.
+				log.WithError(err).Debug("Could not check whether MFA is required.")
+				// SSH certs can be used without reissuing.
+				if params.usage() == proto.UserCertsRequest_SSH && key.Cert != nil {
+					return key, nil
+				}
+				return proxy.reissueUserCerts(ctx, CertCacheKeep, params)
 			}
-			return proxy.reissueUserCerts(ctx, CertCacheKeep, params)
+			return nil, trace.Wrap(err)
 		}
-		return nil, trace.Wrap(err)
+		required = requiredCheck.Required
 	}
-	if !requiredCheck.Required {
+	if !required {
 		log.Debug("MFA not required for access.")
 		// MFA is not required.
 		// SSH certs can be used without embedding the node name.
@@ -447,6 +482,8 @@ func (proxy *ProxyClient) IssueUserCertsWithMFA(ctx context.Context, params Reis
 		case proto.UserCertsRequest_Database:
 			key.DBTLSCerts[params.RouteToDatabase.ServiceName] = makeDatabaseClientPEM(
 				params.RouteToDatabase.Protocol, crt.TLS, key.Priv)
+		case proto.UserCertsRequest_App:
+			key.AppTLSCerts[params.RouteToApp.Name] = crt.TLS
 		case proto.UserCertsRequest_WindowsDesktop:
 			key.WindowsDesktopCerts[params.RouteToWindowsDesktop.WindowsDesktop] = crt.TLS
 		default:
@@ -1165,7 +1202,7 @@ func (proxy *ProxyClient) ConnectToNode(ctx context.Context, nodeAddress NodeAdd
 	sshConfig := &ssh.ClientConfig{
 		User:            user,
 		Auth:            authMethods,
-		HostKeyCallback: proxy.hostKeyCallback,
+		HostKeyCallback: proxy.teleportClient.wrapHostKeyCallback(proxy.hostKeyCallback),
 	}
 	conn, chans, reqs, err := newClientConn(ctx, pipeNetConn, nodeAddress.ProxyFormat(), sshConfig)
 	if err != nil {
@@ -1237,7 +1274,7 @@ func (proxy *ProxyClient) PortForwardToNode(ctx context.Context, nodeAddress Nod
 	sshConfig := &ssh.ClientConfig{
 		User:            user,
 		Auth:            authMethods,
-		HostKeyCallback: proxy.hostKeyCallback,
+		HostKeyCallback: proxy.teleportClient.wrapHostKeyCallback(proxy.hostKeyCallback),
 	}
 	conn, chans, reqs, err := newClientConn(ctx, proxyConn, nodeAddress.Addr, sshConfig)
 	if err != nil {
@@ -1371,6 +1408,14 @@ func (c *NodeClient) ExecuteSCP(ctx context.Context, cmd scp.Command) error {
 	}
 	defer s.Close()
 
+	// Pass along the transfer reason so the audit events recorded for this
+	// copy carry the justification.
+	if c.TC != nil && c.TC.Config.Reason != "" {
+		if err := s.Setenv(teleport.EnvSSHSessionReason, c.TC.Config.Reason); err != nil {
+			log.WithError(err).Debug("Failed to set the session reason.")
+		}
+	}
+
 	stdin, err := s.StdinPipe()
 	if err != nil {
 		return trace.Wrap(err)
@@ -1540,10 +1585,11 @@ func acceptWithContext(ctx context.Context, l net.Listener) (net.Conn, error) {
 
 // listenAndForward listens on a given socket and forwards all incoming
 // commands to the remote address through the SSH tunnel.
-func (c *NodeClient) listenAndForward(ctx context.Context, ln net.Listener, remoteAddr string) {
+func (c *NodeClient) listenAndForward(ctx context.Context, ln net.Listener, remoteAddr string, stats *ForwardStats) {
 	defer ln.Close()
 	defer c.Close()
 
+	limiter := c.forwardLimiter()
 	for {
 		// Accept connections from the client.
 		conn, err := acceptWithContext(ctx, ln)
@@ -1551,10 +1597,16 @@ func (c *NodeClient) listenAndForward(ctx context.Context, ln net.Listener, remo
 			log.Errorf("Port forwarding failed: %v.", err)
 			break
 		}
+		if !limiter.acquire() {
+			log.Warnf("Rejecting connection on forwarded port %v: limit of %v concurrent connections reached.", stats.Spec, limiter.maxConns)
+			conn.Close()
+			continue
+		}
+		stats.addConnection()
 
 		// Proxy the connection to the remote address.
 		go func() {
-			err := proxyConnection(ctx, conn, remoteAddr, c.Client)
+			err := proxyConnection(ctx, limiter.wrap(newStatsConn(conn, stats)), remoteAddr, c.Client)
 			if err != nil {
 				log.Warnf("Failed to proxy connection: %v.", err)
 			}
@@ -1564,10 +1616,11 @@ func (c *NodeClient) listenAndForward(ctx context.Context, ln net.Listener, remo
 
 // dynamicListenAndForward listens for connections, performs a SOCKS5
 // handshake, and then proxies the connection to the requested address.
-func (c *NodeClient) dynamicListenAndForward(ctx context.Context, ln net.Listener) {
+func (c *NodeClient) dynamicListenAndForward(ctx context.Context, ln net.Listener, stats *ForwardStats) {
 	defer ln.Close()
 	defer c.Close()
 
+	limiter := c.forwardLimiter()
 	for {
 		// Accept connection from the client. Here the client is typically
 		// something like a web browser or other SOCKS5 aware application.
@@ -1576,19 +1629,26 @@ func (c *NodeClient) dynamicListenAndForward(ctx context.Context, ln net.Listene
 			log.Errorf("Dynamic port forwarding (SOCKS5) failed: %v.", err)
 			break
 		}
+		if !limiter.acquire() {
+			log.Warnf("Rejecting connection on forwarded port %v: limit of %v concurrent connections reached.", stats.Spec, limiter.maxConns)
+			conn.Close()
+			continue
+		}
 
 		// Perform the SOCKS5 handshake with the client to find out the remote
 		// address to proxy.
 		remoteAddr, err := socks.Handshake(conn)
 		if err != nil {
 			log.Errorf("SOCKS5 handshake failed: %v.", err)
+			limiter.release()
 			break
 		}
 		log.Debugf("SOCKS5 proxy forwarding requests to %v.", remoteAddr)
+		stats.addConnection()
 
 		// Proxy the connection to the remote address.
 		go func() {
-			err := proxyConnection(ctx, conn, remoteAddr, c.Client)
+			err := proxyConnection(ctx, limiter.wrap(newStatsConn(conn, stats)), remoteAddr, c.Client)
 			if err != nil {
 				log.Warnf("Failed to proxy connection: %v.", err)
 			}
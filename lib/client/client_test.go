@@ -204,7 +204,7 @@ func (s *ClientTestSuite) TestListenAndForwardCancel(c *check.C) {
 	// Start listenAndForward and close the unblock channel once "Accept" has
 	// unblocked.
 	go func() {
-		client.listenAndForward(ctx, ln, "")
+		client.listenAndForward(ctx, ln, "", client.trackForward("", false))
 		close(unblockCh)
 	}()
 
@@ -20,10 +20,13 @@ package dbcmd
 
 import (
 	"fmt"
+	"net/url"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/client/db"
@@ -206,11 +209,69 @@ func (c *CLICommandBuilder) getCockroachCommand() *exec.Cmd {
 
 // getPostgresConnString returns the connection string for postgres.
 func (c *CLICommandBuilder) getPostgresConnString() string {
-	return postgres.GetConnString(
+	connString := postgres.GetConnString(
 		db.New(c.tc, *c.db, *c.profile, c.rootCluster, c.host, c.port),
 		c.options.noTLS,
 		c.options.printFormat,
 	)
+	return appendPostgresParams(connString, c.options.startupParams)
+}
+
+// appendPostgresParams appends session startup parameters to a postgres
+// connection string. application_name is a first-class libpq parameter;
+// everything else is passed as "options=-c name=value", which libpq forwards
+// to the server (or pooler) at session start.
+func appendPostgresParams(connString string, params map[string]string) string {
+	if len(params) == 0 {
+		return connString
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var extra []string
+	var options []string
+	for _, name := range names {
+		if name == "application_name" {
+			extra = append(extra, fmt.Sprintf("application_name=%v", url.QueryEscape(params[name])))
+			continue
+		}
+		options = append(options, fmt.Sprintf("-c %v=%v", escapePostgresOption(name), escapePostgresOption(params[name])))
+	}
+	if len(options) > 0 {
+		extra = append(extra, fmt.Sprintf("options=%v", url.QueryEscape(strings.Join(options, " "))))
+	}
+
+	// The printed form is wrapped in quotes; insert before the closing one.
+	quoted := strings.HasPrefix(connString, `"`) && strings.HasSuffix(connString, `"`)
+	if quoted {
+		connString = strings.Trim(connString, `"`)
+	}
+	separator := "?"
+	if strings.Contains(connString, "?") {
+		separator = "&"
+	}
+	connString += separator + strings.Join(extra, "&")
+	if quoted {
+		connString = fmt.Sprintf(`"%s"`, connString)
+	}
+	return connString
+}
+
+// escapePostgresOption backslash-escapes backslashes and whitespace for use
+// inside the libpq "options" value, where an unescaped space would otherwise
+// start a new option argument and corrupt the session settings.
+func escapePostgresOption(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r == '\\' || unicode.IsSpace(r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
 }
 
 // getMySQLCommonCmdOpts returns common command line arguments for mysql and mariadb.
@@ -464,6 +525,7 @@ type connectionCommandOpts struct {
 	printFormat              bool
 	tolerateMissingCLIClient bool
 	log                      *logrus.Entry
+	startupParams            map[string]string
 }
 
 // ConnectCommandFunc is a type for functions returned by the "With*" functions in this package.
@@ -502,6 +564,16 @@ func WithPrintFormat() ConnectCommandFunc {
 	}
 }
 
+// WithStartupParameters is the connect command option that passes session
+// startup parameters (application_name, search_path, statement_timeout, ...)
+// through to the database at connect time. Only supported for PostgreSQL
+// flavored databases.
+func WithStartupParameters(params map[string]string) ConnectCommandFunc {
+	return func(opts *connectionCommandOpts) {
+		opts.startupParams = params
+	}
+}
+
 // WithLogger is the connect command option that allows the caller to pass a logger that will be
 // used by CLICommandBuilder.
 func WithLogger(log *logrus.Entry) ConnectCommandFunc {
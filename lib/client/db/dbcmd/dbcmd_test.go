@@ -16,6 +16,7 @@ package dbcmd
 
 import (
 	"errors"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -565,3 +566,32 @@ func TestGetConnectCommandNoAbsPathIsNoopWhenGivenRelativePath(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "psql postgres://myUser@localhost:12345/mydb", got.String())
 }
+
+func TestAppendPostgresParams(t *testing.T) {
+	// No parameters leaves the connection string untouched.
+	require.Equal(t, "postgres://u@localhost:1/db",
+		appendPostgresParams("postgres://u@localhost:1/db", nil))
+
+	// application_name is a first-class parameter, everything else rides
+	// in "options". Parameters are sorted for deterministic output.
+	got := appendPostgresParams("postgres://u@localhost:1/db", map[string]string{
+		"statement_timeout": "5s",
+		"application_name":  "ci job",
+		"search_path":       "app",
+	})
+	require.Equal(t, "postgres://u@localhost:1/db?application_name=ci+job&options=-c+search_path%3Dapp+-c+statement_timeout%3D5s", got)
+
+	// Existing query parameters are extended, and the quotes of the
+	// printed form stay on the outside.
+	got = appendPostgresParams(`"postgres://u@localhost:1/db?sslmode=verify-full"`, map[string]string{
+		"application_name": "ci",
+	})
+	require.Equal(t, `"postgres://u@localhost:1/db?sslmode=verify-full&application_name=ci"`, got)
+
+	// Spaces and backslashes in option values are backslash-escaped so
+	// libpq keeps the whole value in a single "-c" argument.
+	got = appendPostgresParams("postgres://u@localhost:1/db", map[string]string{
+		"search_path": `a, b\c`,
+	})
+	require.Equal(t, "postgres://u@localhost:1/db?options="+url.QueryEscape(`-c search_path=a,\ b\\c`), got)
+}
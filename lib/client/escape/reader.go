@@ -29,7 +29,7 @@ const (
 
 	// Note: on a raw terminal, "\r\n" is needed to move a cursor to the start
 	// of next line.
-	helpText = "\r\ntsh escape characters:\r\n  ~? - display a list of escape characters\r\n  ~. - disconnect\r\n"
+	helpText = "\r\ntsh escape characters:\r\n  ~? - display a list of escape characters\r\n  ~i - display information about the current session\r\n  ~. - disconnect\r\n"
 )
 
 var (
@@ -52,17 +52,20 @@ type Reader struct {
 	onDisconnect func(error)
 	bufferLimit  int
 
-	// cond protects buf and err and also announces to blocked readers that
-	// more data is available.
-	cond sync.Cond
-	buf  []byte
-	err  error
+	// cond protects buf, err and onInfo and also announces to blocked readers
+	// that more data is available.
+	cond   sync.Cond
+	buf    []byte
+	err    error
+	onInfo func(io.Writer)
 }
 
 // NewReader creates a new Reader to catch escape sequences from 'in'.
 //
-// Two sequences are supported:
+// Three sequences are supported:
 // - "~?": prints help text to 'out' listing supported sequences
+// - "~i": invokes the callback registered with SetOnInfo, if any, to print
+//   information about the current session to 'out'
 // - "~.": disconnect stops any future reads from in; after this sequence,
 //   callers can still read any unread data up to this sequence from Reader but
 //   all future Read calls will return ErrDisconnect; onDisconnect will also be
@@ -148,6 +151,14 @@ func (r *Reader) runReads() {
 					continue inner
 				}
 				newLine = false
+			case 'i':
+				if escape {
+					// Complete info sequence.
+					r.printInfo()
+					newLine, escape = false, false
+					continue inner
+				}
+				newLine = false
 			case '.':
 				if escape {
 					// Complete disconnect sequence.
@@ -215,6 +226,24 @@ func (r *Reader) setErr(err error) {
 	r.cond.L.Unlock()
 }
 
+// SetOnInfo registers a callback invoked when the user enters the "~i"
+// sequence. The callback receives the Reader's output writer and should
+// format its output for a raw terminal (using "\r\n" line endings).
+func (r *Reader) SetOnInfo(fn func(io.Writer)) {
+	r.cond.L.Lock()
+	r.onInfo = fn
+	r.cond.L.Unlock()
+}
+
 func (r *Reader) printHelp() {
 	r.out.Write([]byte(helpText))
 }
+
+func (r *Reader) printInfo() {
+	r.cond.L.Lock()
+	fn := r.onInfo
+	r.cond.L.Unlock()
+	if fn != nil {
+		fn(r.out)
+	}
+}
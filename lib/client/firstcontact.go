@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/teleport/api/profile"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+)
+
+// knownNodesFile records the nodes the user has confirmed in strict host
+// checking mode, one "cluster hostname fingerprint" triple per line.
+const knownNodesFile = "known_nodes"
+
+// wrapHostKeyCallback adds OpenSSH-style first-contact awareness on top of
+// the regular host key verification. When strict host checking is enabled,
+// the first connection to a node displays the node's identity and requires
+// confirmation before proceeding; the confirmed identity is remembered so
+// subsequent connections are silent unless the identity changes.
+func (tc *TeleportClient) wrapHostKeyCallback(next ssh.HostKeyCallback) ssh.HostKeyCallback {
+	if !tc.Config.StrictHostChecking {
+		return next
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := next(hostname, remote, key); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(tc.confirmFirstContact(hostname, key))
+	}
+}
+
+// confirmFirstContact checks the node against the known nodes file and, on
+// first contact or an identity change, displays the node identity and asks
+// the user to confirm the connection.
+func (tc *TeleportClient) confirmFirstContact(hostname string, key ssh.PublicKey) error {
+	fingerprint := ssh.FingerprintSHA256(key)
+	path := filepath.Join(profile.FullProfilePath(tc.Config.HomePath), knownNodesFile)
+
+	recorded, err := lookupKnownNode(path, tc.SiteName, hostname)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if recorded == fingerprint {
+		return nil
+	}
+
+	var question strings.Builder
+	if recorded == "" {
+		fmt.Fprintf(&question, "First connection to node %q:\n", hostname)
+	} else {
+		fmt.Fprintf(&question, "WARNING: the identity of node %q has changed since it was last confirmed.\n", hostname)
+	}
+	fmt.Fprintf(&question, "  Cluster:     %v\n", tc.SiteName)
+	if cert, ok := key.(*ssh.Certificate); ok {
+		fmt.Fprintf(&question, "  Principals:  %v\n", strings.Join(cert.ValidPrincipals, ", "))
+		fmt.Fprintf(&question, "  Fingerprint: %v\n", ssh.FingerprintSHA256(cert.Key))
+	} else {
+		fmt.Fprintf(&question, "  Fingerprint: %v\n", fingerprint)
+	}
+	question.WriteString("Are you sure you want to continue?")
+
+	ok, err := tc.promptUI().Confirm(context.Background(), question.String())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !ok {
+		return trace.AccessDenied("connection to node %q rejected by user", hostname)
+	}
+	return trace.Wrap(recordKnownNode(path, tc.SiteName, hostname, fingerprint))
+}
+
+// lookupKnownNode returns the recorded fingerprint for the node, empty if
+// the node has not been confirmed before.
+func lookupKnownNode(path, cluster, hostname string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	var fingerprint string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == cluster && fields[1] == hostname {
+			// Later entries win so identity changes overwrite old records.
+			fingerprint = fields[2]
+		}
+	}
+	return fingerprint, trace.ConvertSystemError(scanner.Err())
+}
+
+// recordKnownNode appends the confirmed node identity to the known nodes
+// file.
+func recordKnownNode(path, cluster, hostname, fingerprint string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%v %v %v\n", cluster, hostname, fingerprint)
+	return trace.ConvertSystemError(err)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownNodesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), knownNodesFile)
+
+	// Unknown nodes have no recorded fingerprint.
+	fingerprint, err := lookupKnownNode(path, "example.com", "node-1")
+	require.NoError(t, err)
+	require.Empty(t, fingerprint)
+
+	require.NoError(t, recordKnownNode(path, "example.com", "node-1", "SHA256:aaa"))
+	require.NoError(t, recordKnownNode(path, "leaf.example.com", "node-1", "SHA256:bbb"))
+
+	fingerprint, err = lookupKnownNode(path, "example.com", "node-1")
+	require.NoError(t, err)
+	require.Equal(t, "SHA256:aaa", fingerprint)
+
+	// The same hostname in another cluster is tracked separately.
+	fingerprint, err = lookupKnownNode(path, "leaf.example.com", "node-1")
+	require.NoError(t, err)
+	require.Equal(t, "SHA256:bbb", fingerprint)
+
+	// A re-confirmed identity overrides the earlier record.
+	require.NoError(t, recordKnownNode(path, "example.com", "node-1", "SHA256:ccc"))
+	fingerprint, err = lookupKnownNode(path, "example.com", "node-1")
+	require.NoError(t, err)
+	require.Equal(t, "SHA256:ccc", fingerprint)
+}
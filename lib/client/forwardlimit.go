@@ -0,0 +1,133 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// forwardLimiter enforces the optional per-forward resource caps: a maximum
+// number of concurrent connections and a throughput budget shared by all
+// connections on the forward. A zero value for either cap disables it.
+type forwardLimiter struct {
+	maxConns int64
+	active   int64
+	bucket   *rateBucket
+}
+
+// newForwardLimiter returns a limiter for a single forwarded port.
+func newForwardLimiter(maxConns int, bytesPerSecond int64) *forwardLimiter {
+	l := &forwardLimiter{maxConns: int64(maxConns)}
+	if bytesPerSecond > 0 {
+		l.bucket = &rateBucket{bytesPerSecond: bytesPerSecond, last: time.Now()}
+	}
+	return l
+}
+
+// forwardLimiter builds a limiter from the client configuration, used by the
+// port forwarding accept loops.
+func (c *NodeClient) forwardLimiter() *forwardLimiter {
+	if c.TC == nil {
+		return newForwardLimiter(0, 0)
+	}
+	return newForwardLimiter(c.TC.Config.ForwardConnLimit, c.TC.Config.ForwardBytesPerSecond)
+}
+
+// acquire reserves a connection slot, reporting false when the concurrent
+// connection cap has been reached.
+func (l *forwardLimiter) acquire() bool {
+	if atomic.AddInt64(&l.active, 1) > l.maxConns && l.maxConns > 0 {
+		atomic.AddInt64(&l.active, -1)
+		return false
+	}
+	return true
+}
+
+// release returns a connection slot taken by acquire.
+func (l *forwardLimiter) release() {
+	atomic.AddInt64(&l.active, -1)
+}
+
+// wrap applies the throughput cap to the connection and arranges for the
+// connection slot to be released on close.
+func (l *forwardLimiter) wrap(conn net.Conn) net.Conn {
+	return &limitedForwardConn{Conn: conn, limiter: l}
+}
+
+// limitedForwardConn paces reads and writes through the forward's token
+// bucket and releases its connection slot when closed.
+type limitedForwardConn struct {
+	net.Conn
+	limiter   *forwardLimiter
+	closeOnce sync.Once
+}
+
+func (c *limitedForwardConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 && c.limiter.bucket != nil {
+		c.limiter.bucket.wait(int64(n))
+	}
+	return n, err
+}
+
+func (c *limitedForwardConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 && c.limiter.bucket != nil {
+		c.limiter.bucket.wait(int64(n))
+	}
+	return n, err
+}
+
+func (c *limitedForwardConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(c.limiter.release)
+	return err
+}
+
+// rateBucket is a minimal token bucket: it refills at bytesPerSecond up to
+// one second worth of burst, every transfer drains its size from the bucket
+// and the caller sleeps while the bucket is in deficit.
+type rateBucket struct {
+	mu             sync.Mutex
+	bytesPerSecond int64
+	available      float64
+	last           time.Time
+}
+
+// wait accounts for n transferred bytes and blocks until the bucket can
+// afford them.
+func (b *rateBucket) wait(n int64) {
+	b.mu.Lock()
+	now := time.Now()
+	b.available += now.Sub(b.last).Seconds() * float64(b.bytesPerSecond)
+	if burst := float64(b.bytesPerSecond); b.available > burst {
+		b.available = burst
+	}
+	b.last = now
+	b.available -= float64(n)
+	var sleep time.Duration
+	if b.available < 0 {
+		sleep = time.Duration(-b.available / float64(b.bytesPerSecond) * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if sleep > 0 {
+		time.Sleep(sleep)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardLimiterConnCap(t *testing.T) {
+	limiter := newForwardLimiter(2, 0)
+	require.True(t, limiter.acquire())
+	require.True(t, limiter.acquire())
+	require.False(t, limiter.acquire())
+
+	limiter.release()
+	require.True(t, limiter.acquire())
+
+	// Closing a wrapped connection releases its slot exactly once.
+	local, remote := net.Pipe()
+	defer remote.Close()
+	conn := limiter.wrap(local)
+	require.False(t, limiter.acquire())
+	require.NoError(t, conn.Close())
+	// A second close must not release the slot again.
+	conn.Close()
+	require.True(t, limiter.acquire())
+	require.False(t, limiter.acquire())
+
+	// A limiter without caps never rejects.
+	unlimited := newForwardLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		require.True(t, unlimited.acquire())
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// ForwardStats accumulates usage counters for a single active port forward,
+// so users can tell whether their tunnel is actually carrying traffic.
+//
+// ForwardStats is safe for concurrent use.
+type ForwardStats struct {
+	// Spec is the forward spec as typed by the user, e.g. "8080:web:80".
+	Spec string
+	// Dynamic marks SOCKS5 (-D) forwards.
+	Dynamic bool
+
+	connections int64
+	bytesIn     int64
+	bytesOut    int64
+}
+
+// addConnection counts a new connection accepted on the forward.
+func (s *ForwardStats) addConnection() {
+	atomic.AddInt64(&s.connections, 1)
+}
+
+// Connections returns the number of connections accepted on the forward.
+func (s *ForwardStats) Connections() int64 {
+	return atomic.LoadInt64(&s.connections)
+}
+
+// Bytes returns the number of bytes received from and sent to the remote
+// side of the forward.
+func (s *ForwardStats) Bytes() (in, out int64) {
+	return atomic.LoadInt64(&s.bytesIn), atomic.LoadInt64(&s.bytesOut)
+}
+
+// statsConn wraps a local connection of a port forward and counts the bytes
+// flowing through it.
+type statsConn struct {
+	net.Conn
+	stats *ForwardStats
+}
+
+// newStatsConn returns conn wrapped with byte counting on stats.
+func newStatsConn(conn net.Conn, stats *ForwardStats) *statsConn {
+	return &statsConn{Conn: conn, stats: stats}
+}
+
+// Read counts data read from the local client, i.e. bytes sent to the
+// remote side.
+func (c *statsConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.stats.bytesOut, int64(n))
+	return n, err
+}
+
+// Write counts data written to the local client, i.e. bytes received from
+// the remote side.
+func (c *statsConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.stats.bytesIn, int64(n))
+	return n, err
+}
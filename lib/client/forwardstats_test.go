@@ -0,0 +1,51 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardStats(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	stats := &ForwardStats{Spec: "8080:localhost:80"}
+	stats.addConnection()
+	conn := newStatsConn(client, stats)
+
+	go func() {
+		server.Write([]byte("request"))
+		buf := make([]byte, 8)
+		server.Read(buf)
+	}()
+
+	buf := make([]byte, 7)
+	_, err := conn.Read(buf)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("response"))
+	require.NoError(t, err)
+
+	in, out := stats.Bytes()
+	require.Equal(t, int64(8), in)
+	require.Equal(t, int64(7), out)
+	require.Equal(t, int64(1), stats.Connections())
+}
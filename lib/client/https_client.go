@@ -19,6 +19,7 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"net/http"
 	"net/url"
@@ -50,6 +51,26 @@ func NewInsecureWebClient() *http.Client {
 	}
 }
 
+// newClientWithVerify returns an HTTPS client that skips the standard
+// certificate chain verification and instead runs the supplied callback on
+// every handshake, used for fingerprint-pinned (TOFU) connections.
+func newClientWithVerify(verify func(tls.ConnectionState) error) *http.Client {
+	// Because Teleport clients can't be configured (yet), they take the default
+	// list of cipher suites from Go.
+	tlsConfig := utils.TLSConfig(nil)
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyConnection = verify
+
+	return &http.Client{
+		Transport: otelhttp.NewTransport(&http.Transport{
+			TLSClientConfig: tlsConfig,
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				return httpproxy.FromEnvironment().ProxyFunc()(req.URL)
+			},
+		}),
+	}
+}
+
 func newClientWithPool(pool *x509.CertPool) *http.Client {
 	// Because Teleport clients can't be configured (yet), they take the default
 	// list of cipher suites from Go.
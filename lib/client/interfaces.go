@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/gravitational/teleport"
@@ -96,7 +97,14 @@ type Key struct {
 // NewKey generates a new unsigned key. Such key must be signed by a
 // Teleport CA (auth server) before it becomes useful.
 func NewKey() (key *Key, err error) {
-	priv, pub, err := native.GenerateKeyPair()
+	return NewKeyWithAlgorithm("")
+}
+
+// NewKeyWithAlgorithm generates a new unsigned key using the given key
+// algorithm, or the default algorithm if empty. Such key must be signed by
+// a Teleport CA (auth server) before it becomes useful.
+func NewKeyWithAlgorithm(algorithm string) (key *Key, err error) {
+	priv, pub, err := native.GenerateKeyPairWithAlgorithm(algorithm)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -116,11 +124,26 @@ func KeyFromIdentityFile(path string) (*Key, error) {
 	if err != nil {
 		return nil, trace.Wrap(err, "failed to parse identity file")
 	}
+	return keyFromIdentity(ident, path)
+}
+
+// KeyFromIdentityReader parses identity file material from the reader into a
+// Key, allowing identities to be supplied without touching the filesystem.
+func KeyFromIdentityReader(r io.Reader) (*Key, error) {
+	ident, err := identityfile.Read(r)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to parse identity")
+	}
+	return keyFromIdentity(ident, "in-memory identity")
+}
 
+// keyFromIdentity validates a parsed identity file and converts it into a
+// Key. The source is only used in error messages.
+func keyFromIdentity(ident *identityfile.IdentityFile, source string) (*Key, error) {
 	// validate both by parsing them:
 	privKey, err := ssh.ParseRawPrivateKey(ident.PrivateKey)
 	if err != nil {
-		return nil, trace.BadParameter("invalid identity: %s. %v", path, err)
+		return nil, trace.BadParameter("invalid identity: %s. %v", source, err)
 	}
 	signer, err := ssh.NewSignerFromKey(privKey)
 	if err != nil {
@@ -346,7 +369,26 @@ func (k *Key) AsAgentKeys() ([]agent.AddedKey, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return sshutils.AsAgentKeys(cert, k.Priv)
+	agentKeys, err := sshutils.AsAgentKeys(cert, k.Priv)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for i := range agentKeys {
+		// Name the proxy in the comment so tools that list the agent
+		// (e.g. ssh-add -l) can tell which cluster a certificate is for.
+		if k.ProxyHost != "" {
+			agentKeys[i].Comment = fmt.Sprintf("teleport:%v@%v", cert.KeyId, k.ProxyHost)
+		}
+		// Constrain the agent key lifetime to the certificate expiry so
+		// the agent drops the key once the certificate is no longer
+		// usable.
+		if cert.ValidBefore != 0 && cert.ValidBefore != uint64(ssh.CertTimeInfinity) {
+			if ttl := time.Until(time.Unix(int64(cert.ValidBefore), 0)); ttl > 0 {
+				agentKeys[i].LifetimeSecs = uint32(ttl.Seconds())
+			}
+		}
+	}
+	return agentKeys, nil
 }
 
 // TeleportTLSCertificate returns the parsed x509 certificate for
@@ -365,6 +407,16 @@ func (k *Key) KubeTLSCertificate(kubeClusterName string) (*x509.Certificate, err
 	return tlsca.ParseCertificatePEM(tlsCert)
 }
 
+// AppTLSCertificate returns the parsed x509 certificate for
+// authentication against a named application.
+func (k *Key) AppTLSCertificate(appName string) (*x509.Certificate, error) {
+	tlsCert, ok := k.AppTLSCerts[appName]
+	if !ok {
+		return nil, trace.NotFound("TLS certificate for application %q not found", appName)
+	}
+	return tlsca.ParseCertificatePEM(tlsCert)
+}
+
 // DBTLSCertificates returns all parsed x509 database access certificates.
 func (k *Key) DBTLSCertificates() (certs []x509.Certificate, err error) {
 	for _, bytes := range k.DBTLSCerts {
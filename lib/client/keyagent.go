@@ -157,7 +157,7 @@ func NewLocalAgent(conf LocalAgentConfig) (a *LocalKeyAgent, err error) {
 	}
 
 	if shouldAddKeysToAgent(conf.KeysOption) {
-		a.sshAgent = connectToSSHAgent()
+		a.sshAgent = ConnectToSSHAgent()
 	} else {
 		log.Debug("Skipping connection to the local ssh-agent.")
 
@@ -246,7 +246,7 @@ func (a *LocalKeyAgent) UnloadKey() error {
 
 		// remove any teleport keys we currently have loaded in the agent for this user
 		for _, key := range keyList {
-			if key.Comment == fmt.Sprintf("teleport:%v", a.username) {
+			if isTeleportAgentKey(key, a.username) {
 				err = agent.Remove(key)
 				if err != nil {
 					a.log.Warnf("Unable to communicate with agent and remove key: %v", err)
@@ -258,6 +258,14 @@ func (a *LocalKeyAgent) UnloadKey() error {
 	return nil
 }
 
+// isTeleportAgentKey checks if an agent key was loaded by Teleport for the
+// given user. It matches both the current "teleport:<user>@<proxy>" comment
+// format and the legacy "teleport:<user>" format.
+func isTeleportAgentKey(key *agent.Key, username string) bool {
+	return key.Comment == fmt.Sprintf("teleport:%v", username) ||
+		strings.HasPrefix(key.Comment, fmt.Sprintf("teleport:%v@", username))
+}
+
 // UnloadKeys will unload all Teleport keys from the teleport agent as well as
 // the system agent.
 func (a *LocalKeyAgent) UnloadKeys() error {
@@ -495,6 +503,15 @@ func (a *LocalKeyAgent) AddDatabaseKey(key *Key) error {
 	return a.addKey(key)
 }
 
+// AddAppKey activates a new signed app access key by adding it into the keystore.
+// key must contain at least one app cert. ssh cert is not required.
+func (a *LocalKeyAgent) AddAppKey(key *Key) error {
+	if len(key.AppTLSCerts) == 0 {
+		return trace.BadParameter("key must contains at least one app access certificate")
+	}
+	return a.addKey(key)
+}
+
 // addKey activates a new signed session key by adding it into the keystore.
 func (a *LocalKeyAgent) addKey(key *Key) error {
 	if key == nil {
@@ -90,7 +90,7 @@ func makeSuite(t *testing.T) *KeyAgentTestSuite {
 //   * When we add a key, both the certificate and private key are added into
 //     the both the teleport ssh agent and the system ssh agent.
 //   * When we add a key, it's tagged with a comment that indicates that it's
-//     a teleport key with the teleport username.
+//     a teleport key with the teleport username and proxy host.
 func TestAddKey(t *testing.T) {
 	s := makeSuite(t)
 
@@ -131,22 +131,23 @@ func TestAddKey(t *testing.T) {
 	// check that we've loaded a cert as well as a private key into the teleport agent
 	// and it's for the user we expected to add a certificate for
 	require.Len(t, teleportAgentKeys, 2)
+	expectedComment := "teleport:" + s.username + "@" + s.hostname
 	require.Equal(t, "ssh-rsa-cert-v01@openssh.com", teleportAgentKeys[0].Type())
-	require.Equal(t, "teleport:"+s.username, teleportAgentKeys[0].Comment)
+	require.Equal(t, expectedComment, teleportAgentKeys[0].Comment)
 	require.Equal(t, "ssh-rsa", teleportAgentKeys[1].Type())
-	require.Equal(t, "teleport:"+s.username, teleportAgentKeys[1].Comment)
+	require.Equal(t, expectedComment, teleportAgentKeys[1].Comment)
 
 	// check that we've loaded a cert as well as a private key into the system again
 	found := false
 	for _, sak := range systemAgentKeys {
-		if sak.Comment == "teleport:"+s.username && sak.Type() == "ssh-rsa" {
+		if sak.Comment == expectedComment && sak.Type() == "ssh-rsa" {
 			found = true
 		}
 	}
 	require.True(t, found)
 	found = false
 	for _, sak := range systemAgentKeys {
-		if sak.Comment == "teleport:"+s.username && sak.Type() == "ssh-rsa-cert-v01@openssh.com" {
+		if sak.Comment == expectedComment && sak.Type() == "ssh-rsa-cert-v01@openssh.com" {
 			found = true
 		}
 	}
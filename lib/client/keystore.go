@@ -183,11 +183,33 @@ func (fs *FSLocalKeyStore) writeBytes(bytes []byte, fp string) error {
 		fs.log.Error(err)
 		return trace.ConvertSystemError(err)
 	}
-	err := os.WriteFile(fp, bytes, keyFilePerms)
+	// Write to a temporary file and rename it into place so concurrent
+	// readers never observe a partially written key or certificate.
+	tmp, err := os.CreateTemp(filepath.Dir(fp), filepath.Base(fp)+".tmp-*")
 	if err != nil {
 		fs.log.Error(err)
+		return trace.ConvertSystemError(err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(keyFilePerms); err != nil {
+		tmp.Close()
+		fs.log.Error(err)
+		return trace.ConvertSystemError(err)
+	}
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		fs.log.Error(err)
+		return trace.ConvertSystemError(err)
 	}
-	return trace.ConvertSystemError(err)
+	if err := tmp.Close(); err != nil {
+		fs.log.Error(err)
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(tmp.Name(), fp); err != nil {
+		fs.log.Error(err)
+		return trace.ConvertSystemError(err)
+	}
+	return nil
 }
 
 // DeleteKey deletes the user's key with all its certs.
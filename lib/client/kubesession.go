@@ -198,7 +198,7 @@ func (s *KubeSession) pipeInOut(stdout io.Writer, mode types.SessionParticipantM
 
 		switch mode {
 		case types.SessionPeerMode:
-			handlePeerControls(s.term, s.stream)
+			handlePeerControls(s.term, s.stream, s.writeSessionInfo)
 		default:
 			handleNonPeerControls(mode, s.term, func() {
 				err := s.stream.ForceTerminate()
@@ -211,6 +211,16 @@ func (s *KubeSession) pipeInOut(stdout io.Writer, mode types.SessionParticipantM
 	}()
 }
 
+// writeSessionInfo prints metadata about the joined session to w. It is
+// invoked by the "~i" escape sequence, so the output is formatted for a raw
+// terminal.
+func (s *KubeSession) writeSessionInfo(w io.Writer) {
+	fmt.Fprint(w, "\r\nTeleport session:\r\n")
+	fmt.Fprintf(w, "  Session ID: %v\r\n", s.meta.GetSessionID())
+	fmt.Fprintf(w, "  Kubernetes cluster: %v\r\n", s.meta.GetKubeCluster())
+	fmt.Fprintf(w, "  Cluster: %v\r\n", s.meta.GetClusterName())
+}
+
 // Wait waits for the session to finish.
 func (s *KubeSession) Wait() {
 	s.closeWait.Wait()
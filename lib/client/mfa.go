@@ -80,11 +80,75 @@ func (tc *TeleportClient) PromptMFAChallenge(
 			opts.AuthenticatorAttachment = optsOverride.AuthenticatorAttachment
 		}
 	}
-	return PromptMFAChallenge(ctx, c, tc.WebProxyAddr, opts)
+	return tc.promptUI().PromptMFA(ctx, tc.WebProxyAddr, c, opts)
+}
+
+// mfaMethod is a single way of solving an MFA challenge. Methods are offered
+// concurrently by runMFACeremony and whichever completes first wins.
+type mfaMethod struct {
+	// kind is the method name used in debug logs.
+	kind string
+	// run solves the challenge, blocking until the user completes this
+	// method or ctx is canceled because another method won the race.
+	run func(ctx context.Context) (*proto.MFAAuthenticateResponse, error)
+}
+
+// runMFACeremony offers all methods concurrently and resolves with the first
+// successful response, canceling the remaining methods. A method that fails
+// (e.g. a rejected security key) only removes it from the race, the user can
+// still complete any of the others.
+func runMFACeremony(ctx context.Context, methods []mfaMethod) (*proto.MFAAuthenticateResponse, error) {
+	type response struct {
+		kind string
+		resp *proto.MFAAuthenticateResponse
+		err  error
+	}
+	respC := make(chan response, len(methods))
+
+	// Use ctx and wg to clean up after ourselves.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	cancelAndWait := func() {
+		cancel()
+		wg.Wait()
+	}
+
+	for _, method := range methods {
+		method := method
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := method.run(ctx)
+			respC <- response{kind: method.kind, resp: resp, err: err}
+		}()
+	}
+
+	for i := 0; i < len(methods); i++ {
+		select {
+		case resp := <-respC:
+			if err := resp.err; err != nil {
+				log.WithError(err).Debugf("%s authentication failed", resp.kind)
+				continue
+			}
+
+			// Cleanup in-flight goroutines.
+			cancelAndWait()
+			return resp.resp, nil
+		case <-ctx.Done():
+			cancelAndWait()
+			return nil, trace.Wrap(ctx.Err())
+		}
+	}
+	cancelAndWait()
+	return nil, trace.BadParameter(
+		"failed to authenticate using all MFA devices, rerun the command with '-d' to see error details for each device")
 }
 
 // PromptMFAChallenge prompts the user to complete MFA authentication
-// challenges.
+// challenges. All methods present in the challenge and supported by the
+// platform are offered at once through runMFACeremony, so the user may tap a
+// key or enter an OTP code in whichever order they prefer.
 // PromptMFAChallenge makes an attempt to read OTPs from prompt.Stdin and
 // abandons the read if the user chooses WebAuthn instead. For this reason
 // callers must use prompt.Stdin exclusively after calling this function.
@@ -117,121 +181,90 @@ func PromptMFAChallenge(ctx context.Context, c *proto.MFAAuthenticateChallenge,
 		hasTOTP = false
 	}
 
-	var numGoroutines int
-	if hasTOTP && hasWebauthn {
-		numGoroutines = 2
-	} else {
-		numGoroutines = 1
-	}
-
-	type response struct {
-		kind string
-		resp *proto.MFAAuthenticateResponse
-		err  error
-	}
-	respC := make(chan response, numGoroutines)
-
-	// Use ctx and wg to clean up after ourselves.
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	var wg sync.WaitGroup
-	cancelAndWait := func() {
-		cancel()
-		wg.Wait()
-	}
+	var methods []mfaMethod
 
 	// Use variables below to cancel OTP reads and make sure the goroutine exited.
 	otpWait := &sync.WaitGroup{}
 	otpCtx, otpCancel := context.WithCancel(ctx)
 	defer otpCancel()
 
-	// Fire TOTP goroutine.
+	// TOTP method, reads a code from the terminal.
 	if hasTOTP {
 		otpWait.Add(1)
-		wg.Add(1)
-		go func() {
-			defer otpWait.Done()
-			defer wg.Done()
-			const kind = "TOTP"
-			var msg string
-			if !quiet {
-				if hasWebauthn {
-					msg = fmt.Sprintf("Tap any %ssecurity key or enter a code from a %sOTP device", promptDevicePrefix, promptDevicePrefix)
-				} else {
-					msg = fmt.Sprintf("Enter an OTP code from a %sdevice", promptDevicePrefix)
-				}
-			}
-
-			otp, err := prompt.Password(otpCtx, os.Stderr, prompt.Stdin(), msg)
-			if err != nil {
-				respC <- response{kind: kind, err: err}
-				return
+		var msg string
+		if !quiet {
+			if hasWebauthn {
+				msg = fmt.Sprintf("Tap any %ssecurity key or enter a code from a %sOTP device", promptDevicePrefix, promptDevicePrefix)
+			} else {
+				msg = fmt.Sprintf("Enter an OTP code from a %sdevice", promptDevicePrefix)
 			}
-			respC <- response{
-				kind: kind,
-				resp: &proto.MFAAuthenticateResponse{
+		}
+		methods = append(methods, mfaMethod{
+			kind: "TOTP",
+			run: func(ctx context.Context) (*proto.MFAAuthenticateResponse, error) {
+				defer otpWait.Done()
+				// The read is canceled when the ceremony ends, but also by
+				// otpCancel alone so WebAuthn can reclaim stdin (see
+				// mfaPrompt) without tearing the whole ceremony down.
+				readCtx, readCancel := context.WithCancel(ctx)
+				defer readCancel()
+				go func() {
+					select {
+					case <-otpCtx.Done():
+						readCancel()
+					case <-readCtx.Done():
+					}
+				}()
+				otp, err := prompt.Password(readCtx, os.Stderr, prompt.Stdin(), msg)
+				if err != nil {
+					return nil, trace.Wrap(err)
+				}
+				return &proto.MFAAuthenticateResponse{
 					Response: &proto.MFAAuthenticateResponse_TOTP{
 						TOTP: &proto.TOTPResponse{Code: otp},
 					},
-				},
-			}
-		}()
+				}, nil
+			},
+		})
 	}
 
-	// Fire Webauthn goroutine.
+	// WebAuthn method, prompts for a security key tap.
 	if hasWebauthn {
 		origin := proxyAddr
 		if !strings.HasPrefix(origin, "https://") {
 			origin = "https://" + origin
 		}
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			log.Debugf("WebAuthn: prompting devices with origin %q", origin)
-
-			prompt := wancli.NewDefaultPrompt(ctx, os.Stderr)
-
-			// Let OTP take over the prompt if present, but otherwise delegate to
-			// WebAuthn.
-			prompt.FirstTouchMessage = ""
-			if !hasTOTP && !quiet {
-				prompt.FirstTouchMessage = fmt.Sprintf("Tap any %ssecurity key", promptDevicePrefix)
-			}
-			prompt.SecondTouchMessage = fmt.Sprintf("Tap your %ssecurity key to complete login", promptDevicePrefix)
-
-			mfaPrompt := &mfaPrompt{LoginPrompt: prompt, otpCancelAndWait: func() {
-				otpCancel()
-				otpWait.Wait()
-			}}
-
-			const user = ""
-			resp, _, err := promptWebauthn(ctx, origin, wanlib.CredentialAssertionFromProto(c.WebauthnChallenge), mfaPrompt, &wancli.LoginOpts{
-				User:                    user,
-				AuthenticatorAttachment: opts.AuthenticatorAttachment,
-			})
-			respC <- response{kind: "WEBAUTHN", resp: resp, err: err}
-		}()
+		methods = append(methods, mfaMethod{
+			kind: "WEBAUTHN",
+			run: func(ctx context.Context) (*proto.MFAAuthenticateResponse, error) {
+				log.Debugf("WebAuthn: prompting devices with origin %q", origin)
+
+				prompt := wancli.NewDefaultPrompt(ctx, os.Stderr)
+
+				// Let OTP take over the prompt if present, but otherwise delegate to
+				// WebAuthn.
+				prompt.FirstTouchMessage = ""
+				if !hasTOTP && !quiet {
+					prompt.FirstTouchMessage = fmt.Sprintf("Tap any %ssecurity key", promptDevicePrefix)
+				}
+				prompt.SecondTouchMessage = fmt.Sprintf("Tap your %ssecurity key to complete login", promptDevicePrefix)
+
+				mfaPrompt := &mfaPrompt{LoginPrompt: prompt, otpCancelAndWait: func() {
+					otpCancel()
+					otpWait.Wait()
+				}}
+
+				const user = ""
+				resp, _, err := promptWebauthn(ctx, origin, wanlib.CredentialAssertionFromProto(c.WebauthnChallenge), mfaPrompt, &wancli.LoginOpts{
+					User:                    user,
+					AuthenticatorAttachment: opts.AuthenticatorAttachment,
+				})
+				return resp, trace.Wrap(err)
+			},
+		})
 	}
 
-	for i := 0; i < numGoroutines; i++ {
-		select {
-		case resp := <-respC:
-			if err := resp.err; err != nil {
-				log.WithError(err).Debugf("%s authentication failed", resp.kind)
-				continue
-			}
-
-			// Cleanup in-flight goroutines.
-			cancelAndWait()
-			return resp.resp, nil
-		case <-ctx.Done():
-			cancelAndWait()
-			return nil, trace.Wrap(ctx.Err())
-		}
-	}
-	cancelAndWait()
-	return nil, trace.BadParameter(
-		"failed to authenticate using all MFA devices, rerun the command with '-d' to see error details for each device")
+	return runMFACeremony(ctx, methods)
 }
 
 // MFAAuthenticateChallenge is an MFA authentication challenge sent on user
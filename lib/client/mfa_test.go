@@ -0,0 +1,80 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMFACeremony(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	totpResponse := &proto.MFAAuthenticateResponse{
+		Response: &proto.MFAAuthenticateResponse_TOTP{
+			TOTP: &proto.TOTPResponse{Code: "123456"},
+		},
+	}
+
+	// blocked models a method waiting for user interaction that never comes;
+	// it must unblock once another method wins.
+	blocked := mfaMethod{
+		kind: "BLOCKED",
+		run: func(ctx context.Context) (*proto.MFAAuthenticateResponse, error) {
+			<-ctx.Done()
+			return nil, trace.Wrap(ctx.Err())
+		},
+	}
+	failing := mfaMethod{
+		kind: "FAILING",
+		run: func(ctx context.Context) (*proto.MFAAuthenticateResponse, error) {
+			return nil, trace.AccessDenied("device rejected")
+		},
+	}
+	succeeding := mfaMethod{
+		kind: "TOTP",
+		run: func(ctx context.Context) (*proto.MFAAuthenticateResponse, error) {
+			return totpResponse, nil
+		},
+	}
+
+	// The successful method wins the race and the blocked one is canceled.
+	resp, err := runMFACeremony(ctx, []mfaMethod{blocked, succeeding})
+	require.NoError(t, err)
+	require.Equal(t, totpResponse, resp)
+
+	// A failing method does not abort the ceremony while others are pending.
+	resp, err = runMFACeremony(ctx, []mfaMethod{failing, succeeding})
+	require.NoError(t, err)
+	require.Equal(t, totpResponse, resp)
+
+	// All methods failing surfaces an error.
+	_, err = runMFACeremony(ctx, []mfaMethod{failing})
+	require.True(t, trace.IsBadParameter(err))
+
+	// Context cancellation unblocks the ceremony.
+	canceledCtx, cancelNow := context.WithCancel(ctx)
+	cancelNow()
+	_, err = runMFACeremony(canceledCtx, []mfaMethod{blocked})
+	require.Error(t, err)
+}
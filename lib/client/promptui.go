@@ -0,0 +1,96 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/gravitational/teleport/lib/utils/prompt"
+	"github.com/gravitational/trace"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// PromptUI groups the interactive questions TeleportClient asks the user
+// during logins and connections: passwords, OTP tokens, MFA challenges and
+// yes/no confirmations. Programs embedding the client as a library can set
+// Config.PromptUI to route these questions through their own UI instead of
+// the process terminal.
+type PromptUI interface {
+	// AskPassword asks the user a question expecting a secret answer that
+	// must not be echoed back.
+	AskPassword(ctx context.Context, question string) (string, error)
+	// AskOTP asks the user for a one-time token.
+	AskOTP(ctx context.Context, question string) (string, error)
+	// Confirm asks the user a yes/no question and reports the answer.
+	Confirm(ctx context.Context, question string) (bool, error)
+	// PromptMFA asks the user to complete an MFA authentication challenge.
+	PromptMFA(ctx context.Context, proxyAddr string, c *proto.MFAAuthenticateChallenge, opts *PromptMFAChallengeOpts) (*proto.MFAAuthenticateResponse, error)
+}
+
+// promptUI returns the configured PromptUI, falling back to the terminal
+// prompts used by tsh.
+func (tc *TeleportClient) promptUI() PromptUI {
+	if tc.Config.PromptUI != nil {
+		return tc.Config.PromptUI
+	}
+	return &terminalPromptUI{out: tc.Stderr}
+}
+
+// terminalPromptUI is the default PromptUI. It reads answers from the
+// process standard input and writes questions to the client error stream,
+// matching the historic tsh behavior.
+type terminalPromptUI struct {
+	out io.Writer
+}
+
+func (p *terminalPromptUI) AskPassword(ctx context.Context, question string) (string, error) {
+	return prompt.Password(ctx, p.out, prompt.Stdin(), question)
+}
+
+func (p *terminalPromptUI) AskOTP(ctx context.Context, question string) (string, error) {
+	return prompt.Password(ctx, p.out, prompt.Stdin(), question)
+}
+
+func (p *terminalPromptUI) Confirm(ctx context.Context, question string) (bool, error) {
+	return prompt.Confirmation(ctx, p.out, prompt.Stdin(), question)
+}
+
+func (p *terminalPromptUI) PromptMFA(ctx context.Context, proxyAddr string, c *proto.MFAAuthenticateChallenge, opts *PromptMFAChallengeOpts) (*proto.MFAAuthenticateResponse, error) {
+	return PromptMFAChallenge(ctx, c, proxyAddr, opts)
+}
+
+// promptHostKey routes the unknown host key confirmation through the
+// configured PromptUI. It is only installed on the local agent when a custom
+// UI is set, keeping the default terminal prompt otherwise.
+func (tc *TeleportClient) promptHostKey(host string, key ssh.PublicKey) error {
+	ok, err := tc.promptUI().Confirm(context.Background(), fmt.Sprintf(
+		"The authenticity of host '%s' can't be established. Its public key is:\n%s\nAre you sure you want to continue?",
+		host,
+		ssh.MarshalAuthorizedKey(key),
+	))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !ok {
+		return trace.BadParameter("not trusted")
+	}
+	return nil
+}
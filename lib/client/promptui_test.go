@@ -0,0 +1,70 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/teleport/api/client/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePromptUI records the questions asked and returns canned answers.
+type fakePromptUI struct {
+	questions []string
+}
+
+func (p *fakePromptUI) AskPassword(ctx context.Context, question string) (string, error) {
+	p.questions = append(p.questions, question)
+	return "password", nil
+}
+
+func (p *fakePromptUI) AskOTP(ctx context.Context, question string) (string, error) {
+	p.questions = append(p.questions, question)
+	return "123456", nil
+}
+
+func (p *fakePromptUI) Confirm(ctx context.Context, question string) (bool, error) {
+	p.questions = append(p.questions, question)
+	return true, nil
+}
+
+func (p *fakePromptUI) PromptMFA(ctx context.Context, proxyAddr string, c *proto.MFAAuthenticateChallenge, opts *PromptMFAChallengeOpts) (*proto.MFAAuthenticateResponse, error) {
+	return &proto.MFAAuthenticateResponse{}, nil
+}
+
+func TestPromptUIOverride(t *testing.T) {
+	ui := &fakePromptUI{}
+	tc := &TeleportClient{Config: Config{
+		Username: "alice",
+		PromptUI: ui,
+	}}
+
+	password, err := tc.AskPassword(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "password", password)
+
+	otp, err := tc.AskOTP(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "123456", otp)
+
+	require.Equal(t, []string{
+		"Enter password for Teleport user alice",
+		"Enter your OTP token",
+	}, ui.questions)
+}
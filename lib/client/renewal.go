@@ -0,0 +1,118 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// certExpiryPollInterval is how often the expiry watcher re-reads the
+// certificate, picking up renewals that happen before the deadline. It is a
+// variable to allow shortening in tests.
+var certExpiryPollInterval = 30 * time.Second
+
+// CertExpiry returns the expiry time of the SSH certificate for the current
+// profile.
+func (tc *TeleportClient) CertExpiry() (time.Time, error) {
+	if tc.localAgent == nil {
+		return time.Time{}, trace.BadParameter("TeleportClient.CertExpiry called on a client without localAgent")
+	}
+	key, err := tc.localAgent.GetKey(tc.SiteName)
+	if err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	expiry, err := key.CertValidBefore()
+	if err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	return expiry, nil
+}
+
+// WatchCertExpiry returns a channel that receives the certificate expiry
+// time once the certificate is within lead of expiring. Daemons built on the
+// client library (local proxies, forwards) use it to refresh and hot-swap
+// certificates before expiry without dropping active connections: each time
+// the certificate is renewed the watcher re-arms for the new expiry. The
+// channel is closed when the context is done.
+func (tc *TeleportClient) WatchCertExpiry(ctx context.Context, lead time.Duration) (<-chan time.Time, error) {
+	if tc.localAgent == nil {
+		return nil, trace.BadParameter("TeleportClient.WatchCertExpiry called on a client without localAgent")
+	}
+	if lead < 0 {
+		return nil, trace.BadParameter("lead time must not be negative")
+	}
+	ch := make(chan time.Time, 1)
+	go watchCertExpiry(ctx, lead, tc.CertExpiry, ch)
+	return ch, nil
+}
+
+// watchCertExpiry is the watcher loop behind WatchCertExpiry, taking the
+// expiry reader as a parameter so it can be exercised in tests.
+func watchCertExpiry(ctx context.Context, lead time.Duration, getExpiry func() (time.Time, error), ch chan<- time.Time) {
+	defer close(ch)
+	var lastNotified time.Time
+	for {
+		expiry, err := getExpiry()
+		if err != nil {
+			log.WithError(err).Debug("Failed to read certificate expiry.")
+			if !waitOrDone(ctx, certExpiryPollInterval) {
+				return
+			}
+			continue
+		}
+		if expiry.Equal(lastNotified) {
+			// Already notified for this certificate, wait for a renewal.
+			if !waitOrDone(ctx, certExpiryPollInterval) {
+				return
+			}
+			continue
+		}
+		if wait := time.Until(expiry.Add(-lead)); wait > 0 {
+			// Sleep in poll-sized slices so an early renewal re-arms the
+			// watcher for the new expiry.
+			if wait > certExpiryPollInterval {
+				wait = certExpiryPollInterval
+			}
+			if !waitOrDone(ctx, wait) {
+				return
+			}
+			continue
+		}
+		select {
+		case ch <- expiry:
+			lastNotified = expiry
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// waitOrDone sleeps for the given duration, returning false if the context
+// finished first.
+func waitOrDone(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
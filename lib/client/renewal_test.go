@@ -0,0 +1,79 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchCertExpiry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Shorten the poll interval so the watcher picks up the renewal quickly.
+	restore := certExpiryPollInterval
+	certExpiryPollInterval = 10 * time.Millisecond
+	defer func() { certExpiryPollInterval = restore }()
+
+	var mu sync.Mutex
+	expiry := time.Now().Add(time.Minute)
+	getExpiry := func() (time.Time, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return expiry, nil
+	}
+
+	// With a lead time larger than the remaining validity the watcher fires
+	// immediately.
+	ch := make(chan time.Time, 1)
+	go watchCertExpiry(ctx, time.Hour, getExpiry, ch)
+
+	select {
+	case notified := <-ch:
+		mu.Lock()
+		require.Equal(t, expiry, notified)
+		// "Renew" the certificate: the watcher re-arms and, still within
+		// the lead window, fires again for the new expiry.
+		expiry = expiry.Add(time.Minute)
+		mu.Unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for expiry notification")
+	}
+
+	select {
+	case notified := <-ch:
+		mu.Lock()
+		require.Equal(t, expiry, notified)
+		mu.Unlock()
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for renewal notification")
+	}
+
+	// Cancelling the context closes the channel.
+	cancel()
+	select {
+	case _, ok := <-ch:
+		require.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for channel close")
+	}
+}
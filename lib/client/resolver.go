@@ -0,0 +1,141 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/api/profile"
+
+	"github.com/gravitational/trace"
+)
+
+// hostsFileName is the name of the optional hosts override file inside the
+// tsh profile directory. It uses the /etc/hosts format: an address followed
+// by one or more host names, with '#' starting a comment.
+const hostsFileName = "hosts"
+
+// resolverCacheTTL is how long successful DNS lookups are reused before the
+// system resolver is consulted again.
+const resolverCacheTTL = time.Minute
+
+// addrResolver resolves host names in dialed addresses. Overrides from the
+// profile hosts file always win, other lookups go through the system
+// resolver and are cached for a short period. The override file helps in
+// split-horizon DNS or VPN setups where system DNS returns wrong answers
+// for cluster names.
+type addrResolver struct {
+	overrides map[string]string
+
+	mu    sync.Mutex
+	cache map[string]resolverCacheEntry
+}
+
+type resolverCacheEntry struct {
+	host    string
+	expires time.Time
+}
+
+// newAddrResolver creates a resolver with overrides loaded from the hosts
+// file in the given profile directory, if one exists.
+func newAddrResolver(profileDir string) *addrResolver {
+	r := &addrResolver{
+		overrides: make(map[string]string),
+		cache:     make(map[string]resolverCacheEntry),
+	}
+	r.loadOverrides(filepath.Join(profile.FullProfilePath(profileDir), hostsFileName))
+	return r
+}
+
+// loadOverrides parses an /etc/hosts style file into the override map.
+// A missing or malformed file simply results in no overrides.
+func (r *addrResolver) loadOverrides(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+		for _, host := range fields[1:] {
+			r.overrides[strings.ToLower(host)] = fields[0]
+		}
+	}
+}
+
+// resolveAddr resolves the host part of a host:port address. The original
+// address is returned when the host is already an IP address or when
+// resolution fails, leaving the error to the dialer.
+func (r *addrResolver) resolveAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return addr
+	}
+	resolved, err := r.resolveHost(strings.ToLower(host))
+	if err != nil {
+		log.WithError(err).Debugf("Failed to resolve %v, leaving it to the dialer.", host)
+		return addr
+	}
+	return net.JoinHostPort(resolved, port)
+}
+
+// resolveHost resolves a single host name using overrides, the cache and
+// finally the system resolver.
+func (r *addrResolver) resolveHost(host string) (string, error) {
+	if override, ok := r.overrides[host]; ok {
+		return override, nil
+	}
+
+	r.mu.Lock()
+	entry, ok := r.cache[host]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.host, nil
+	}
+
+	addrs, err := net.LookupHost(host)
+	if err != nil || len(addrs) == 0 {
+		return "", trace.Wrap(err, "failed to resolve %v", host)
+	}
+
+	r.mu.Lock()
+	r.cache[host] = resolverCacheEntry{
+		host:    addrs[0],
+		expires: time.Now().Add(resolverCacheTTL),
+	}
+	r.mu.Unlock()
+	return addrs[0], nil
+}
+
+// resolveAddr applies the profile hosts override file and the caching
+// resolver to an address before it is dialed.
+func (tc *TeleportClient) resolveAddr(addr string) string {
+	tc.resolverOnce.Do(func() {
+		tc.resolver = newAddrResolver(tc.HomePath)
+	})
+	return tc.resolver.resolveAddr(addr)
+}
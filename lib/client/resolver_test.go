@@ -0,0 +1,45 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddrResolverOverrides(t *testing.T) {
+	profileDir := t.TempDir()
+	hosts := `# split-horizon overrides
+10.0.0.1 proxy.example.com alias.example.com
+not-an-ip ignored.example.com
+10.0.0.2
+`
+	require.NoError(t, os.WriteFile(filepath.Join(profileDir, hostsFileName), []byte(hosts), 0600))
+
+	r := newAddrResolver(profileDir)
+
+	// Overridden hosts resolve through the hosts file, case-insensitively.
+	require.Equal(t, "10.0.0.1:3023", r.resolveAddr("proxy.example.com:3023"))
+	require.Equal(t, "10.0.0.1:443", r.resolveAddr("Alias.Example.Com:443"))
+
+	// IP addresses and malformed lines are left untouched.
+	require.Equal(t, "127.0.0.1:3023", r.resolveAddr("127.0.0.1:3023"))
+	require.NotContains(t, r.overrides, "ignored.example.com")
+}
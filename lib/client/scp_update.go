@@ -0,0 +1,184 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/sshutils/scp"
+
+	"github.com/gravitational/trace"
+)
+
+// uploadPair maps a local source file to the remote paths it could land at.
+// A single-file upload can name either the destination file itself or a
+// directory to copy into, so both candidates are checked.
+type uploadPair struct {
+	local  string
+	remote []string
+}
+
+// markUnchangedFiles compares the checksums of the files about to be
+// uploaded against their destination counterparts on the node and records
+// the matching ones in the transfer manifest so they are skipped. The
+// comparison is best-effort: any failure simply means everything is copied.
+func markUnchangedFiles(ctx context.Context, nodeClient *NodeClient, manifest *scp.Manifest, sources []string, destPath string, recursive bool, progressWriter io.Writer) error {
+	pairs, err := collectUploadPairs(sources, destPath, recursive)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var remotePaths []string
+	for _, pair := range pairs {
+		remotePaths = append(remotePaths, pair.remote...)
+	}
+	remoteSums, err := nodeClient.remoteChecksums(ctx, remotePaths)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, pair := range pairs {
+		localSum, err := fileSHA256(pair.local)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		for _, remote := range pair.remote {
+			if remoteSums[remote] == localSum {
+				manifest.MarkComplete(pair.local)
+				if progressWriter != nil {
+					fmt.Fprintf(progressWriter, "Skipping %v: destination is up to date\n", pair.local)
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// collectUploadPairs expands the upload sources into (local, remote
+// candidates) pairs, walking directories when the copy is recursive.
+// Remote paths use the same layout scp produces: files copy to
+// dest/basename and directories recreate themselves under dest.
+func collectUploadPairs(sources []string, destPath string, recursive bool) ([]uploadPair, error) {
+	if destPath == "" {
+		destPath = "."
+	}
+	var pairs []uploadPair
+	for _, source := range sources {
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		if !info.IsDir() {
+			candidates := []string{path.Join(destPath, filepath.Base(source))}
+			if len(sources) == 1 {
+				candidates = append(candidates, destPath)
+			}
+			pairs = append(pairs, uploadPair{local: source, remote: candidates})
+			continue
+		}
+		if !recursive {
+			// The transfer itself rejects directories without -r; leave
+			// the error to it.
+			continue
+		}
+		root := path.Join(destPath, filepath.Base(source))
+		err = filepath.Walk(source, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(source, p)
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, uploadPair{
+				local:  p,
+				remote: []string{path.Join(root, filepath.ToSlash(rel))},
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+	}
+	return pairs, nil
+}
+
+// fileSHA256 returns the hex-encoded SHA256 checksum of a local file.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// remoteChecksums runs sha256sum on the node for the given paths and returns
+// a path to checksum map. Paths that do not exist on the node are simply
+// absent from the result: sha256sum still prints the lines for the files it
+// could read even when it exits non-zero.
+func (c *NodeClient) remoteChecksums(ctx context.Context, paths []string) (map[string]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	s, err := c.Client.NewSession()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer s.Close()
+
+	quoted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		quoted = append(quoted, shellQuote(p))
+	}
+	out, _ := s.Output("sha256sum -- " + strings.Join(quoted, " "))
+	return parseChecksumOutput(out), nil
+}
+
+// parseChecksumOutput parses "checksum  path" lines as printed by sha256sum,
+// ignoring anything that does not start with a hex checksum.
+func parseChecksumOutput(out []byte) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			continue
+		}
+		if _, err := hex.DecodeString(fields[0]); err != nil {
+			continue
+		}
+		sums[strings.TrimSpace(fields[1])] = fields[0]
+	}
+	return sums
+}
+
+// shellQuote single-quotes a string for safe interpolation into a remote
+// shell command.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
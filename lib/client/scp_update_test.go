@@ -0,0 +1,67 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectUploadPairs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "artifact"), []byte("data"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "build", "bin"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build", "bin", "app"), []byte("bin"), 0600))
+
+	// A single file checks both the destination itself and dest/basename.
+	pairs, err := collectUploadPairs([]string{filepath.Join(dir, "artifact")}, "/tmp/out", false)
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+	require.ElementsMatch(t, []string{"/tmp/out/artifact", "/tmp/out"}, pairs[0].remote)
+
+	// A recursive copy recreates the directory under the destination.
+	pairs, err = collectUploadPairs([]string{filepath.Join(dir, "build")}, "/tmp/out", true)
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+	require.Equal(t, filepath.Join(dir, "build", "bin", "app"), pairs[0].local)
+	require.Equal(t, []string{"/tmp/out/build/bin/app"}, pairs[0].remote)
+
+	// An empty destination means the remote home directory.
+	pairs, err = collectUploadPairs([]string{filepath.Join(dir, "artifact")}, "", false)
+	require.NoError(t, err)
+	require.Equal(t, "artifact", pairs[0].remote[0])
+}
+
+func TestParseChecksumOutput(t *testing.T) {
+	sumA := strings.Repeat("ab", 32)
+	sumB := strings.Repeat("cd", 32)
+	out := []byte(sumA + "  /tmp/out/artifact\nsha256sum: /tmp/out/missing: No such file or directory\n" + sumB + "  /tmp/out/other\n")
+	sums := parseChecksumOutput(out)
+	require.Equal(t, map[string]string{
+		"/tmp/out/artifact": sumA,
+		"/tmp/out/other":    sumB,
+	}, sums)
+}
+
+func TestShellQuote(t *testing.T) {
+	require.Equal(t, `'/tmp/a b'`, shellQuote("/tmp/a b"))
+	require.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
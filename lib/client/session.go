@@ -503,12 +503,31 @@ func (ns *NodeSession) runShell(ctx context.Context, mode types.SessionParticipa
 	})
 }
 
+// runNonInteractiveShell executes the user's shell on the remote node without
+// allocating a server-side terminal, mirroring `ssh -T` / RequestTTY=no.
+func (ns *NodeSession) runNonInteractiveShell(ctx context.Context, callback ShellCreatedCallback) error {
+	return ns.regularSession(ctx, func(s *ssh.Session) error {
+		if err := s.Shell(); err != nil {
+			return trace.Wrap(err)
+		}
+		if callback != nil {
+			exit, err := callback(s, ns.NodeClient().Client, nil)
+			if exit {
+				return trace.Wrap(err)
+			}
+		}
+		return trace.Wrap(s.Wait())
+	})
+}
+
 // runCommand executes a "exec" request either in interactive mode (with a
-// TTY attached) or non-intractive mode (no TTY).
-func (ns *NodeSession) runCommand(ctx context.Context, mode types.SessionParticipantMode, cmd []string, callback ShellCreatedCallback, interactive bool) error {
+// TTY attached) or non-intractive mode (no TTY). When forceTTY is set, a
+// terminal is allocated on the server even if stdin is not a terminal,
+// mirroring RequestTTY=force / `ssh -tt`.
+func (ns *NodeSession) runCommand(ctx context.Context, mode types.SessionParticipantMode, cmd []string, callback ShellCreatedCallback, interactive, forceTTY bool) error {
 	// If stdin is not a terminal, refuse to allocate terminal on the server and
-	// fallback to non-interactive mode
-	if interactive && !ns.terminal.IsAttached() {
+	// fallback to non-interactive mode, unless TTY allocation was forced.
+	if interactive && !forceTTY && !ns.terminal.IsAttached() {
 		interactive = false
 		fmt.Fprintf(os.Stderr, "TTY will not be allocated on the server because stdin is not a terminal\n")
 	}
@@ -651,13 +670,13 @@ func handleNonPeerControls(mode types.SessionParticipantMode, term *terminal.Ter
 
 // handlePeerControls streams the terminal input to the remote shell's standard input.
 // Escape sequences for stopping the stream on the client side are supported via `escape.NewReader`.
-func handlePeerControls(term *terminal.Terminal, remoteStdin io.Writer) {
+func handlePeerControls(term *terminal.Terminal, remoteStdin io.Writer, onInfo func(io.Writer)) {
 	stdin := term.Stdin()
 	if term.IsAttached() {
 		// escape.NewReader is used to enable manual disconnect sequences as those supported
 		// by tsh. These can be used to force a client disconnect since CTRL-C is merely passed
 		// to the other end and not interpreted as an exit request locally
-		stdin = escape.NewReader(stdin, term.Stderr(), func(err error) {
+		reader := escape.NewReader(stdin, term.Stderr(), func(err error) {
 			log.Debugf("escape.NewReader error: %v", err)
 
 			switch err {
@@ -669,6 +688,8 @@ func handlePeerControls(term *terminal.Terminal, remoteStdin io.Writer) {
 				fmt.Fprintf(term.Stderr(), "\r\nunknown error: %v\r\n", err.Error())
 			}
 		})
+		reader.SetOnInfo(onInfo)
+		stdin = reader
 	}
 
 	_, err := io.Copy(remoteStdin, stdin)
@@ -705,11 +726,36 @@ func (ns *NodeSession) pipeInOut(shell io.ReadWriteCloser, mode types.SessionPar
 	case types.SessionPeerMode:
 		// copy from the local input to the remote shell:
 		go func() {
-			handlePeerControls(ns.terminal, shell)
+			handlePeerControls(ns.terminal, shell, ns.writeSessionInfo)
 		}()
 	}
 }
 
+// writeSessionInfo prints metadata about the current session to w. It is
+// invoked by the "~i" escape sequence, so the output is formatted for a raw
+// terminal and is written to stderr to not disturb the remote stream.
+func (ns *NodeSession) writeSessionInfo(w io.Writer) {
+	tc := ns.nodeClient.TC
+	fmt.Fprint(w, "\r\nTeleport session:\r\n")
+	fmt.Fprintf(w, "  Session ID: %v\r\n", ns.id)
+	fmt.Fprintf(w, "  Node: %v\r\n", ns.nodeClient.Client.RemoteAddr())
+	fmt.Fprintf(w, "  Cluster: %v\r\n", tc.SiteName)
+	if key, err := tc.LocalAgent().GetKey(tc.SiteName); err == nil {
+		if expires, err := key.CertValidBefore(); err == nil {
+			fmt.Fprintf(w, "  Certificate expires: %v\r\n", expires.Format(time.RFC1123))
+		}
+	}
+	for _, stats := range ns.nodeClient.ForwardStats() {
+		kind := "Forwarded port"
+		if stats.Dynamic {
+			kind = "Dynamically forwarded port"
+		}
+		in, out := stats.Bytes()
+		fmt.Fprintf(w, "  %v: %v (%v connections, %v bytes received, %v bytes sent)\r\n",
+			kind, stats.Spec, stats.Connections(), in, out)
+	}
+}
+
 func (ns *NodeSession) Close() error {
 	if ns.closer != nil {
 		ns.closer.Close()
@@ -0,0 +1,268 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/session"
+	"github.com/gravitational/teleport/lib/sshutils"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+)
+
+// SessionConfig configures a programmatic SSH session opened with
+// TeleportClient.OpenSession. Unlike TeleportClient.SSH, which drives the
+// local terminal, OpenSession hands control of the session streams to the
+// caller so automation written in Go can run expect-style interactions
+// without exec'ing the tsh binary.
+type SessionConfig struct {
+	// Host is the target node, defaulting to the client's configured host.
+	Host string
+	// HostLogin is the login on the node, defaulting to the client's
+	// configured host login.
+	HostLogin string
+	// Command, when set, is executed on the node instead of the login shell.
+	Command []string
+	// Env is extra environment variables to set on the remote session.
+	Env map[string]string
+	// TermType is the terminal type requested from the server, defaulting
+	// to the safe terminal type.
+	TermType string
+	// TermWidth and TermHeight override the default terminal dimensions.
+	TermWidth, TermHeight int
+	// Stdout, when set, receives a copy of the session output in addition
+	// to the OnOutput hook.
+	Stdout io.Writer
+	// OnConnect is called once the session is established, before any
+	// output is delivered.
+	OnConnect func(s *SessionHandle)
+	// OnOutput is called with each chunk of session output (stdout and
+	// stderr combined, as produced by the server-side terminal). The slice
+	// is only valid for the duration of the call.
+	OnOutput func(data []byte)
+	// OnClose is called exactly once when the session ends, with the
+	// session error, if any.
+	OnClose func(err error)
+}
+
+// SessionHandle represents a session opened with OpenSession. Input is sent
+// to the remote shell with Write, and Wait blocks until the session ends.
+type SessionHandle struct {
+	id          session.ID
+	sess        *ssh.Session
+	nodeClient  *NodeClient
+	proxyClient *ProxyClient
+	stdin       io.WriteCloser
+
+	closeOnce sync.Once
+	done      chan struct{}
+	mu        sync.Mutex
+	err       error
+}
+
+// OpenSession connects to the target node and starts an interactive session
+// (or the given command) with the caller in control of the session streams.
+// The returned handle must be closed by the caller.
+func (tc *TeleportClient) OpenSession(ctx context.Context, cfg SessionConfig) (*SessionHandle, error) {
+	if !tc.Config.ProxySpecified() {
+		return nil, trace.BadParameter("proxy server is not specified")
+	}
+	host := cfg.Host
+	if host == "" {
+		host = tc.Host
+	}
+	if host == "" {
+		return nil, trace.BadParameter("no target host specified")
+	}
+	login := cfg.HostLogin
+	if login == "" {
+		login = tc.Config.HostLogin
+	}
+
+	proxyClient, err := tc.ConnectToProxy(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	siteInfo, err := proxyClient.currentCluster()
+	if err != nil {
+		proxyClient.Close()
+		return nil, trace.Wrap(err)
+	}
+	nodeClient, err := proxyClient.ConnectToNode(ctx,
+		NodeAddr{Addr: host, Namespace: tc.Namespace, Cluster: siteInfo.Name},
+		login, false)
+	if err != nil {
+		proxyClient.Close()
+		return nil, trace.Wrap(err)
+	}
+
+	handle, err := startSessionHandle(nodeClient, proxyClient, cfg)
+	if err != nil {
+		nodeClient.Close()
+		proxyClient.Close()
+		return nil, trace.Wrap(err)
+	}
+	return handle, nil
+}
+
+// startSessionHandle establishes the server-side session on an existing node
+// connection and starts the output pump.
+func startSessionHandle(nodeClient *NodeClient, proxyClient *ProxyClient, cfg SessionConfig) (*SessionHandle, error) {
+	sess, err := nodeClient.Client.NewSession()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	h := &SessionHandle{
+		id:          session.NewID(),
+		sess:        sess,
+		nodeClient:  nodeClient,
+		proxyClient: proxyClient,
+		done:        make(chan struct{}),
+	}
+
+	// Register the session ID so the session is tracked and recorded like
+	// any other.
+	if err := sess.Setenv(sshutils.SessionEnvVar, string(h.id)); err != nil {
+		log.Warn(err)
+	}
+	for key, val := range cfg.Env {
+		if err := sess.Setenv(key, val); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	termType := cfg.TermType
+	if termType == "" {
+		termType = teleport.SafeTerminalType
+	}
+	width := cfg.TermWidth
+	if width == 0 {
+		width = teleport.DefaultTerminalWidth
+	}
+	height := cfg.TermHeight
+	if height == 0 {
+		height = teleport.DefaultTerminalHeight
+	}
+	if err := sess.RequestPty(termType, height, width, ssh.TerminalModes{}); err != nil {
+		sess.Close()
+		return nil, trace.Wrap(err)
+	}
+
+	h.stdin, err = sess.StdinPipe()
+	if err != nil {
+		sess.Close()
+		return nil, trace.Wrap(err)
+	}
+	stdout, err := sess.StdoutPipe()
+	if err != nil {
+		sess.Close()
+		return nil, trace.Wrap(err)
+	}
+
+	if len(cfg.Command) > 0 {
+		err = sess.Start(strings.Join(cfg.Command, " "))
+	} else {
+		err = sess.Shell()
+	}
+	if err != nil {
+		sess.Close()
+		return nil, trace.Wrap(err)
+	}
+
+	if cfg.OnConnect != nil {
+		cfg.OnConnect(h)
+	}
+
+	go h.pumpOutput(stdout, cfg)
+	return h, nil
+}
+
+// pumpOutput delivers session output to the configured writer and hooks and
+// finalizes the handle when the session ends.
+func (h *SessionHandle) pumpOutput(stdout io.Reader, cfg SessionConfig) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := stdout.Read(buf)
+		if n > 0 {
+			if cfg.Stdout != nil {
+				cfg.Stdout.Write(buf[:n])
+			}
+			if cfg.OnOutput != nil {
+				cfg.OnOutput(buf[:n])
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	err := h.sess.Wait()
+	if err == io.EOF {
+		err = nil
+	}
+	h.mu.Lock()
+	h.err = err
+	h.mu.Unlock()
+
+	h.release()
+	if cfg.OnClose != nil {
+		cfg.OnClose(err)
+	}
+}
+
+// ID returns the Teleport session ID.
+func (h *SessionHandle) ID() session.ID {
+	return h.id
+}
+
+// Write sends input to the remote shell.
+func (h *SessionHandle) Write(data []byte) (int, error) {
+	return h.stdin.Write(data)
+}
+
+// Wait blocks until the session ends and returns the session error, if any.
+func (h *SessionHandle) Wait() error {
+	<-h.done
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// Close terminates the session and releases the underlying connections. It
+// is safe to call multiple times and after the session has ended.
+func (h *SessionHandle) Close() error {
+	h.stdin.Close()
+	h.sess.Close()
+	h.release()
+	return nil
+}
+
+// release tears down the underlying connections exactly once.
+func (h *SessionHandle) release() {
+	h.closeOnce.Do(func() {
+		h.nodeClient.Close()
+		h.proxyClient.Close()
+		close(h.done)
+	})
+}
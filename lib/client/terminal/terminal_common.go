@@ -16,10 +16,13 @@ limitations under the License.
 package terminal
 
 import (
+	"io"
+	"os"
 	"sync"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/trace"
+	"github.com/moby/term"
 	"github.com/sirupsen/logrus"
 )
 
@@ -68,6 +71,77 @@ func (e *signalEmitter) clearSubscribers() {
 	e.subscribers = e.subscribers[:0]
 }
 
+const (
+	// enterAltScreenSeq switches the terminal to the alternate screen
+	// buffer, leaving the main screen and its scrollback untouched.
+	enterAltScreenSeq = "\x1b[?1049h"
+	// exitAltScreenSeq switches back to the main screen buffer, restoring
+	// whatever was on screen before the alternate screen was entered.
+	exitAltScreenSeq = "\x1b[?1049l"
+	// restoreModesSeq resets modes that remote full-screen programs leave
+	// enabled when a session ends abnormally: it leaves the alternate
+	// screen, shows the cursor, disables mouse reporting and bracketed
+	// paste, re-enables auto-wrap and resets colors and attributes. All of
+	// these are no-ops when the corresponding mode is not active, so the
+	// sequence is safe to emit unconditionally.
+	restoreModesSeq = exitAltScreenSeq +
+		"\x1b[?25h" + // show cursor
+		"\x1b[?1000l\x1b[?1002l\x1b[?1003l\x1b[?1006l" + // disable mouse reporting
+		"\x1b[?2004l" + // disable bracketed paste
+		"\x1b[?7h" + // re-enable auto-wrap
+		"\x1b[0m\r" // reset attributes, return carriage
+)
+
+// attachedStdout returns the stdout stream when it is attached to a
+// terminal. Escape sequences are only ever written to real terminals, so
+// redirected output is not polluted with control characters.
+func (t *Terminal) attachedStdout() (io.Writer, bool) {
+	f, ok := t.Stdout().(*os.File)
+	if !ok || !term.IsTerminal(f.Fd()) {
+		return nil, false
+	}
+	return f, true
+}
+
+// EnterAlternateScreen switches the attached terminal to the alternate
+// screen buffer so full-screen output does not consume local scrollback.
+// It is a no-op when stdout is not a terminal.
+func (t *Terminal) EnterAlternateScreen() error {
+	out, ok := t.attachedStdout()
+	if !ok {
+		return nil
+	}
+	_, err := io.WriteString(out, enterAltScreenSeq)
+	return trace.Wrap(err)
+}
+
+// ExitAlternateScreen switches the attached terminal back to the main
+// screen buffer, restoring the screen contents and scrollback from before
+// EnterAlternateScreen. It is a no-op when stdout is not a terminal.
+func (t *Terminal) ExitAlternateScreen() error {
+	out, ok := t.attachedStdout()
+	if !ok {
+		return nil
+	}
+	_, err := io.WriteString(out, exitAltScreenSeq)
+	return trace.Wrap(err)
+}
+
+// Restore returns the attached terminal to its default modes: main screen
+// buffer, visible cursor, no mouse reporting, no bracketed paste. Remote
+// full-screen programs enable these modes directly on the local terminal,
+// so an abnormal session exit leaves them active and the terminal garbled;
+// Close emits this reset on every teardown since the sequence is harmless
+// when no mode is active.
+func (t *Terminal) Restore() error {
+	out, ok := t.attachedStdout()
+	if !ok {
+		return nil
+	}
+	_, err := io.WriteString(out, restoreModesSeq)
+	return trace.Wrap(err)
+}
+
 // Clear clears the terminal, including scrollback.
 func (t *Terminal) Clear() error {
 	// \x1b[3J - clears scrollback (it is needed at least for the Mac terminal) -
@@ -275,6 +275,11 @@ func (t *Terminal) Stderr() io.Writer {
 // Close closes the Terminal, restoring the console to its original state.
 // Potentially blocks on cleanup tasks.
 func (t *Terminal) Close() error {
+	// Reset terminal modes the remote end may have left enabled before
+	// handing the cooked terminal back to the user.
+	if err := t.Restore(); err != nil {
+		log.Warnf("Failed to restore terminal modes: %v.", err)
+	}
 	t.clearSubscribers()
 	if err := t.closer.Close(); err != nil {
 		return trace.Wrap(err)
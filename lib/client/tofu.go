@@ -0,0 +1,196 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/teleport/api/profile"
+
+	"github.com/gravitational/trace"
+)
+
+// tofuFileName is the file in the profile directory that records pinned
+// proxy TLS certificate fingerprints, one "proxyaddr fingerprint" pair per
+// line.
+const tofuFileName = "tofu_hosts"
+
+// tofuMismatchWarning is shown when the certificate presented by a pinned
+// proxy changes. It mirrors the tone of the OpenSSH known-hosts warning on
+// purpose: a silent certificate change in a TOFU deployment can mean an
+// attacker is intercepting the connection.
+const tofuMismatchWarning = `WARNING: the TLS certificate presented by proxy %v does not match the pinned fingerprint!
+
+Pinned:    %v
+Presented: %v
+
+This can mean the proxy certificate was rotated, or that the connection is
+being intercepted. If the certificate rotation is expected, remove the line
+for this proxy from %v and connect again`
+
+// TOFUStore persists proxy TLS certificate fingerprints recorded on first
+// use, giving environments without a public CA a safer alternative to
+// disabling certificate verification entirely.
+type TOFUStore struct {
+	// mu serializes check-and-pin sequences: the verification callback runs
+	// for every TLS connection and connections can be established
+	// concurrently.
+	mu sync.Mutex
+	// path is the location of the pin file.
+	path string
+}
+
+// NewTOFUStore returns a store backed by the tofu_hosts file in the profile
+// directory. An empty homePath uses the default profile location.
+func NewTOFUStore(homePath string) *TOFUStore {
+	return &TOFUStore{path: filepath.Join(profile.FullProfilePath(homePath), tofuFileName)}
+}
+
+// TLSCertFingerprint returns the SHA-256 fingerprint of the certificate in
+// the form "sha256:<hex>".
+func TLSCertFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// GetPin returns the pinned fingerprint for the proxy, or an empty string
+// if the proxy has not been seen before.
+func (s *TOFUStore) GetPin(proxyAddr string) (string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", trace.ConvertSystemError(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == proxyAddr {
+			return fields[1], nil
+		}
+	}
+	return "", nil
+}
+
+// SetPin records the fingerprint for the proxy, replacing any existing pin.
+func (s *TOFUStore) SetPin(proxyAddr, fingerprint string) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	var lines []string
+	data, err := os.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] == proxyAddr {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, proxyAddr+" "+fingerprint)
+	out := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(s.path, []byte(out), 0600); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+// Verify checks the presented fingerprint against the pin recorded for the
+// proxy, recording it if the proxy has not been seen before. It returns true
+// when the fingerprint was newly pinned, and an error when the presented
+// fingerprint does not match the existing pin.
+func (s *TOFUStore) Verify(proxyAddr, fingerprint string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pin, err := s.GetPin(proxyAddr)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	switch pin {
+	case "":
+		if err := s.SetPin(proxyAddr, fingerprint); err != nil {
+			return false, trace.Wrap(err)
+		}
+		return true, nil
+	case fingerprint:
+		return false, nil
+	default:
+		return false, trace.AccessDenied(tofuMismatchWarning, proxyAddr, pin, fingerprint, s.path)
+	}
+}
+
+// tofuVerifyConnection returns a TLS verification callback that enforces the
+// pinned proxy certificate fingerprint on every connection, or nil when TOFU
+// is disabled. The callback is meant to be installed as
+// tls.Config.VerifyConnection, which the TLS stack invokes even with
+// InsecureSkipVerify set: CA verification is skipped on purpose, trust comes
+// from the pin instead, and a fingerprint mismatch fails the handshake.
+func (tc *TeleportClient) tofuVerifyConnection() func(tls.ConnectionState) error {
+	if !tc.TOFU {
+		return nil
+	}
+	store := NewTOFUStore(tc.HomePath)
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return trace.AccessDenied("proxy %v presented no TLS certificate", tc.WebProxyAddr)
+		}
+		fingerprint := TLSCertFingerprint(cs.PeerCertificates[0])
+		firstUse, err := store.Verify(tc.WebProxyAddr, fingerprint)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if firstUse {
+			fmt.Fprintf(tc.Stderr, "First connection to proxy %v, pinned TLS certificate fingerprint %v.\n",
+				tc.WebProxyAddr, fingerprint)
+		} else {
+			log.Debugf("Proxy %v matches the pinned TLS certificate fingerprint.", tc.WebProxyAddr)
+		}
+		return nil
+	}
+}
+
+// applyTOFU installs the pinned-fingerprint check on the given TLS config.
+// The standard certificate chain verification is replaced by the pin: any
+// VerifyConnection callback already present keeps running after the pin
+// check passes.
+func (tc *TeleportClient) applyTOFU(cfg *tls.Config) {
+	if !tc.TOFU || cfg == nil {
+		return
+	}
+	verifyPin := tc.tofuVerifyConnection()
+	verifyPrev := cfg.VerifyConnection
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if err := verifyPin(cs); err != nil {
+			return trace.Wrap(err)
+		}
+		if verifyPrev != nil {
+			return trace.Wrap(verifyPrev(cs))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOFUStore(t *testing.T) {
+	store := NewTOFUStore(t.TempDir())
+
+	// Unknown proxies have no pin.
+	pin, err := store.GetPin("proxy.example.com:3080")
+	require.NoError(t, err)
+	require.Empty(t, pin)
+
+	// Pins round-trip and don't interfere with each other.
+	require.NoError(t, store.SetPin("proxy.example.com:3080", "sha256:aaaa"))
+	require.NoError(t, store.SetPin("other.example.com:3080", "sha256:bbbb"))
+
+	pin, err = store.GetPin("proxy.example.com:3080")
+	require.NoError(t, err)
+	require.Equal(t, "sha256:aaaa", pin)
+
+	// Re-pinning replaces the existing entry.
+	require.NoError(t, store.SetPin("proxy.example.com:3080", "sha256:cccc"))
+	pin, err = store.GetPin("proxy.example.com:3080")
+	require.NoError(t, err)
+	require.Equal(t, "sha256:cccc", pin)
+
+	pin, err = store.GetPin("other.example.com:3080")
+	require.NoError(t, err)
+	require.Equal(t, "sha256:bbbb", pin)
+}
+
+func TestTOFUStoreVerify(t *testing.T) {
+	store := NewTOFUStore(t.TempDir())
+
+	// The first fingerprint for a proxy gets pinned.
+	firstUse, err := store.Verify("proxy.example.com:3080", "sha256:aaaa")
+	require.NoError(t, err)
+	require.True(t, firstUse)
+
+	// The same fingerprint keeps verifying.
+	firstUse, err = store.Verify("proxy.example.com:3080", "sha256:aaaa")
+	require.NoError(t, err)
+	require.False(t, firstUse)
+
+	// A changed fingerprint fails closed.
+	_, err = store.Verify("proxy.example.com:3080", "sha256:bbbb")
+	require.True(t, trace.IsAccessDenied(err))
+}
+
+func TestApplyTOFU(t *testing.T) {
+	certA := newTestTLSCert(t)
+	certB := newTestTLSCert(t)
+
+	tc := &TeleportClient{Config: Config{
+		TOFU:         true,
+		HomePath:     t.TempDir(),
+		WebProxyAddr: "proxy.example.com:3080",
+		Stderr:       io.Discard,
+	}}
+
+	cfg := &tls.Config{}
+	tc.applyTOFU(cfg)
+	require.True(t, cfg.InsecureSkipVerify)
+	require.NotNil(t, cfg.VerifyConnection)
+
+	// The first connection pins the certificate, matching connections pass.
+	require.NoError(t, cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{certA}}))
+	require.NoError(t, cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{certA}}))
+
+	// A different certificate fails the handshake, and so does a peer that
+	// presents no certificate at all.
+	err := cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{certB}})
+	require.True(t, trace.IsAccessDenied(err))
+	err = cfg.VerifyConnection(tls.ConnectionState{})
+	require.True(t, trace.IsAccessDenied(err))
+
+	// Without TOFU the config is left untouched.
+	cfg = &tls.Config{}
+	(&TeleportClient{}).applyTOFU(cfg)
+	require.False(t, cfg.InsecureSkipVerify)
+	require.Nil(t, cfg.VerifyConnection)
+}
+
+// newTestTLSCert generates a self-signed certificate for fingerprint tests.
+func newTestTLSCert(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "proxy.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
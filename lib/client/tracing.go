@@ -0,0 +1,120 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// traceFileEnv enables client operation tracing. When set to a file path,
+// spans covering the major client operations (login, proxy dialing, session
+// establishment, listings) are appended there as JSON lines following the
+// OpenTelemetry span data model with OTLP/JSON field names, so they can be
+// ingested by a collector and correlated end-to-end.
+const traceFileEnv = "TELEPORT_TRACE_FILE"
+
+// spanStatusError is the OTLP status code for a failed span.
+const spanStatusError = 2
+
+// spanContextKey carries the current span through the context so child
+// spans join the same trace.
+type spanContextKey struct{}
+
+// clientSpan is a single traced client operation.
+type clientSpan struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	StartTimeUnixNano int64       `json:"startTimeUnixNano"`
+	EndTimeUnixNano   int64       `json:"endTimeUnixNano"`
+	Status            *spanStatus `json:"status,omitempty"`
+	start             time.Time
+}
+
+// spanStatus mirrors the OTLP span status message.
+type spanStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// startSpan begins a span for the named operation and returns a context
+// that makes it the parent of nested spans, plus a function to end the
+// span with the operation's error. Tracing is off unless TELEPORT_TRACE_FILE
+// is set, in which case the returned end function appends the finished span
+// to the file.
+func startSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	if os.Getenv(traceFileEnv) == "" {
+		return ctx, func(error) {}
+	}
+	span := &clientSpan{
+		Name:   name,
+		SpanID: randomHex(8),
+		start:  time.Now(),
+	}
+	if parent, ok := ctx.Value(spanContextKey{}).(*clientSpan); ok {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+	} else {
+		span.TraceID = randomHex(16)
+	}
+	ctx = context.WithValue(ctx, spanContextKey{}, span)
+	return ctx, func(opErr error) {
+		finishSpan(span, opErr)
+	}
+}
+
+// finishSpan stamps the span end time and appends it to the trace file.
+// Failures to record are logged and otherwise ignored: tracing must never
+// break the traced operation.
+func finishSpan(span *clientSpan, opErr error) {
+	span.StartTimeUnixNano = span.start.UnixNano()
+	span.EndTimeUnixNano = time.Now().UnixNano()
+	if opErr != nil {
+		span.Status = &spanStatus{Code: spanStatusError, Message: opErr.Error()}
+	}
+	path := os.Getenv(traceFileEnv)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		log.WithError(err).Debugf("Failed to open trace file %v.", path)
+		return
+	}
+	defer f.Close()
+	line, err := json.Marshal(span)
+	if err != nil {
+		log.WithError(err).Debug("Failed to encode span.")
+		return
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.WithError(err).Debugf("Failed to write trace file %v.", path)
+	}
+}
+
+// randomHex returns n random bytes hex-encoded, matching the OTLP trace and
+// span ID wire format.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartSpan(t *testing.T) {
+	// Tracing is a no-op unless enabled.
+	ctx, span := startSpan(context.Background(), "noop")
+	require.NotNil(t, ctx)
+	span(nil)
+
+	path := filepath.Join(t.TempDir(), "trace.json")
+	os.Setenv(traceFileEnv, path)
+	defer os.Unsetenv(traceFileEnv)
+
+	ctx, parentSpan := startSpan(context.Background(), "parent")
+	_, childSpan := startSpan(ctx, "child")
+	childSpan(trace.ConnectionProblem(nil, "proxy unreachable"))
+	parentSpan(nil)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var child, parent clientSpan
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &child))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &parent))
+
+	// The child joins the parent's trace and records the failure.
+	require.Equal(t, parent.TraceID, child.TraceID)
+	require.Equal(t, parent.SpanID, child.ParentSpanID)
+	require.NotNil(t, child.Status)
+	require.Equal(t, spanStatusError, child.Status.Code)
+	require.Contains(t, child.Status.Message, "proxy unreachable")
+	require.Nil(t, parent.Status)
+	require.LessOrEqual(t, child.StartTimeUnixNano, child.EndTimeUnixNano)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/api/client/webclient"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// printVersionCheckWarning compares the running client version against the
+// minimum version advertised by the proxy and, if the client is too old,
+// prints upgrade instructions including the download URL for the matching
+// tsh build.
+func printVersionCheckWarning(out io.Writer, pr *webclient.PingResponse) {
+	err := utils.CheckVersion(teleport.Version, pr.MinClientVersion)
+	if err == nil || !trace.IsBadParameter(err) {
+		return
+	}
+	// Suggest the server's own version when known: it is always at least the
+	// minimum supported version and keeps client and server in lockstep.
+	suggested := pr.MinClientVersion
+	if pr.ServerVersion != "" {
+		suggested = pr.ServerVersion
+	}
+	fmt.Fprintf(out, `
+WARNING
+Detected potentially incompatible client and server versions.
+Minimum client version supported by the server is %v but you are using %v.
+Please upgrade tsh to %v or newer:
+
+    %v
+
+Use the --skip-version-check flag to bypass this check.
+Future versions of tsh will fail when incompatible versions are detected.
+`, pr.MinClientVersion, teleport.Version, suggested, downloadURLForVersion(suggested))
+}
+
+// downloadURLForVersion returns the download URL for the Teleport build
+// matching the given version on the current platform, falling back to the
+// generic downloads page for unrecognized platforms.
+func downloadURLForVersion(version string) string {
+	switch runtime.GOOS {
+	case "linux", "darwin":
+		return fmt.Sprintf("https://get.gravitational.com/teleport-v%v-%v-%v-bin.tar.gz", version, runtime.GOOS, runtime.GOARCH)
+	case "windows":
+		return fmt.Sprintf("https://get.gravitational.com/teleport-v%v-windows-%v-bin.zip", version, runtime.GOARCH)
+	default:
+		return "https://goteleport.com/download/"
+	}
+}
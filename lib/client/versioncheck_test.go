@@ -0,0 +1,48 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gravitational/teleport/api/client/webclient"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintVersionCheckWarning(t *testing.T) {
+	// A compatible client prints nothing.
+	var out bytes.Buffer
+	printVersionCheckWarning(&out, &webclient.PingResponse{MinClientVersion: "1.0.0"})
+	require.Empty(t, out.String())
+
+	// An outdated client is pointed at the server's own version.
+	out.Reset()
+	printVersionCheckWarning(&out, &webclient.PingResponse{
+		MinClientVersion: "99.0.0",
+		ServerVersion:    "99.1.2",
+	})
+	require.Contains(t, out.String(), "Minimum client version supported by the server is 99.0.0")
+	require.Contains(t, out.String(), "upgrade tsh to 99.1.2")
+	require.Contains(t, out.String(), downloadURLForVersion("99.1.2"))
+
+	// Without a server version the minimum supported version is suggested.
+	out.Reset()
+	printVersionCheckWarning(&out, &webclient.PingResponse{MinClientVersion: "99.0.0"})
+	require.Contains(t, out.String(), "upgrade tsh to 99.0.0")
+}
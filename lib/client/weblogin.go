@@ -19,6 +19,7 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
@@ -170,6 +171,10 @@ type SSHLogin struct {
 	Insecure bool
 	// Pool is x509 cert pool to use for server certifcate verification
 	Pool *x509.CertPool
+	// VerifyConnection is an optional callback invoked by the TLS stack on
+	// every handshake, even when Insecure is set. It lets the client enforce
+	// its own verification, such as TOFU certificate pinning.
+	VerifyConnection func(tls.ConnectionState) error
 	// Compatibility sets compatibility mode for SSH certificates
 	Compatibility string
 	// RouteToCluster is an optional cluster name to route the response
@@ -225,7 +230,7 @@ type SSHLoginMFA struct {
 }
 
 // initClient creates a new client to the HTTPS web proxy.
-func initClient(proxyAddr string, insecure bool, pool *x509.CertPool) (*WebClient, *url.URL, error) {
+func initClient(proxyAddr string, insecure bool, pool *x509.CertPool, verifyConnection func(tls.ConnectionState) error) (*WebClient, *url.URL, error) {
 	log := logrus.WithFields(logrus.Fields{
 		trace.Component: teleport.ComponentClient,
 	})
@@ -247,11 +252,17 @@ func initClient(proxyAddr string, insecure bool, pool *x509.CertPool) (*WebClien
 
 	var opts []roundtrip.ClientParam
 
-	if insecure {
+	switch {
+	case verifyConnection != nil:
+		// The standard chain check is skipped, but the caller's verification
+		// (such as TOFU certificate pinning) runs on every handshake, so the
+		// connection is not insecure and no warning is printed.
+		opts = append(opts, roundtrip.HTTPClient(newClientWithVerify(verifyConnection)))
+	case insecure:
 		// Skip https cert verification, print a warning that this is insecure.
 		fmt.Fprintf(os.Stderr, "WARNING: You are using insecure connection to SSH proxy %v\n", proxyAddr)
 		opts = append(opts, roundtrip.HTTPClient(NewInsecureWebClient()))
-	} else if pool != nil {
+	case pool != nil:
 		// use custom set of trusted CAs
 		opts = append(opts, roundtrip.HTTPClient(newClientWithPool(pool)))
 	}
@@ -335,7 +346,7 @@ func SSHAgentSSOLogin(ctx context.Context, login SSHLoginSSO, config *Redirector
 
 // SSHAgentLogin is used by tsh to fetch local user credentials.
 func SSHAgentLogin(ctx context.Context, login SSHLoginDirect) (*auth.SSHLoginResponse, error) {
-	clt, _, err := initClient(login.ProxyAddr, login.Insecure, login.Pool)
+	clt, _, err := initClient(login.ProxyAddr, login.Insecure, login.Pool, login.VerifyConnection)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -368,7 +379,7 @@ func SSHAgentLogin(ctx context.Context, login SSHLoginDirect) (*auth.SSHLoginRes
 // prompt the user to provide 2nd factor and pass the response to the proxy.
 // If the authentication succeeds, we will get a temporary certificate back.
 func SSHAgentMFALogin(ctx context.Context, login SSHLoginMFA) (*auth.SSHLoginResponse, error) {
-	clt, _, err := initClient(login.ProxyAddr, login.Insecure, login.Pool)
+	clt, _, err := initClient(login.ProxyAddr, login.Insecure, login.Pool, login.VerifyConnection)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -434,7 +445,7 @@ func SSHAgentMFALogin(ctx context.Context, login SSHLoginMFA) (*auth.SSHLoginRes
 
 // HostCredentials is used to fetch host credentials for a node.
 func HostCredentials(ctx context.Context, proxyAddr string, insecure bool, req types.RegisterUsingTokenRequest) (*proto.Certs, error) {
-	clt, _, err := initClient(proxyAddr, insecure, nil)
+	clt, _, err := initClient(proxyAddr, insecure, nil, nil)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -453,8 +464,8 @@ func HostCredentials(ctx context.Context, proxyAddr string, insecure bool, req t
 }
 
 // GetWebConfig is used by teleterm to fetch webconfig.js from proxies
-func GetWebConfig(ctx context.Context, proxyAddr string, insecure bool) (*webclient.WebConfig, error) {
-	clt, _, err := initClient(proxyAddr, insecure, nil)
+func GetWebConfig(ctx context.Context, proxyAddr string, insecure bool, verifyConnection func(tls.ConnectionState) error) (*webclient.WebConfig, error) {
+	clt, _, err := initClient(proxyAddr, insecure, nil, verifyConnection)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
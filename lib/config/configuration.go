@@ -1248,6 +1248,12 @@ func applyAppsConfig(fc *FileConfig, cfg *service.Config) error {
 				Headers:  headers,
 			}
 		}
+		if application.MTLS != nil {
+			app.MTLS = &service.AppMTLS{
+				ClientCertFile: application.MTLS.ClientCertFile,
+				ClientKeyFile:  application.MTLS.ClientKeyFile,
+			}
+		}
 		if err := app.CheckAndSetDefaults(); err != nil {
 			return trace.Wrap(err)
 		}
@@ -1652,6 +1652,35 @@ app_service:
 			inComment: "config is missing internal address",
 			outError:  true,
 		},
+		{
+			inConfigString: `
+app_service:
+  enabled: true
+  apps:
+    -
+      name: foo
+      uri: "https://127.0.0.1:8080"
+      mtls:
+        client_cert_file: /etc/teleport/app.crt
+        client_key_file: /etc/teleport/app.key
+`,
+			inComment: "mtls config is valid",
+			outError:  false,
+		},
+		{
+			inConfigString: `
+app_service:
+  enabled: true
+  apps:
+    -
+      name: foo
+      uri: "https://127.0.0.1:8080"
+      mtls:
+        client_cert_file: /etc/teleport/app.crt
+`,
+			inComment: "mtls config is missing the client key",
+			outError:  true,
+		},
 	}
 
 	for _, tt := range tests {
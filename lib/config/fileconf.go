@@ -1304,6 +1304,19 @@ type App struct {
 
 	// Rewrite defines a block that is used to rewrite requests and responses.
 	Rewrite *Rewrite `yaml:"rewrite,omitempty"`
+
+	// MTLS configures a client certificate to present to the application,
+	// for applications that require mutual TLS.
+	MTLS *AppMTLS `yaml:"mtls,omitempty"`
+}
+
+// AppMTLS is a client certificate configuration for establishing mutual
+// TLS to the proxied application.
+type AppMTLS struct {
+	// ClientCertFile is a path to a PEM encoded client certificate.
+	ClientCertFile string `yaml:"client_cert_file"`
+	// ClientKeyFile is a path to a PEM encoded private key.
+	ClientKeyFile string `yaml:"client_key_file"`
 }
 
 // Rewrite is a list of rewriting rules to apply to requests and responses.
@@ -60,6 +60,23 @@ type Values struct {
 
 	// TLSServerName is SNI host value passed to the server.
 	TLSServerName string
+
+	// Impersonate allows to define the default impersonated user.
+	// Must be a subset of kubernetes_users or the Teleport username
+	// otherwise Teleport rejects the request.
+	Impersonate string
+	// ImpersonateGroups allows to define the default impersonated groups.
+	// Must be a subset of kubernetes_groups otherwise Teleport rejects the
+	// request.
+	ImpersonateGroups []string
+	// Namespace is the default Kubernetes namespace set on generated
+	// contexts.
+	Namespace string
+	// ContextNameTemplate optionally overrides how generated contexts are
+	// named. The "{{cluster}}" and "{{kube}}" placeholders are replaced
+	// with the Teleport and kubernetes cluster names. When empty, the
+	// default "<cluster>-<kube>" naming is used.
+	ContextNameTemplate string
 }
 
 // ExecValues contain values for configuring tsh as an exec auth plugin in
@@ -90,6 +107,33 @@ func Update(path string, v Values) error {
 		return trace.Wrap(err)
 	}
 
+	if err := configure(config, v); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return Save(path, *config)
+}
+
+// Create generates a kubeconfig from the given values alone, without merging
+// the entries into an existing kubeconfig file. The result is fully
+// self-contained and can be written to any destination, including stdout.
+func Create(v Values) (*clientcmdapi.Config, error) {
+	config := clientcmdapi.NewConfig()
+	if err := configure(config, v); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return config, nil
+}
+
+// Marshal serializes the given kubeconfig into its YAML representation.
+func Marshal(config *clientcmdapi.Config) ([]byte, error) {
+	out, err := clientcmd.Write(*config)
+	return out, trace.Wrap(err)
+}
+
+// configure applies the given values to kubeconfig entries ("cluster",
+// "user" and "context" sections) of the provided config.
+func configure(config *clientcmdapi.Config, v Values) error {
 	clusterCAs, err := v.Credentials.RootClusterCAs()
 	if err != nil {
 		return trace.Wrap(err)
@@ -116,7 +160,7 @@ func Update(path string, v Values) error {
 		}
 
 		for _, c := range v.Exec.KubeClusters {
-			contextName := ContextName(v.TeleportClusterName, c)
+			contextName := ContextNameFromTemplate(v.ContextNameTemplate, v.TeleportClusterName, c)
 			authName := contextName
 			execArgs := []string{"kube", "credentials",
 				fmt.Sprintf("--kube-cluster=%s", c),
@@ -126,6 +170,8 @@ func Update(path string, v Values) error {
 				execArgs = append(execArgs, fmt.Sprintf("--proxy=%s", v.ProxyAddr))
 			}
 			authInfo := &clientcmdapi.AuthInfo{
+				Impersonate:       v.Impersonate,
+				ImpersonateGroups: v.ImpersonateGroups,
 				Exec: &clientcmdapi.ExecConfig{
 					APIVersion: "client.authentication.k8s.io/v1beta1",
 					Command:    v.Exec.TshBinaryPath,
@@ -140,10 +186,10 @@ func Update(path string, v Values) error {
 			}
 			config.AuthInfos[authName] = authInfo
 
-			setContext(config.Contexts, contextName, clusterName, authName)
+			setContext(config.Contexts, contextName, clusterName, authName, v.Namespace)
 		}
 		if v.Exec.SelectCluster != "" {
-			contextName := ContextName(v.TeleportClusterName, v.Exec.SelectCluster)
+			contextName := ContextNameFromTemplate(v.ContextNameTemplate, v.TeleportClusterName, v.Exec.SelectCluster)
 			if _, ok := config.Contexts[contextName]; !ok {
 				return trace.BadParameter("can't switch kubeconfig context to cluster %q, run 'tsh kube ls' to see available clusters", v.Exec.SelectCluster)
 			}
@@ -164,23 +210,30 @@ func Update(path string, v Values) error {
 		config.AuthInfos[v.TeleportClusterName] = &clientcmdapi.AuthInfo{
 			ClientCertificateData: v.Credentials.TLSCert,
 			ClientKeyData:         v.Credentials.Priv,
+			Impersonate:           v.Impersonate,
+			ImpersonateGroups:     v.ImpersonateGroups,
 		}
 
-		setContext(config.Contexts, v.TeleportClusterName, v.TeleportClusterName, v.TeleportClusterName)
+		setContext(config.Contexts, v.TeleportClusterName, v.TeleportClusterName, v.TeleportClusterName, v.Namespace)
 		config.CurrentContext = v.TeleportClusterName
 	}
 
-	return Save(path, *config)
+	return nil
 }
 
-func setContext(contexts map[string]*clientcmdapi.Context, name, cluster, auth string) {
+func setContext(contexts map[string]*clientcmdapi.Context, name, cluster, auth, namespace string) {
 	lastContext := contexts[name]
 	newContext := &clientcmdapi.Context{
-		Cluster:  cluster,
-		AuthInfo: auth,
+		Cluster:   cluster,
+		AuthInfo:  auth,
+		Namespace: namespace,
 	}
 	if lastContext != nil {
-		newContext.Namespace = lastContext.Namespace
+		// Explicitly requested namespaces win over the previously
+		// configured one.
+		if namespace == "" {
+			newContext.Namespace = lastContext.Namespace
+		}
 		newContext.Extensions = lastContext.Extensions
 	}
 	contexts[name] = newContext
@@ -290,6 +343,20 @@ func ContextName(teleportCluster, kubeCluster string) string {
 	return fmt.Sprintf("%s-%s", teleportCluster, kubeCluster)
 }
 
+// ContextNameFromTemplate renders a kubeconfig context name from the given
+// template, substituting the "{{cluster}}" and "{{kube}}" placeholders with
+// the Teleport and kubernetes cluster names. An empty template falls back to
+// the default naming.
+func ContextNameFromTemplate(template, teleportCluster, kubeCluster string) string {
+	if template == "" {
+		return ContextName(teleportCluster, kubeCluster)
+	}
+	return strings.NewReplacer(
+		"{{cluster}}", teleportCluster,
+		"{{kube}}", kubeCluster,
+	).Replace(template)
+}
+
 // KubeClusterFromContext extracts the kubernetes cluster name from context
 // name generated by this package.
 func KubeClusterFromContext(contextName, teleportCluster string) string {
@@ -304,12 +371,19 @@ func KubeClusterFromContext(contextName, teleportCluster string) string {
 // SelectContext switches the active kubeconfig context to point to the
 // provided kubeCluster in teleportCluster.
 func SelectContext(teleportCluster, kubeCluster string) error {
+	return SelectContextFromTemplate("", teleportCluster, kubeCluster)
+}
+
+// SelectContextFromTemplate switches the active kubeconfig context to point
+// to the provided kubeCluster in teleportCluster, naming the context using
+// the given template (see ContextNameFromTemplate).
+func SelectContextFromTemplate(template, teleportCluster, kubeCluster string) error {
 	kc, err := Load("")
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	kubeContext := ContextName(teleportCluster, kubeCluster)
+	kubeContext := ContextNameFromTemplate(template, teleportCluster, kubeCluster)
 	if _, ok := kc.Contexts[kubeContext]; !ok {
 		return trace.NotFound("kubeconfig context %q not found", kubeContext)
 	}
@@ -208,6 +208,34 @@ func TestUpdate(t *testing.T) {
 	require.Equal(t, wantConfig, config)
 }
 
+func TestCreate(t *testing.T) {
+	const (
+		clusterName = "teleport-cluster"
+		clusterAddr = "https://1.2.3.6:3080"
+	)
+	creds, caCertPEM, err := genUserKey()
+	require.NoError(t, err)
+	config, err := Create(Values{
+		TeleportClusterName: clusterName,
+		ClusterAddr:         clusterAddr,
+		Credentials:         creds,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, clusterName, config.CurrentContext)
+	require.Equal(t, clusterAddr, config.Clusters[clusterName].Server)
+	require.Equal(t, caCertPEM, config.Clusters[clusterName].CertificateAuthorityData)
+	require.Equal(t, creds.TLSCert, config.AuthInfos[clusterName].ClientCertificateData)
+	require.Equal(t, creds.Priv, config.AuthInfos[clusterName].ClientKeyData)
+
+	// The marshaled config must load back without touching the filesystem.
+	out, err := Marshal(config)
+	require.NoError(t, err)
+	parsed, err := clientcmd.Load(out)
+	require.NoError(t, err)
+	require.Equal(t, clusterName, parsed.CurrentContext)
+}
+
 func TestUpdateWithExec(t *testing.T) {
 	const (
 		clusterName = "teleport-cluster"
@@ -267,6 +295,40 @@ func TestUpdateWithExec(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, wantConfig, config)
 }
+
+func TestUpdateWithImpersonation(t *testing.T) {
+	const (
+		clusterName      = "teleport-cluster"
+		clusterAddr      = "https://1.2.3.6:3080"
+		tshPath          = "/path/to/tsh"
+		kubeCluster      = "my-cluster"
+		impersonateUser  = "alice"
+		impersonateGroup = "system:masters"
+	)
+	kubeconfigPath, _ := setup(t)
+	creds, _, err := genUserKey()
+	require.NoError(t, err)
+	err = Update(kubeconfigPath, Values{
+		TeleportClusterName: clusterName,
+		ClusterAddr:         clusterAddr,
+		Credentials:         creds,
+		Impersonate:         impersonateUser,
+		ImpersonateGroups:   []string{impersonateGroup},
+		Exec: &ExecValues{
+			TshBinaryPath: tshPath,
+			KubeClusters:  []string{kubeCluster},
+		},
+	})
+	require.NoError(t, err)
+
+	config, err := Load(kubeconfigPath)
+	require.NoError(t, err)
+	authInfo := config.AuthInfos[ContextName(clusterName, kubeCluster)]
+	require.NotNil(t, authInfo)
+	require.Equal(t, impersonateUser, authInfo.Impersonate)
+	require.Equal(t, []string{impersonateGroup}, authInfo.ImpersonateGroups)
+}
+
 func TestUpdateWithExecAndProxy(t *testing.T) {
 	const (
 		clusterName = "teleport-cluster"
@@ -882,6 +882,19 @@ type App struct {
 
 	// Rewrite defines a block that is used to rewrite requests and responses.
 	Rewrite *Rewrite
+
+	// MTLS configures a client certificate to present to the application,
+	// for applications that require mutual TLS.
+	MTLS *AppMTLS
+}
+
+// AppMTLS is a client certificate configuration for establishing mutual
+// TLS to the proxied application.
+type AppMTLS struct {
+	// ClientCertFile is a path to a PEM encoded client certificate.
+	ClientCertFile string
+	// ClientKeyFile is a path to a PEM encoded private key.
+	ClientKeyFile string
 }
 
 // CheckAndSetDefaults validates an application.
@@ -928,6 +941,10 @@ func (a *App) CheckAndSetDefaults() error {
 			}
 		}
 	}
+	// A client certificate and key always come as a pair.
+	if a.MTLS != nil && (a.MTLS.ClientCertFile == "" || a.MTLS.ClientKeyFile == "") {
+		return trace.BadParameter("application %q mtls configuration requires both client_cert_file and client_key_file", a.Name)
+	}
 	return nil
 }
 
@@ -2971,6 +2971,7 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 				StaticFS:         fs,
 				ClusterFeatures:  process.getClusterFeatures(),
 				ProxySettings:    proxySettings,
+				LockWatcher:      lockWatcher,
 			})
 		if err != nil {
 			return trace.Wrap(err)
@@ -3650,6 +3651,7 @@ func (process *TeleportProcess) initApps() {
 
 		// Loop over each application and create a server.
 		var applications types.Apps
+		clientCerts := make(map[string]tls.Certificate)
 		for _, app := range process.Config.Apps.Apps {
 			publicAddr, err := getPublicAddr(accessPoint, app)
 			if err != nil {
@@ -3685,6 +3687,16 @@ func (process *TeleportProcess) initApps() {
 				return trace.Wrap(err)
 			}
 
+			// Load the client certificate used to establish mutual TLS to
+			// the application, if one is configured.
+			if app.MTLS != nil {
+				cert, err := tls.LoadX509KeyPair(app.MTLS.ClientCertFile, app.MTLS.ClientKeyFile)
+				if err != nil {
+					return trace.Wrap(err, "failed to load mtls client certificate for application %q", app.Name)
+				}
+				clientCerts[app.Name] = cert
+			}
+
 			applications = append(applications, a)
 		}
 
@@ -3720,6 +3732,7 @@ func (process *TeleportProcess) initApps() {
 			Hostname:         process.Config.Hostname,
 			GetRotation:      process.getRotation,
 			Apps:             applications,
+			ClientCerts:      clientCerts,
 			ResourceMatchers: process.Config.Apps.ResourceMatchers,
 			OnHeartbeat:      process.onHeartbeat(teleport.ComponentApp),
 		})
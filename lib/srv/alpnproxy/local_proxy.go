@@ -278,17 +278,22 @@ func (l *LocalProxy) handleDownstreamConnection(ctx context.Context, downstreamC
 	}
 	defer upstreamConn.Close()
 
+	// When one direction finishes, half-close the peer instead of tearing
+	// down both connections. Protocols like Postgres rely on half-close
+	// semantics: a CancelRequest client sends its packet and shuts down the
+	// write side while the backend still processes it, and COPY bulk loads
+	// end with the server responding after the client is done sending.
+	// Closing both connections on the first EOF would drop that in-flight
+	// data.
 	errC := make(chan error, 2)
 	go func() {
-		defer downstreamConn.Close()
-		defer upstreamConn.Close()
 		_, err := io.Copy(downstreamConn, upstreamConn)
+		closeConnWrite(downstreamConn)
 		errC <- err
 	}()
 	go func() {
-		defer downstreamConn.Close()
-		defer upstreamConn.Close()
 		_, err := io.Copy(upstreamConn, downstreamConn)
+		closeConnWrite(upstreamConn)
 		errC <- err
 	}()
 
@@ -306,6 +311,20 @@ func (l *LocalProxy) handleDownstreamConnection(ctx context.Context, downstreamC
 	return trace.NewAggregate(errs...)
 }
 
+// closeConnWrite half-closes the connection, signalling EOF to the peer
+// while leaving the read side open. Connections that do not support
+// half-close (neither *net.TCPConn nor *tls.Conn) are closed completely.
+func closeConnWrite(conn net.Conn) {
+	cw, ok := conn.(interface{ CloseWrite() error })
+	if !ok {
+		conn.Close()
+		return
+	}
+	if err := cw.CloseWrite(); err != nil && !utils.IsOKNetworkError(err) {
+		log.WithError(err).Debug("Failed to close connection for writing.")
+	}
+}
+
 func (l *LocalProxy) Close() error {
 	l.cancel()
 	if l.cfg.Listener != nil {
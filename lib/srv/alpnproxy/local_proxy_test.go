@@ -19,6 +19,8 @@ package alpnproxy
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -97,6 +99,56 @@ func TestHandleAWSAccessSigVerification(t *testing.T) {
 	}
 }
 
+// TestLocalProxyHalfClose verifies that the local proxy relays a client
+// half-close to the remote server and still delivers the server's response.
+// Postgres depends on these semantics: a CancelRequest client stops sending
+// after a single packet and COPY bulk loads end with the server responding
+// after the client is done writing.
+func TestLocalProxyHalfClose(t *testing.T) {
+	const request = "cancel request"
+	const response = "server response"
+
+	ca := mustGenSelfSignedCert(t)
+	serverListener := tls.NewListener(mustCreateListener(t), &tls.Config{
+		Certificates: []tls.Certificate{mustGenCertSignedWithCA(t, ca)},
+		NextProtos:   []string{string(common.ProtocolPostgres)},
+	})
+	go func() {
+		conn, err := serverListener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Read until the client half-closes, then respond.
+		data, err := io.ReadAll(conn)
+		if err != nil || string(data) != request {
+			return
+		}
+		conn.Write([]byte(response))
+	}()
+
+	listener := mustCreateListener(t)
+	mustStartLocalProxy(t, LocalProxyConfig{
+		Listener:           listener,
+		RemoteProxyAddr:    serverListener.Addr().String(),
+		Protocols:          []common.Protocol{common.ProtocolPostgres},
+		ParentContext:      context.Background(),
+		InsecureSkipVerify: true,
+	})
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(request))
+	require.NoError(t, err)
+	require.NoError(t, conn.(*net.TCPConn).CloseWrite())
+
+	data, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, response, string(data))
+}
+
 func createAWSAccessProxySuite(t *testing.T, cred *credentials.Credentials) *LocalProxy {
 	hs := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
 	listener := mustCreateListener(t)
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport"
+
+	"github.com/gravitational/oxy/forward"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	appRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: teleport.MetricAppRequests,
+			Help: "Number of requests proxied to applications",
+		},
+		[]string{teleport.TagApp, teleport.TagStatusCode},
+	)
+	appRequestLatencies = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: teleport.MetricAppRequestsHistogram,
+			Help: "Latency for requests proxied to applications",
+			// lowest bucket start of upper bound 0.001 sec (1 ms) with factor 2
+			// highest bucket start of 0.001 sec * 2^15 == 32.768 sec
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+		},
+		[]string{teleport.TagApp},
+	)
+	appActiveTunnels = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: teleport.MetricAppActiveTunnels,
+			Help: "Number of active websocket tunnels to applications",
+		},
+		[]string{teleport.TagApp},
+	)
+
+	prometheusCollectors = []prometheus.Collector{
+		appRequests, appRequestLatencies, appActiveTunnels,
+	}
+)
+
+// observeRequest records metrics for a single proxied request.
+func observeRequest(app string, statusCode int, started time.Time) {
+	appRequests.WithLabelValues(app, strconv.Itoa(statusCode)).Inc()
+	appRequestLatencies.WithLabelValues(app).Observe(time.Since(started).Seconds())
+}
+
+// trackTunnels wraps a websocket dialer so connections it establishes are
+// reflected in the active tunnel gauge of the given application.
+func trackTunnels(app string, dialer forward.Dialer) forward.Dialer {
+	return func(network, address string) (net.Conn, error) {
+		conn, err := dialer(network, address)
+		if err != nil {
+			return nil, err
+		}
+		appActiveTunnels.WithLabelValues(app).Inc()
+		return &trackedConn{Conn: conn, app: app}, nil
+	}
+}
+
+// trackedConn decrements the active tunnel gauge of an application when the
+// underlying connection is closed.
+type trackedConn struct {
+	net.Conn
+	app  string
+	once sync.Once
+}
+
+// Close closes the underlying connection. It is safe to call multiple times,
+// the gauge is only decremented once.
+func (c *trackedConn) Close() error {
+	c.once.Do(func() {
+		appActiveTunnels.WithLabelValues(c.app).Dec()
+	})
+	return c.Conn.Close()
+}
@@ -87,6 +87,10 @@ type Config struct {
 	// Apps is a list of statically registered apps this agent proxies.
 	Apps types.Apps
 
+	// ClientCerts maps an application name to a client certificate this
+	// agent presents when establishing mutual TLS to the application.
+	ClientCerts map[string]tls.Certificate
+
 	// OnHeartbeat is called after every heartbeat. Used to update process state.
 	OnHeartbeat func(error)
 
@@ -216,6 +220,10 @@ func New(ctx context.Context, c *Config) (*Server, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	if err := utils.RegisterPrometheusCollectors(prometheusCollectors...); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	awsSigner, err := appaws.NewSigningService(appaws.SigningServiceConfig{})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -18,6 +18,7 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"path/filepath"
 	"sync"
 	"time"
@@ -102,6 +103,13 @@ func (s *Server) newSessionChunk(ctx context.Context, identity *tlsca.Identity,
 	}
 	traits[teleport.TraitJWT] = []string{jwt}
 
+	// If a client certificate is configured for this application, present
+	// it to establish mutual TLS.
+	var clientCerts []tls.Certificate
+	if cert, ok := s.c.ClientCerts[app.GetName()]; ok {
+		clientCerts = append(clientCerts, cert)
+	}
+
 	// Create a rewriting transport that will be used to forward requests.
 	transport, err := newTransport(s.closeContext,
 		&transportConfig{
@@ -113,6 +121,7 @@ func (s *Server) newSessionChunk(ctx context.Context, identity *tlsca.Identity,
 			traits:       traits,
 			log:          s.log,
 			user:         identity.Username,
+			clientCerts:  clientCerts,
 		})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"time"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/types"
@@ -51,6 +52,7 @@ type transportConfig struct {
 	traits       wrappers.Traits
 	log          logrus.FieldLogger
 	user         string
+	clientCerts  []tls.Certificate
 }
 
 // Check validates configuration.
@@ -115,7 +117,7 @@ func newTransport(ctx context.Context, c *transportConfig) (*transport, error) {
 		c:            c,
 		uri:          uri,
 		tr:           tr,
-		ws:           newWebsocketTransport(uri, tr.TLSClientConfig),
+		ws:           newWebsocketTransport(uri, tr.TLSClientConfig, c.app.GetName()),
 	}, nil
 }
 
@@ -148,10 +150,12 @@ func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
 	}
 
 	// Forward the request to the target application and emit an audit event.
+	start := time.Now()
 	resp, err := t.tr.RoundTrip(r)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	observeRequest(t.c.app.GetName(), resp.StatusCode, start)
 
 	// Emit the event to the audit log.
 	if err := t.emitAuditEvent(r, resp); err != nil {
@@ -305,6 +309,9 @@ func configureTLS(c *transportConfig) (*tls.Config, error) {
 	// the application config.
 	tlsConfig.InsecureSkipVerify = (lib.IsInsecureDevMode() || c.app.GetInsecureSkipVerify())
 
+	// Present a client certificate to applications that require mutual TLS.
+	tlsConfig.Certificates = c.clientCerts
+
 	return tlsConfig, nil
 }
 
@@ -334,18 +341,19 @@ type websocketTransport struct {
 }
 
 // newWebsocketTransport returns transport that knows how to rewrite and
-// dial websocket requests.
-func newWebsocketTransport(uri *url.URL, tlsConfig *tls.Config) *websocketTransport {
+// dial websocket requests. Established tunnels are tracked in the active
+// tunnel gauge of the given application.
+func newWebsocketTransport(uri *url.URL, tlsConfig *tls.Config, app string) *websocketTransport {
 	return &websocketTransport{
 		uri: uri,
-		dialer: func(network, address string) (net.Conn, error) {
+		dialer: trackTunnels(app, func(network, address string) (net.Conn, error) {
 			// Request is going to "wss://".
 			if uri.Scheme == "https" {
 				return tls.Dial(network, address, tlsConfig)
 			}
 			// Request is going to "ws://".
 			return net.Dial(network, address)
-		},
+		}),
 	}
 }
 
@@ -0,0 +1,52 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scp
+
+import "sync"
+
+// Manifest tracks files that have been fully transferred so a retried
+// recursive copy can skip them instead of re-sending everything. It is
+// only consulted on the source side of a transfer and is safe for
+// concurrent use.
+type Manifest struct {
+	mu   sync.Mutex
+	done map[string]struct{}
+}
+
+// NewManifest returns an empty transfer manifest.
+func NewManifest() *Manifest {
+	return &Manifest{
+		done: make(map[string]struct{}),
+	}
+}
+
+// IsComplete returns true if the file at the given path has already been
+// transferred.
+func (m *Manifest) IsComplete(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.done[path]
+	return ok
+}
+
+// MarkComplete records that the file at the given path has been fully
+// transferred and acknowledged by the remote side.
+func (m *Manifest) MarkComplete(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.done[path] = struct{}{}
+}
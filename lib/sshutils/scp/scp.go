@@ -70,6 +70,14 @@ type Flags struct {
 	// PreserveAttrs preserves access and modification times
 	// from the original file
 	PreserveAttrs bool
+	// Sparse detects runs of zero bytes and transfers them as holes instead
+	// of data, preserving sparse regions of the original file. Requires a
+	// Teleport node on the remote side.
+	Sparse bool
+	// Update skips destination files whose checksum already matches the
+	// source. The comparison happens on the client before the transfer
+	// starts; matching files are pre-recorded in the transfer manifest.
+	Update bool
 }
 
 // Config describes Command configuration settings
@@ -90,6 +98,9 @@ type Config struct {
 	// RunOnServer is low level API flag that indicates that
 	// this command will be run on the server
 	RunOnServer bool
+	// Manifest optionally tracks completed file transfers so a retried
+	// recursive copy skips files that were already sent.
+	Manifest *Manifest
 	// Log optionally specifies the logger
 	Log log.FieldLogger
 }
@@ -249,6 +260,11 @@ func (cmd *command) GetRemoteShellCmd() (shellCmd string, err error) {
 	if cmd.Flags.PreserveAttrs {
 		shellCmd += " -p"
 	}
+	if cmd.Flags.Sparse {
+		// Not an OpenSSH flag: sparse mode changes the data stream and is
+		// only understood by the Teleport scp implementation.
+		shellCmd += " --sparse"
+	}
 	shellCmd += (" " + cmd.RemoteLocation)
 
 	return shellCmd, nil
@@ -291,7 +307,7 @@ func (cmd *command) serveSource(ch io.ReadWriter) (retErr error) {
 			if err := cmd.sendDir(r, ch, info); err != nil {
 				return trace.Wrap(err)
 			}
-		} else {
+		} else if !cmd.transferComplete(info) {
 			if err := cmd.sendFile(r, ch, info); err != nil {
 				return trace.Wrap(err)
 			}
@@ -302,6 +318,12 @@ func (cmd *command) serveSource(ch io.ReadWriter) (retErr error) {
 	return nil
 }
 
+// transferComplete returns true if the file was already sent during a
+// previous attempt recorded in the transfer manifest.
+func (cmd *command) transferComplete(fileInfo FileInfo) bool {
+	return cmd.Manifest != nil && cmd.Manifest.IsComplete(fileInfo.GetPath())
+}
+
 func (cmd *command) sendDir(r *reader, ch io.ReadWriter, fileInfo FileInfo) error {
 	if cmd.Config.Flags.PreserveAttrs {
 		if err := cmd.sendFileTimes(r, ch, fileInfo); err != nil {
@@ -326,7 +348,7 @@ func (cmd *command) sendDir(r *reader, ch io.ReadWriter, fileInfo FileInfo) erro
 			if err != nil {
 				return trace.Wrap(err)
 			}
-		} else {
+		} else if !cmd.transferComplete(info) {
 			err := cmd.sendFile(r, ch, info)
 			if err != nil {
 				return trace.Wrap(err)
@@ -356,7 +378,12 @@ func (cmd *command) sendFile(r *reader, ch io.ReadWriter, fileInfo FileInfo) err
 		return trace.Wrap(err)
 	}
 
-	n, err := io.Copy(ch, reader)
+	var n int64
+	if cmd.Config.Flags.Sparse {
+		n, err = sendSparseData(ch, reader)
+	} else {
+		n, err = io.Copy(ch, reader)
+	}
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -372,7 +399,14 @@ func (cmd *command) sendFile(r *reader, ch io.ReadWriter, fileInfo FileInfo) err
 	if err := sendOK(ch); err != nil {
 		return trace.Wrap(err)
 	}
-	return trace.Wrap(r.read())
+	if err := r.read(); err != nil {
+		return trace.Wrap(err)
+	}
+	// Only record the file once the remote side acknowledged it.
+	if cmd.Manifest != nil {
+		cmd.Manifest.MarkComplete(fileInfo.GetPath())
+	}
+	return nil
 }
 
 func (cmd *command) sendErr(ch io.Writer, err error) {
@@ -508,7 +542,12 @@ func (cmd *command) receiveFile(st *state, fc newFileCmd, ch io.ReadWriter) erro
 		return trace.Wrap(err)
 	}
 
-	n, err := io.CopyN(writer, ch, int64(fc.Length))
+	var n int64
+	if cmd.Config.Flags.Sparse {
+		n, err = receiveSparseData(writer, ch)
+	} else {
+		n, err = io.CopyN(writer, ch, int64(fc.Length))
+	}
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -163,6 +163,38 @@ func TestSend(t *testing.T) {
 	}
 }
 
+// TestSendSkipsFilesInManifest verifies that files recorded as complete in
+// the transfer manifest are not sent again on a retried recursive copy.
+func TestSendSkipsFilesInManifest(t *testing.T) {
+	t.Parallel()
+	logger := logrus.WithField(trace.Component, "t:send")
+
+	config := newSourceConfig("dir", Flags{Recursive: true})
+	config.FileSystem = newTestFS(
+		logger,
+		newDir("dir",
+			newFile("dir/file1", "file1 contents"),
+			newFile("dir/file2", "file2 contents"),
+		),
+	)
+	config.Manifest = NewManifest()
+	config.Manifest.MarkComplete("dir/file1")
+
+	cmd, err := CreateCommand(config)
+	require.NoError(t, err)
+
+	targetDir := t.TempDir()
+	target := filepath.Join(targetDir, "dir")
+	require.NoError(t, runSCP(cmd, "-v", "-t", "-r", target))
+
+	// The file marked as complete is skipped, the other one is sent.
+	_, err = os.Stat(filepath.Join(target, "file1"))
+	require.True(t, os.IsNotExist(err))
+	contents, err := os.ReadFile(filepath.Join(target, "file2"))
+	require.NoError(t, err)
+	require.Equal(t, "file2 contents", string(contents))
+}
+
 func TestReceive(t *testing.T) {
 	t.Parallel()
 	modtime := testNow
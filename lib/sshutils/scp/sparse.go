@@ -0,0 +1,167 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scp
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// sparseBlockSize is the granularity at which the sender scans for runs of
+// zero bytes. Blocks that are entirely zero are sent as holes instead of
+// data.
+const sparseBlockSize = 64 * 1024
+
+// sendSparseData transmits the file as a sequence of data segments, skipping
+// blocks that contain only zeros. Each segment is a "<offset> <length>\n"
+// header followed by length raw bytes; the stream is terminated by a
+// "-1 <size>\n" marker carrying the total file size. Both sides must be in
+// sparse mode since this replaces the raw byte stream of the SCP protocol.
+func sendSparseData(ch io.Writer, r io.Reader) (int64, error) {
+	buf := make([]byte, sparseBlockSize)
+	var offset int64
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 && !isZeroBlock(buf[:n]) {
+			if _, err := fmt.Fprintf(ch, "%d %d\n", offset, n); err != nil {
+				return 0, trace.Wrap(err)
+			}
+			if _, err := ch.Write(buf[:n]); err != nil {
+				return 0, trace.Wrap(err)
+			}
+		}
+		offset += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return 0, trace.Wrap(err)
+		}
+	}
+	if _, err := fmt.Fprintf(ch, "-1 %d\n", offset); err != nil {
+		return 0, trace.Wrap(err)
+	}
+	return offset, nil
+}
+
+// receiveSparseData reassembles a sparse stream produced by sendSparseData.
+// When the destination supports seeking, skipped regions become holes in the
+// destination file; otherwise the holes are filled with zeros.
+func receiveSparseData(w io.Writer, ch io.Reader) (int64, error) {
+	seeker, canSeek := w.(io.WriteSeeker)
+	var written int64
+	for {
+		var offset, length int64
+		if err := readSparseHeader(ch, &offset, &length); err != nil {
+			return 0, trace.Wrap(err)
+		}
+		// End of stream: the length field carries the total file size.
+		if offset < 0 {
+			if err := finalizeSparseFile(w, written, length, canSeek); err != nil {
+				return 0, trace.Wrap(err)
+			}
+			return length, nil
+		}
+		if offset < written {
+			return 0, trace.BadParameter("sparse segments out of order: offset %v after %v bytes", offset, written)
+		}
+		if offset > written {
+			if err := advanceTo(w, seeker, canSeek, offset-written); err != nil {
+				return 0, trace.Wrap(err)
+			}
+			written = offset
+		}
+		n, err := io.CopyN(w, ch, length)
+		written += n
+		if err != nil {
+			return 0, trace.Wrap(err)
+		}
+	}
+}
+
+// readSparseHeader reads a single "<offset> <length>\n" segment header. The
+// header is read byte by byte to not consume any of the raw data that
+// follows it.
+func readSparseHeader(ch io.Reader, offset, length *int64) error {
+	var line []byte
+	var b [1]byte
+	for {
+		if _, err := io.ReadFull(ch, b[:]); err != nil {
+			return trace.Wrap(err)
+		}
+		if b[0] == '\n' {
+			break
+		}
+		line = append(line, b[0])
+		if len(line) > 64 {
+			return trace.BadParameter("malformed sparse segment header")
+		}
+	}
+	if _, err := fmt.Sscanf(string(line), "%d %d", offset, length); err != nil {
+		return trace.BadParameter("malformed sparse segment header %q", string(line))
+	}
+	return nil
+}
+
+// advanceTo moves the write position forward by gap bytes, seeking over the
+// gap when possible so it remains a hole on disk and writing zeros otherwise.
+func advanceTo(w io.Writer, seeker io.WriteSeeker, canSeek bool, gap int64) error {
+	if canSeek {
+		_, err := seeker.Seek(gap, io.SeekCurrent)
+		return trace.ConvertSystemError(err)
+	}
+	zeros := make([]byte, sparseBlockSize)
+	for gap > 0 {
+		n := int64(len(zeros))
+		if gap < n {
+			n = gap
+		}
+		if _, err := w.Write(zeros[:n]); err != nil {
+			return trace.Wrap(err)
+		}
+		gap -= n
+	}
+	return nil
+}
+
+// finalizeSparseFile extends the destination to its full size to materialize
+// a trailing hole.
+func finalizeSparseFile(w io.Writer, written, size int64, canSeek bool) error {
+	if written > size {
+		return trace.BadParameter("sparse stream wrote %v bytes past the declared size %v", written-size, size)
+	}
+	if written == size {
+		return nil
+	}
+	if t, ok := w.(interface{ Truncate(int64) error }); ok && canSeek {
+		return trace.ConvertSystemError(t.Truncate(size))
+	}
+	seeker, _ := w.(io.WriteSeeker)
+	return trace.Wrap(advanceTo(w, seeker, canSeek, size-written))
+}
+
+// isZeroBlock returns true if the block contains only zero bytes.
+func isZeroBlock(block []byte) bool {
+	for _, b := range block {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparseRoundTrip(t *testing.T) {
+	// Data block, two blocks of zeros, another data block, trailing hole.
+	data := make([]byte, 5*sparseBlockSize)
+	copy(data, bytes.Repeat([]byte{'a'}, sparseBlockSize))
+	copy(data[3*sparseBlockSize:], bytes.Repeat([]byte{'b'}, sparseBlockSize))
+
+	var stream bytes.Buffer
+	sent, err := sendSparseData(&stream, bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sent)
+	// Only the two data blocks travel over the wire, plus segment headers.
+	require.Less(t, stream.Len(), 2*sparseBlockSize+100)
+
+	// A seekable destination reassembles the file with holes.
+	path := filepath.Join(t.TempDir(), "out")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	received, err := receiveSparseData(f, bytes.NewReader(stream.Bytes()))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Equal(t, int64(len(data)), received)
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, data, out)
+
+	// A non-seekable destination gets the holes as zeros.
+	var plain bytes.Buffer
+	received, err = receiveSparseData(&plain, bytes.NewReader(stream.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), received)
+	require.Equal(t, data, plain.Bytes())
+}
+
+func TestSparsePartialBlock(t *testing.T) {
+	// Files that are not a multiple of the block size keep their tail.
+	data := append(bytes.Repeat([]byte{0}, sparseBlockSize), []byte("tail")...)
+
+	var stream bytes.Buffer
+	sent, err := sendSparseData(&stream, bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), sent)
+
+	var out bytes.Buffer
+	received, err := receiveSparseData(&out, bytes.NewReader(stream.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), received)
+	require.Equal(t, data, out.Bytes())
+}
@@ -56,7 +56,7 @@ func InitLogger(purpose LoggingPurpose, level log.Level, verbose ...bool) {
 	case LoggingForCLI:
 		// If debug logging was asked for on the CLI, then write logs to stderr.
 		// Otherwise, discard all logs.
-		if level == log.DebugLevel {
+		if level >= log.DebugLevel {
 			log.SetFormatter(NewDefaultTextFormatter(trace.IsTerminal(os.Stderr)))
 			log.SetOutput(os.Stderr)
 		} else {
@@ -68,6 +68,45 @@ func InitLogger(purpose LoggingPurpose, level log.Level, verbose ...bool) {
 	}
 }
 
+// FilterLogComponents limits output of the global logger to entries whose
+// component matches one of the given components. A filter matches the
+// component exactly or any of its subcomponents, e.g. "proxy" matches both
+// "proxy" and "proxy:agent". Entries without a component are suppressed.
+func FilterLogComponents(components []string) {
+	filter := make(map[string]bool, len(components))
+	for _, component := range components {
+		filter[strings.TrimSpace(component)] = true
+	}
+	logger := log.StandardLogger()
+	logger.SetFormatter(&componentFilterFormatter{
+		next:       logger.Formatter,
+		components: filter,
+	})
+}
+
+// componentFilterFormatter drops log entries whose component does not match
+// the filter before handing them to the wrapped formatter.
+type componentFilterFormatter struct {
+	next       log.Formatter
+	components map[string]bool
+}
+
+// Format implements logrus.Formatter.
+func (f *componentFilterFormatter) Format(entry *log.Entry) ([]byte, error) {
+	component, ok := entry.Data[trace.Component].(string)
+	if !ok {
+		return nil, nil
+	}
+	for !f.components[component] {
+		idx := strings.LastIndex(component, ":")
+		if idx < 0 {
+			return nil, nil
+		}
+		component = component[:idx]
+	}
+	return f.next.Format(entry)
+}
+
 // InitLoggerForTests initializes the standard logger for tests.
 func InitLoggerForTests() {
 	// Parse flags to check testing.Verbose().
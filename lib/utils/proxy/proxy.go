@@ -95,7 +95,9 @@ func (d directDial) getTLSConfig(addr *utils.NetAddr) (*tls.Config, error) {
 		return nil, trace.BadParameter("TLS config was nil")
 	}
 	tlsConfig := d.tlsConfig.Clone()
-	tlsConfig.ServerName = addr.Host()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = addr.Host()
+	}
 	tlsConfig.InsecureSkipVerify = d.insecure
 	return tlsConfig, nil
 }
@@ -159,7 +161,9 @@ func (d proxyDial) getTLSConfig(addr *utils.NetAddr) (*tls.Config, error) {
 		return nil, trace.BadParameter("TLS config was nil")
 	}
 	tlsConfig := d.tlsConfig.Clone()
-	tlsConfig.ServerName = addr.Host()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = addr.Host()
+	}
 	tlsConfig.InsecureSkipVerify = d.insecure
 	return tlsConfig, nil
 }
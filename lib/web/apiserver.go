@@ -188,6 +188,17 @@ type Config struct {
 
 	// ProxySettings allows fetching the current proxy settings.
 	ProxySettings proxySettingsGetter
+
+	// MaxAppSessionsPerUser caps the number of simultaneous app sessions a
+	// single user may hold through this proxy, zero means no limit.
+	MaxAppSessionsPerUser int
+	// MaxAppStreamsPerUser caps the number of in-flight forwarded app
+	// requests per user, zero means no limit.
+	MaxAppStreamsPerUser int
+
+	// LockWatcher is the lock watcher of the proxy, used to enforce locks
+	// on forwarded app requests.
+	LockWatcher *services.LockWatcher
 }
 
 type APIHandler struct {
@@ -541,12 +552,15 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*APIHandler, error) {
 	// Create application specific handler. This handler handles sessions and
 	// forwarding for application access.
 	appHandler, err := app.NewHandler(cfg.Context, &app.HandlerConfig{
-		Clock:         h.clock,
-		AuthClient:    cfg.ProxyClient,
-		AccessPoint:   cfg.AccessPoint,
-		ProxyClient:   cfg.Proxy,
-		CipherSuites:  cfg.CipherSuites,
-		WebPublicAddr: resp.SSH.PublicAddr,
+		Clock:              h.clock,
+		AuthClient:         cfg.ProxyClient,
+		AccessPoint:        cfg.AccessPoint,
+		ProxyClient:        cfg.Proxy,
+		CipherSuites:       cfg.CipherSuites,
+		WebPublicAddr:      resp.SSH.PublicAddr,
+		MaxSessionsPerUser: cfg.MaxAppSessionsPerUser,
+		MaxStreamsPerUser:  cfg.MaxAppStreamsPerUser,
+		LockWatcher:        cfg.LockWatcher,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
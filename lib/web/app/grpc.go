@@ -0,0 +1,112 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// grpcTimeoutHeader carries the client's deadline on gRPC requests, as
+// defined by the gRPC over HTTP/2 wire protocol.
+const grpcTimeoutHeader = "Grpc-Timeout"
+
+// isGRPCRequest returns true if the request carries a gRPC content type
+// ("application/grpc" optionally followed by a "+<format>" suffix).
+func isGRPCRequest(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "application/grpc") {
+		return false
+	}
+	rest := contentType[len("application/grpc"):]
+	return rest == "" || rest[0] == '+' || rest[0] == ';'
+}
+
+// parseGRPCTimeout parses a grpc-timeout header value: up to 8 ASCII digits
+// followed by a single unit out of "H" (hours), "M" (minutes), "S" (seconds),
+// "m" (milliseconds), "u" (microseconds) or "n" (nanoseconds).
+func parseGRPCTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, trace.BadParameter("invalid grpc-timeout value %q", value)
+	}
+	digits, unit := value[:len(value)-1], value[len(value)-1]
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, trace.BadParameter("invalid grpc-timeout value %q", value)
+	}
+	switch unit {
+	case 'H':
+		return time.Duration(n) * time.Hour, nil
+	case 'M':
+		return time.Duration(n) * time.Minute, nil
+	case 'S':
+		return time.Duration(n) * time.Second, nil
+	case 'm':
+		return time.Duration(n) * time.Millisecond, nil
+	case 'u':
+		return time.Duration(n) * time.Microsecond, nil
+	case 'n':
+		return time.Duration(n) * time.Nanosecond, nil
+	}
+	return 0, trace.BadParameter("invalid grpc-timeout unit %q", string(unit))
+}
+
+// applyGRPCTimeout enforces the grpc-timeout header on the upstream request
+// context so the application server stops working on the call once the
+// client's deadline has passed, instead of relying on the app to honor the
+// header. The returned cancel function, if any, must be called once the
+// response is consumed. Client disconnects already cancel the request
+// context, which aborts the upstream call through the same path.
+func (t *transport) applyGRPCTimeout(r *http.Request) (*http.Request, context.CancelFunc) {
+	value := r.Header.Get(grpcTimeoutHeader)
+	if value == "" {
+		return r, nil
+	}
+	timeout, err := parseGRPCTimeout(value)
+	if err != nil {
+		t.c.log.Warnf("Ignoring invalid grpc-timeout header %q.", value)
+		return r, nil
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	return r.WithContext(ctx), cancel
+}
+
+// cancelOnCloseBody releases the request's deadline context when the
+// response body is closed, so streaming gRPC responses keep the context
+// alive exactly as long as the response is being read.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+// newCancelOnCloseBody wraps the response body so closing it calls cancel.
+func newCancelOnCloseBody(body io.ReadCloser, cancel context.CancelFunc) *cancelOnCloseBody {
+	return &cancelOnCloseBody{ReadCloser: body, cancel: cancel}
+}
+
+// Close cancels the deadline context and closes the underlying body.
+func (b *cancelOnCloseBody) Close() error {
+	b.cancel()
+	return b.ReadCloser.Close()
+}
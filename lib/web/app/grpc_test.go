@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsGRPCRequest(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{contentType: "application/grpc", expected: true},
+		{contentType: "application/grpc+proto", expected: true},
+		{contentType: "application/grpc+json", expected: true},
+		{contentType: "application/grpc-web", expected: false},
+		{contentType: "application/json", expected: false},
+		{contentType: "", expected: false},
+	}
+	for _, tt := range tests {
+		r := &http.Request{Header: http.Header{}}
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		require.Equal(t, tt.expected, isGRPCRequest(r), "content type %q", tt.contentType)
+	}
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{value: "1H", expected: time.Hour},
+		{value: "2M", expected: 2 * time.Minute},
+		{value: "30S", expected: 30 * time.Second},
+		{value: "250m", expected: 250 * time.Millisecond},
+		{value: "100u", expected: 100 * time.Microsecond},
+		{value: "500n", expected: 500 * time.Nanosecond},
+		{value: "99999999S", expected: 99999999 * time.Second},
+		{value: "", wantErr: true},
+		{value: "S", wantErr: true},
+		{value: "10", wantErr: true},
+		{value: "10X", wantErr: true},
+		{value: "999999999S", wantErr: true},
+	}
+	for _, tt := range tests {
+		d, err := parseGRPCTimeout(tt.value)
+		if tt.wantErr {
+			require.Error(t, err, "value %q", tt.value)
+			continue
+		}
+		require.NoError(t, err, "value %q", tt.value)
+		require.Equal(t, tt.expected, d, "value %q", tt.value)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/reversetunnel"
+	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/tlsca"
 	"github.com/gravitational/teleport/lib/utils"
 
@@ -55,6 +56,15 @@ type HandlerConfig struct {
 	CipherSuites []uint16
 	// WebPublicAddr
 	WebPublicAddr string
+	// MaxSessionsPerUser caps the number of simultaneous app sessions a
+	// single user may hold through this handler, zero means no limit.
+	MaxSessionsPerUser int
+	// MaxStreamsPerUser caps the number of in-flight forwarded requests
+	// per user, zero means no limit.
+	MaxStreamsPerUser int
+	// LockWatcher, if set, is used to reject requests from sessions whose
+	// identity is targeted by a lock in force.
+	LockWatcher *services.LockWatcher
 }
 
 // CheckAndSetDefaults validates configuration.
@@ -86,6 +96,8 @@ type Handler struct {
 
 	cache *sessionCache
 
+	limiter *userLimiter
+
 	clusterName string
 
 	log *logrus.Entry
@@ -113,6 +125,8 @@ func NewHandler(ctx context.Context, c *HandlerConfig) (*Handler, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	h.limiter = newUserLimiter(c.MaxSessionsPerUser, c.MaxStreamsPerUser, c.Clock)
+
 	// Get the name of this cluster.
 	cn, err := h.c.AccessPoint.GetClusterName()
 	if err != nil {
@@ -138,6 +152,11 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // handleForward forwards the request to the application service.
 func (h *Handler) handleForward(w http.ResponseWriter, r *http.Request, session *session) error {
+	release, err := h.limiter.acquireStream(session.ws.GetUser())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer release()
 	session.fwd.ServeHTTP(w, r)
 	return nil
 }
@@ -173,17 +192,66 @@ func (h *Handler) authenticate(ctx context.Context, r *http.Request) (*session,
 		return nil, trace.AccessDenied("invalid session")
 	}
 
+	// Deletion of an expired session from the backend may lag behind its
+	// expiry, so check the expiry explicitly on every request.
+	if h.c.Clock.Now().After(ws.Expiry()) {
+		h.expireSession(ws)
+		return nil, trace.AccessDenied("session expired")
+	}
+
 	// Fetch a cached session or create one if this is the first request this
 	// process has seen.
 	session, err := h.getSession(ctx, ws)
 	if err != nil {
 		h.log.Warnf("Failed to get session: %v.", err)
+		// A limit rejection is reported as-is so the caller sees a 429
+		// instead of being treated as unauthenticated.
+		if trace.IsLimitExceeded(err) {
+			return nil, trace.Wrap(err)
+		}
 		return nil, trace.AccessDenied("invalid session")
 	}
 
+	// Re-validate the session identity against locks on every request so a
+	// locked user loses application access immediately instead of when the
+	// session finally expires.
+	if err := h.checkLockInForce(ctx, session); err != nil {
+		h.log.Warnf("Rejecting session %v: %v.", ws.GetName(), err)
+		h.expireSession(ws)
+		return nil, trace.Wrap(err)
+	}
+
 	return session, nil
 }
 
+// expireSession removes a session this process should no longer forward
+// requests for from the local caches. The next request will have to fetch the
+// session from the backend again.
+func (h *Handler) expireSession(ws types.WebSession) {
+	h.cache.remove(ws.GetName())
+	h.limiter.removeSession(ws.GetUser(), ws.GetName())
+}
+
+// checkLockInForce returns an AccessDenied error if a lock targeting the
+// session identity is in force. Lock enforcement requires a lock watcher;
+// handlers configured without one skip the check.
+func (h *Handler) checkLockInForce(ctx context.Context, session *session) error {
+	if h.c.LockWatcher == nil {
+		return nil
+	}
+	authPref, err := h.c.AccessPoint.GetAuthPreference(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roles, err := services.FetchRoles(session.id.Groups, h.c.AccessPoint, session.id.Traits)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	lockingMode := roles.LockingMode(authPref.GetLockingMode())
+	return trace.Wrap(h.c.LockWatcher.CheckLockInForce(lockingMode,
+		services.LockTargetsFromTLSIdentity(*session.id)...))
+}
+
 // renewSession based on the request removes the session from cache (if present)
 // and generates a new one using the `getSession` flow (same as in
 // `authenticate`).
@@ -260,9 +328,16 @@ func (h *Handler) getSession(ctx context.Context, ws types.WebSession) (*session
 		return session, nil
 	}
 
+	// Enforce the per-user session cap before building a forwarder for a
+	// session this process has not seen yet.
+	if err := h.limiter.addSession(ws.GetUser(), ws.GetName(), ws.Expiry()); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	// Create a new session with a forwarder in it.
 	session, err = h.newSession(ctx, ws)
 	if err != nil {
+		h.limiter.removeSession(ws.GetUser(), ws.GetName())
 		return nil, trace.Wrap(err)
 	}
 
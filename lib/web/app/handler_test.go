@@ -173,6 +173,33 @@ func TestMatchApplicationServers(t *testing.T) {
 	require.Equal(t, expectedContent, content)
 }
 
+// TestSessionExpiryEnforced verifies that a request carrying a session past
+// its expiry is rejected even if the backend still returns the session.
+func TestSessionExpiryEnforced(t *testing.T) {
+	clusterName := "test-cluster"
+	publicAddr := "app.example.com"
+
+	key, cert, err := tlsca.GenerateSelfSignedCA(
+		pkix.Name{CommonName: clusterName},
+		[]string{publicAddr, apiutils.EncodeClusterName(clusterName)},
+		defaults.CATTL,
+	)
+	require.NoError(t, err)
+
+	fakeClock := clockwork.NewFakeClockAt(time.Date(2017, 05, 10, 18, 53, 0, 0, time.UTC))
+	authClient := mockAuthClient{
+		clusterName: clusterName,
+		appSession:  createAppSession(t, fakeClock, key, cert, clusterName, publicAddr),
+	}
+	p := setup(t, fakeClock, authClient, nil)
+
+	// Advance the clock past the session expiry; the request must be
+	// rejected before any forwarding takes place.
+	fakeClock.Advance(time.Hour)
+	status, _ := p.makeRequest(t, "GET", "/", CookieName, "abc", []byte{})
+	require.Equal(t, http.StatusForbidden, status)
+}
+
 type testServer struct {
 	serverURL *url.URL
 }
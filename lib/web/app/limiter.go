@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// userLimiter enforces per-user caps on cached app sessions and in-flight
+// forwarded streams, protecting shared applications from a single user's
+// runaway automation. A zero limit disables the corresponding check.
+type userLimiter struct {
+	clock       clockwork.Clock
+	maxSessions int
+	maxStreams  int
+
+	mu sync.Mutex
+	// sessions maps user to session ID to session expiry. Entries are
+	// pruned lazily against the expiry time, so sessions evicted from the
+	// forwarder cache without notice cannot pin the count forever.
+	sessions map[string]map[string]time.Time
+	// streams counts in-flight forwarded requests per user.
+	streams map[string]int
+}
+
+// newUserLimiter returns a limiter enforcing the given per-user caps.
+func newUserLimiter(maxSessions, maxStreams int, clock clockwork.Clock) *userLimiter {
+	return &userLimiter{
+		clock:       clock,
+		maxSessions: maxSessions,
+		maxStreams:  maxStreams,
+		sessions:    make(map[string]map[string]time.Time),
+		streams:     make(map[string]int),
+	}
+}
+
+// addSession records an app session for the user, refusing it when the user
+// already has the maximum number of distinct live sessions. Re-adding a known
+// session only refreshes its expiry.
+func (l *userLimiter) addSession(user, sessionID string, expires time.Time) error {
+	if l.maxSessions <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sessions := l.sessions[user]
+	now := l.clock.Now()
+	for id, expiry := range sessions {
+		if expiry.Before(now) {
+			delete(sessions, id)
+		}
+	}
+	if _, ok := sessions[sessionID]; ok {
+		sessions[sessionID] = expires
+		return nil
+	}
+	if len(sessions) >= l.maxSessions {
+		return trace.LimitExceeded("user %q has reached the limit of %v simultaneous app sessions", user, l.maxSessions)
+	}
+	if sessions == nil {
+		sessions = make(map[string]time.Time)
+		l.sessions[user] = sessions
+	}
+	sessions[sessionID] = expires
+	return nil
+}
+
+// removeSession forgets an app session for the user.
+func (l *userLimiter) removeSession(user, sessionID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.sessions[user], sessionID)
+}
+
+// acquireStream reserves an in-flight stream slot for the user, returning a
+// release function to call once the stream completes.
+func (l *userLimiter) acquireStream(user string) (func(), error) {
+	if l.maxStreams <= 0 {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.streams[user] >= l.maxStreams {
+		return nil, trace.LimitExceeded("user %q has reached the limit of %v concurrent app streams", user, l.maxStreams)
+	}
+	l.streams[user]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.streams[user]--; l.streams[user] <= 0 {
+				delete(l.streams, user)
+			}
+		})
+	}, nil
+}
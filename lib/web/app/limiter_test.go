@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserLimiterSessions(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	limiter := newUserLimiter(2, 0, clock)
+	expires := clock.Now().Add(time.Hour)
+
+	require.NoError(t, limiter.addSession("alice", "s1", expires))
+	require.NoError(t, limiter.addSession("alice", "s2", expires))
+
+	// The third session is refused, but re-adding a known one is not.
+	err := limiter.addSession("alice", "s3", expires)
+	require.True(t, trace.IsLimitExceeded(err))
+	require.NoError(t, limiter.addSession("alice", "s2", expires))
+
+	// Other users have their own budget.
+	require.NoError(t, limiter.addSession("bob", "s4", expires))
+
+	// Removing a session frees a slot.
+	limiter.removeSession("alice", "s1")
+	require.NoError(t, limiter.addSession("alice", "s3", expires))
+
+	// Expired sessions are pruned instead of pinning the count.
+	clock.Advance(2 * time.Hour)
+	require.NoError(t, limiter.addSession("alice", "s5", clock.Now().Add(time.Hour)))
+}
+
+func TestUserLimiterStreams(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	limiter := newUserLimiter(0, 1, clock)
+
+	release, err := limiter.acquireStream("alice")
+	require.NoError(t, err)
+
+	_, err = limiter.acquireStream("alice")
+	require.True(t, trace.IsLimitExceeded(err))
+
+	// Releasing twice only frees the slot once.
+	release()
+	release()
+	release, err = limiter.acquireStream("alice")
+	require.NoError(t, err)
+	defer release()
+
+	// A zero limit disables the check entirely.
+	unlimited := newUserLimiter(0, 0, clock)
+	for i := 0; i < 10; i++ {
+		_, err := unlimited.acquireStream("alice")
+		require.NoError(t, err)
+	}
+}
@@ -36,6 +36,9 @@ func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request, p httprou
 		return trace.Wrap(err)
 	}
 
+	// The session no longer exists, free its slot in the per-user limits.
+	h.limiter.removeSession(session.ws.GetUser(), session.ws.GetName())
+
 	// Set Max-Age to 0 to tell the browser to delete this cookie.
 	http.SetCookie(w, &http.Cookie{
 		Name:     CookieName,
@@ -49,6 +49,11 @@ func (h *Handler) withAuth(handler handlerAuthFunc) http.HandlerFunc {
 		// If the caller fails to authenticate, redirect the caller to Teleport.
 		session, err := h.authenticate(r.Context(), r)
 		if err != nil {
+			// Limit rejections are not an authentication problem, so
+			// redirecting to the launcher would only loop; report them.
+			if trace.IsLimitExceeded(err) {
+				return trace.Wrap(err)
+			}
 			if redirectErr := h.redirectToLauncher(w, r, launcherURLParams{}); redirectErr == nil {
 				return nil
 			}
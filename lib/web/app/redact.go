@@ -0,0 +1,110 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+const (
+	// debugRequestsLabel is the app label that enables debug logging of
+	// requests and responses proxied to the app. Headers are passed through
+	// redaction first so diagnostics don't leak credentials into logs.
+	debugRequestsLabel = types.TeleportNamespace + "/debug-requests"
+	// redactHeadersLabel is the app label with a comma-separated list of
+	// additional header names to redact in debug logs, on top of the
+	// built-in set.
+	redactHeadersLabel = types.TeleportNamespace + "/redact-headers"
+	// debugBodyLimitLabel is the app label with the number of request body
+	// bytes to sample into debug logs. Zero (the default) logs no body.
+	debugBodyLimitLabel = types.TeleportNamespace + "/debug-body-limit"
+
+	// redactedValue replaces the value of a sensitive header in logs.
+	redactedValue = "[redacted]"
+)
+
+// defaultRedactedHeaders are always redacted in debug logs regardless of
+// configuration: they carry credentials or session material.
+var defaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Csrf-Token",
+}
+
+// redactor removes sensitive header values before they are written to
+// debug logs.
+type redactor struct {
+	// headers is the set of canonical header names to redact.
+	headers map[string]struct{}
+}
+
+// newRedactor builds a redactor from the app labels, combining the built-in
+// sensitive headers with any extras listed in the redact-headers label.
+func newRedactor(labels map[string]string) *redactor {
+	headers := make(map[string]struct{})
+	for _, name := range defaultRedactedHeaders {
+		headers[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+	for _, name := range strings.Split(labels[redactHeadersLabel], ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			headers[http.CanonicalHeaderKey(name)] = struct{}{}
+		}
+	}
+	return &redactor{headers: headers}
+}
+
+// redactHeaders returns a copy of the headers with sensitive values
+// replaced, leaving the originals untouched.
+func (r *redactor) redactHeaders(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if _, ok := r.headers[http.CanonicalHeaderKey(name)]; ok {
+			redacted[name] = []string{redactedValue}
+			continue
+		}
+		redacted[name] = append([]string(nil), values...)
+	}
+	return redacted
+}
+
+// debugRequestsEnabled reports whether debug request logging is turned on
+// through the app labels.
+func debugRequestsEnabled(labels map[string]string) bool {
+	enabled, err := strconv.ParseBool(labels[debugRequestsLabel])
+	return err == nil && enabled
+}
+
+// debugBodyLimit returns the number of request body bytes to sample into
+// debug logs, zero when unset or invalid.
+func debugBodyLimit(labels map[string]string) int64 {
+	value, ok := labels[debugBodyLimitLabel]
+	if !ok {
+		return 0
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	r := newRedactor(map[string]string{
+		redactHeadersLabel: "X-Api-Key, x-secret",
+	})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Set("Set-Cookie", "session=secret")
+	header.Set("X-Api-Key", "secret")
+	header.Set("X-Secret", "secret")
+	header.Set("Content-Type", "application/json")
+
+	redacted := r.redactHeaders(header)
+	require.Equal(t, redactedValue, redacted.Get("Authorization"))
+	require.Equal(t, redactedValue, redacted.Get("Set-Cookie"))
+	require.Equal(t, redactedValue, redacted.Get("X-Api-Key"))
+	require.Equal(t, redactedValue, redacted.Get("X-Secret"))
+	require.Equal(t, "application/json", redacted.Get("Content-Type"))
+
+	// The original headers are left untouched.
+	require.Equal(t, "Bearer secret", header.Get("Authorization"))
+}
+
+func TestDebugLabels(t *testing.T) {
+	require.True(t, debugRequestsEnabled(map[string]string{debugRequestsLabel: "true"}))
+	require.False(t, debugRequestsEnabled(map[string]string{debugRequestsLabel: "bogus"}))
+	require.False(t, debugRequestsEnabled(map[string]string{}))
+
+	require.Equal(t, int64(1024), debugBodyLimit(map[string]string{debugBodyLimitLabel: "1024"}))
+	require.Equal(t, int64(0), debugBodyLimit(map[string]string{debugBodyLimitLabel: "-1"}))
+	require.Equal(t, int64(0), debugBodyLimit(map[string]string{}))
+}
@@ -39,6 +39,9 @@ type session struct {
 	fwd *forward.Forwarder
 	// ws represents the services.WebSession this requests belongs to.
 	ws types.WebSession
+	// id is the identity encoded in the session certificate, used to
+	// re-validate the session on each forwarded request.
+	id *tlsca.Identity
 }
 
 // newSession creates a new session.
@@ -113,6 +116,7 @@ func (h *Handler) newSession(ctx context.Context, ws types.WebSession) (*session
 	return &session{
 		fwd: fwd,
 		ws:  ws,
+		id:  identity,
 	}, nil
 }
 
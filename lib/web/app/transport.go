@@ -17,13 +17,16 @@ limitations under the License.
 package app
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gravitational/teleport/api/constants"
 	"github.com/gravitational/teleport/api/types"
@@ -40,6 +43,15 @@ import (
 	"github.com/gravitational/trace"
 )
 
+const (
+	// websocketIdleTimeoutLabel is the app label that configures the idle
+	// timeout enforced on websocket connections to the app.
+	websocketIdleTimeoutLabel = types.TeleportNamespace + "/websocket-idle-timeout"
+	// websocketMaxLifetimeLabel is the app label that configures the
+	// maximum lifetime enforced on websocket connections to the app.
+	websocketMaxLifetimeLabel = types.TeleportNamespace + "/websocket-max-lifetime"
+)
+
 // transportConfig is configuration for a rewriting transport.
 type transportConfig struct {
 	proxyClient  reversetunnel.Tunnel
@@ -94,6 +106,25 @@ type transport struct {
 	// organized in a map where the key is the server ID, and the value is the
 	// `types.AppServer`.
 	servers *sync.Map
+
+	// wsIdleTimeout is the idle timeout enforced on websocket connections.
+	// Zero means no limit.
+	wsIdleTimeout time.Duration
+
+	// wsMaxLifetime is the maximum lifetime enforced on websocket
+	// connections. Zero means no limit.
+	wsMaxLifetime time.Duration
+
+	// debugRequests enables debug logging of proxied requests and
+	// responses, with sensitive headers redacted.
+	debugRequests bool
+
+	// redactor removes sensitive header values from debug logs.
+	redactor *redactor
+
+	// bodySampleLimit is the number of request body bytes included in
+	// debug logs. Zero logs no body.
+	bodySampleLimit int64
 }
 
 // newTransport creates a new transport.
@@ -122,6 +153,15 @@ func newTransport(c *transportConfig) (*transport, error) {
 		t.servers.Store(server.GetResourceID(), server)
 	}
 
+	t.wsIdleTimeout, t.wsMaxLifetime = websocketLimits(c)
+
+	// All matched servers proxy the same application, so the labels of the
+	// first one are authoritative.
+	labels := c.servers[0].GetApp().GetAllLabels()
+	t.debugRequests = debugRequestsEnabled(labels)
+	t.redactor = newRedactor(labels)
+	t.bodySampleLimit = debugBodyLimit(labels)
+
 	t.tr = tr
 	return t, nil
 }
@@ -134,15 +174,72 @@ func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	if t.debugRequests {
+		t.logRequest(r)
+	}
+
+	// gRPC requests carry their deadline in the grpc-timeout header; enforce
+	// it on the upstream context so the app stops working on calls whose
+	// deadline has already passed.
+	var cancel context.CancelFunc
+	if isGRPCRequest(r) {
+		r, cancel = t.applyGRPCTimeout(r)
+	}
+
 	// Forward the request to the target application.
 	resp, err := t.tr.RoundTrip(r)
 	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
 		return nil, trace.Wrap(err)
 	}
+	if cancel != nil {
+		// Keep the deadline context alive until the (possibly streaming)
+		// response body has been consumed.
+		resp.Body = newCancelOnCloseBody(resp.Body, cancel)
+	}
+
+	if t.debugRequests {
+		t.c.log.Debugf("App response: %v %v %v headers=%v.",
+			r.Method, r.URL.Path, resp.StatusCode, t.redactor.redactHeaders(resp.Header))
+	}
 
 	return resp, nil
 }
 
+// logRequest writes the request to the debug log with sensitive headers
+// redacted and, when configured, a bounded sample of the request body.
+func (t *transport) logRequest(r *http.Request) {
+	body := t.sampleBody(r)
+	if body != "" {
+		t.c.log.Debugf("App request: %v %v headers=%v body=%q.",
+			r.Method, r.URL.Path, t.redactor.redactHeaders(r.Header), body)
+		return
+	}
+	t.c.log.Debugf("App request: %v %v headers=%v.",
+		r.Method, r.URL.Path, t.redactor.redactHeaders(r.Header))
+}
+
+// sampleBody reads up to the configured limit from the request body for
+// logging and splices the read bytes back so the upstream request is
+// unaffected.
+func (t *transport) sampleBody(r *http.Request) string {
+	if t.bodySampleLimit <= 0 || r.Body == nil {
+		return ""
+	}
+	buf := make([]byte, t.bodySampleLimit)
+	n, _ := io.ReadFull(r.Body, buf)
+	if n == 0 {
+		return ""
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf[:n]), r.Body), r.Body}
+	return string(buf[:n])
+}
+
 // rewriteRequest applies any rewriting rules to the request before it's forwarded.
 func (t *transport) rewriteRequest(r *http.Request) error {
 	// Set dummy values for the request forwarder. Dialing through the tunnel is
@@ -224,7 +321,96 @@ func (t *transport) DialWebsocket(network, address string) (net.Conn, error) {
 		return nil, trace.Wrap(err)
 	}
 	// App access connections over reverse tunnel use mutual TLS.
-	return tls.Client(conn, t.clientTLSConfig), nil
+	tlsConn := tls.Client(conn, t.clientTLSConfig)
+	if t.wsIdleTimeout == 0 && t.wsMaxLifetime == 0 {
+		return tlsConn, nil
+	}
+	return newLimitedConn(tlsConn, t.wsIdleTimeout, t.wsMaxLifetime), nil
+}
+
+// websocketLimits returns the idle timeout and maximum lifetime configured
+// for the application through the "teleport.dev/websocket-idle-timeout" and
+// "teleport.dev/websocket-max-lifetime" labels. Zero means no limit.
+func websocketLimits(c *transportConfig) (idleTimeout, maxLifetime time.Duration) {
+	// All matched servers proxy the same application, so the labels of the
+	// first one are authoritative.
+	labels := c.servers[0].GetApp().GetAllLabels()
+	idleTimeout = parseDurationLabel(c, labels, websocketIdleTimeoutLabel)
+	maxLifetime = parseDurationLabel(c, labels, websocketMaxLifetimeLabel)
+	return idleTimeout, maxLifetime
+}
+
+// parseDurationLabel parses a duration app label, returning zero (no limit)
+// if the label is missing or invalid.
+func parseDurationLabel(c *transportConfig, labels map[string]string, label string) time.Duration {
+	value, ok := labels[label]
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		c.log.Warnf("Ignoring invalid %v label value %q.", label, value)
+		return 0
+	}
+	return d
+}
+
+// limitedConn is a net.Conn that enforces an idle timeout and a maximum
+// lifetime on websocket connections.
+type limitedConn struct {
+	net.Conn
+
+	// idleTimeout resets the connection deadline on every read and write.
+	idleTimeout time.Duration
+
+	// lifetimeTimer closes the connection once the maximum lifetime is
+	// reached.
+	lifetimeTimer *time.Timer
+}
+
+// newLimitedConn wraps the connection with idle timeout and maximum
+// lifetime enforcement. A zero duration disables the respective limit.
+func newLimitedConn(conn net.Conn, idleTimeout, maxLifetime time.Duration) *limitedConn {
+	l := &limitedConn{
+		Conn:        conn,
+		idleTimeout: idleTimeout,
+	}
+	if maxLifetime > 0 {
+		l.lifetimeTimer = time.AfterFunc(maxLifetime, func() {
+			conn.Close()
+		})
+	}
+	l.extendDeadline()
+	return l
+}
+
+// extendDeadline pushes out the connection deadline by the idle timeout.
+func (l *limitedConn) extendDeadline() {
+	if l.idleTimeout > 0 {
+		l.Conn.SetDeadline(time.Now().Add(l.idleTimeout))
+	}
+}
+
+// Read reads from the connection and resets the idle deadline.
+func (l *limitedConn) Read(b []byte) (int, error) {
+	n, err := l.Conn.Read(b)
+	l.extendDeadline()
+	return n, err
+}
+
+// Write writes to the connection and resets the idle deadline.
+func (l *limitedConn) Write(b []byte) (int, error) {
+	n, err := l.Conn.Write(b)
+	l.extendDeadline()
+	return n, err
+}
+
+// Close stops the lifetime timer and closes the connection.
+func (l *limitedConn) Close() error {
+	if l.lifetimeTimer != nil {
+		l.lifetimeTimer.Stop()
+	}
+	return l.Conn.Close()
 }
 
 // dialAppServer dial and connect to the application service over the reverse
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDurationLabel(t *testing.T) {
+	c := &transportConfig{
+		log: utils.NewLoggerForTests().WithField(trace.Component, "test"),
+	}
+	labels := map[string]string{
+		websocketIdleTimeoutLabel: "5m",
+		websocketMaxLifetimeLabel: "bogus",
+	}
+	require.Equal(t, 5*time.Minute, parseDurationLabel(c, labels, websocketIdleTimeoutLabel))
+	require.Equal(t, time.Duration(0), parseDurationLabel(c, labels, websocketMaxLifetimeLabel))
+	require.Equal(t, time.Duration(0), parseDurationLabel(c, labels, "missing"))
+}
+
+func TestLimitedConnMaxLifetime(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	conn := newLimitedConn(client, 0, 10*time.Millisecond)
+	defer conn.Close()
+
+	buf := make([]byte, 1)
+	_, err := conn.Read(buf)
+	require.Error(t, err)
+}
+
+func TestLimitedConnIdleTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	conn := newLimitedConn(client, 10*time.Millisecond, 0)
+	defer conn.Close()
+
+	buf := make([]byte, 1)
+	_, err := conn.Read(buf)
+	require.Error(t, err)
+}
@@ -82,8 +82,21 @@ const (
 	// MetricMissingSSHTunnels returns the number of missing SSH tunnels for this proxy.
 	MetricMissingSSHTunnels = "proxy_missing_ssh_tunnels"
 
+	// MetricAppRequests counts requests proxied to applications
+	MetricAppRequests = "app_requests_total"
+
+	// MetricAppRequestsHistogram measures latency of requests proxied to applications
+	MetricAppRequestsHistogram = "app_request_seconds"
+
+	// MetricAppActiveTunnels measures the number of active websocket tunnels to applications
+	MetricAppActiveTunnels = "app_active_tunnels"
+
 	// TagCluster is a metric tag for a cluster
 	TagCluster = "cluster"
+	// TagApp is a metric tag for an application
+	TagApp = "app"
+	// TagStatusCode is a metric tag for an HTTP status code
+	TagStatusCode = "status_code"
 )
 
 const (
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// canAccessHelp describes the target formats accepted by `tsh can-access`.
+const canAccessHelp = "Target to check: [user@]host for an SSH node, db/<name>, kube/<name> or app/<name>"
+
+// onCanAccess executes the 'tsh can-access' command. It evaluates the roles
+// from the current certificate against the target resource locally, without
+// attempting to connect, and reports whether access would be allowed along
+// with the roles that grant it.
+func onCanAccess(cf *CLIConf) error {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = client.RetryWithRelogin(cf.Context, tc, func() error {
+		proxyClient, err := tc.ConnectToProxy(cf.Context)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer proxyClient.Close()
+		authClient, err := proxyClient.ConnectToCurrentCluster(cf.Context, true)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer authClient.Close()
+
+		roleSet, err := services.FetchRoles(profile.Roles, authClient, profile.Traits)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(checkAccess(cf, tc, authClient, roleSet))
+	})
+	return trace.Wrap(err)
+}
+
+// checkAccess resolves the target resource and runs the access dry run
+// against it.
+func checkAccess(cf *CLIConf, tc *client.TeleportClient, authClient auth.ClientI, roleSet services.RoleSet) error {
+	kind, name := parseCanAccessTarget(cf.UserHost)
+	switch kind {
+	case types.KindNode:
+		return trace.Wrap(checkNodeAccess(cf, tc, roleSet, name))
+	case types.KindDatabase:
+		return trace.Wrap(checkDatabaseAccess(cf, tc, roleSet, name))
+	case types.KindKubernetesCluster:
+		return trace.Wrap(checkKubeAccess(cf, authClient, roleSet, name))
+	case types.KindApp:
+		return trace.Wrap(checkAppAccess(cf, tc, roleSet, name))
+	default:
+		return trace.BadParameter("unsupported target kind %q, valid kinds are db, kube and app", kind)
+	}
+}
+
+// parseCanAccessTarget splits a can-access target into a resource kind and
+// name. Targets without a kind prefix are treated as SSH nodes.
+func parseCanAccessTarget(target string) (kind, name string) {
+	parts := strings.SplitN(target, "/", 2)
+	if len(parts) != 2 {
+		return types.KindNode, target
+	}
+	switch parts[0] {
+	case "node", "ssh":
+		return types.KindNode, parts[1]
+	case "db":
+		return types.KindDatabase, parts[1]
+	case "kube":
+		return types.KindKubernetesCluster, parts[1]
+	case "app":
+		return types.KindApp, parts[1]
+	}
+	return parts[0], parts[1]
+}
+
+func checkNodeAccess(cf *CLIConf, tc *client.TeleportClient, roleSet services.RoleSet, target string) error {
+	login := tc.HostLogin
+	if parts := strings.SplitN(target, "@", 2); len(parts) == 2 {
+		login, target = parts[0], parts[1]
+	}
+	if login == "" {
+		return trace.BadParameter("no login specified, use user@host or --user")
+	}
+
+	nodes, err := tc.ListAllNodes(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, node := range nodes {
+		if node.GetHostname() != target && node.GetName() != target {
+			continue
+		}
+		return reportAccess(cf, roleSet, node, fmt.Sprintf("node %q as user %q", target, login),
+			services.NewLoginMatcher(login))
+	}
+	return trace.NotFound("node %q not found in cluster", target)
+}
+
+func checkDatabaseAccess(cf *CLIConf, tc *client.TeleportClient, roleSet services.RoleSet, name string) error {
+	databases, err := tc.ListDatabases(cf.Context, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, database := range databases {
+		if database.GetName() != name {
+			continue
+		}
+		var matchers []services.RoleMatcher
+		description := fmt.Sprintf("database %q", name)
+		if cf.DatabaseUser != "" {
+			matchers = append(matchers, &services.DatabaseUserMatcher{User: cf.DatabaseUser})
+			description = fmt.Sprintf("database %q as user %q", name, cf.DatabaseUser)
+		}
+		return reportAccess(cf, roleSet, database, description, matchers...)
+	}
+	return trace.NotFound("database %q not found in cluster", name)
+}
+
+func checkKubeAccess(cf *CLIConf, authClient auth.ClientI, roleSet services.RoleSet, name string) error {
+	servers, err := authClient.GetKubeServices(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, server := range servers {
+		for _, cluster := range server.GetKubernetesClusters() {
+			if cluster.Name != name {
+				continue
+			}
+			kubeV3, err := types.NewKubernetesClusterV3FromLegacyCluster(server.GetNamespace(), cluster)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			return reportAccess(cf, roleSet, kubeV3, fmt.Sprintf("kubernetes cluster %q", name))
+		}
+	}
+	return trace.NotFound("kubernetes cluster %q not found in cluster", name)
+}
+
+func checkAppAccess(cf *CLIConf, tc *client.TeleportClient, roleSet services.RoleSet, name string) error {
+	apps, err := tc.ListApps(cf.Context, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, app := range apps {
+		if app.GetName() != name {
+			continue
+		}
+		return reportAccess(cf, roleSet, app, fmt.Sprintf("app %q", name))
+	}
+	return trace.NotFound("app %q not found in cluster", name)
+}
+
+// reportAccess runs the access check and prints the verdict. The check
+// assumes any per-session MFA requirement would be satisfied, it only
+// evaluates role allow and deny rules.
+func reportAccess(cf *CLIConf, roleSet services.RoleSet, r services.AccessCheckable, description string, matchers ...services.RoleMatcher) error {
+	mfaParams := services.AccessMFAParams{Verified: true}
+	err := roleSet.CheckAccess(r, mfaParams, matchers...)
+	if err != nil {
+		fmt.Fprintf(cf.Stdout(), "Access denied to %v: %v\n", description, err)
+		fmt.Fprintf(cf.Stdout(), "Assigned roles: %v\n", strings.Join(roleNames(roleSet), ", "))
+		return trace.Wrap(err)
+	}
+	fmt.Fprintf(cf.Stdout(), "Access allowed to %v\n", description)
+	if granting := rolesGrantingAccess(roleSet, r, matchers...); len(granting) > 0 {
+		fmt.Fprintf(cf.Stdout(), "Granted by roles: %v\n", strings.Join(granting, ", "))
+	}
+	return nil
+}
+
+// rolesGrantingAccess returns the names of roles that alone grant access to
+// the resource, attributing an allow verdict to specific role rules.
+func rolesGrantingAccess(roleSet services.RoleSet, r services.AccessCheckable, matchers ...services.RoleMatcher) []string {
+	var names []string
+	for _, role := range roleSet {
+		if role.GetName() == constants.DefaultImplicitRole {
+			continue
+		}
+		err := services.NewRoleSet(role).CheckAccess(r, services.AccessMFAParams{Verified: true}, matchers...)
+		if err == nil {
+			names = append(names, role.GetName())
+		}
+	}
+	return names
+}
+
+// roleNames returns the names of the roles in the set, omitting the
+// implicit role.
+func roleNames(roleSet services.RoleSet) []string {
+	var names []string
+	for _, role := range roleSet {
+		if role.GetName() == constants.DefaultImplicitRole {
+			continue
+		}
+		names = append(names, role.GetName())
+	}
+	return names
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,10 +29,13 @@ import (
 	"github.com/gravitational/teleport/api/client"
 	"github.com/gravitational/teleport/api/client/proto"
 	"github.com/gravitational/teleport/api/types"
+	apiutils "github.com/gravitational/teleport/api/utils"
 	"github.com/gravitational/teleport/lib/asciitable"
 	"github.com/gravitational/teleport/lib/auth"
+	libclient "github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/prompt"
 
 	"github.com/gravitational/trace"
 )
@@ -262,6 +266,22 @@ func onRequestCreate(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
+	// With no roles, resources or request ID given, walk the user through
+	// an interactive wizard instead of failing on a missing flag.
+	if cf.DesiredRoles == "" && cf.RequestID == "" && cf.RequestedResourceIDs == "" {
+		if err := runRequestWizard(cf, tc); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	// New requests must satisfy the reason template the cluster advertises,
+	// prompting for the required fields when no reason was given.
+	if cf.RequestID == "" {
+		if err := applyReasonTemplate(cf, tc); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	if err := executeAccessRequest(cf, tc); err != nil {
 		return trace.Wrap(err)
 	}
@@ -270,6 +290,104 @@ func onRequestCreate(cf *CLIConf) error {
 	return nil
 }
 
+// runRequestWizard interactively collects the parameters of a new access
+// request: roles picked from the server's requestable roles, a reason,
+// suggested reviewers and an optional TTL. The answers are stored on cf for
+// executeAccessRequest to use.
+func runRequestWizard(cf *CLIConf, tc *libclient.TeleportClient) error {
+	var caps *types.AccessCapabilities
+	err := tc.WithRootClusterClient(cf.Context, func(clt auth.ClientI) error {
+		var err error
+		caps, err = clt.GetAccessCapabilities(cf.Context, types.AccessCapabilitiesRequest{
+			RequestableRoles: true,
+		})
+		return trace.Wrap(err)
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(caps.RequestableRoles) == 0 {
+		return trace.NotFound("no requestable roles, ask your cluster administrator about role request settings")
+	}
+
+	fmt.Println("Requestable roles:")
+	for i, role := range caps.RequestableRoles {
+		fmt.Printf("  %v) %v\n", i+1, role)
+	}
+
+	var roles []string
+	for {
+		answer, err := prompt.Input(cf.Context, os.Stdout, prompt.Stdin(), "Roles to request (comma-separated names or numbers)")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		roles, err = matchRequestableRoles(caps.RequestableRoles, answer)
+		if err == nil {
+			break
+		}
+		fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
+	}
+
+	reason, err := prompt.Input(cf.Context, os.Stdout, prompt.Stdin(), "Reason for the request")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	reviewers, err := prompt.Input(cf.Context, os.Stdout, prompt.Stdin(), "Suggested reviewers (comma-separated, optional)")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	var ttl time.Duration
+	for {
+		answer, err := prompt.Input(cf.Context, os.Stdout, prompt.Stdin(), "Access TTL, e.g. 2h (optional)")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if answer == "" {
+			break
+		}
+		ttl, err = time.ParseDuration(answer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid duration %q, use a format like 30m or 2h.\n", answer)
+			continue
+		}
+		break
+	}
+
+	cf.DesiredRoles = strings.Join(roles, ",")
+	cf.RequestReason = reason
+	cf.SuggestedReviewers = reviewers
+	cf.RequestTTL = ttl
+	return nil
+}
+
+// matchRequestableRoles resolves a comma-separated answer of role names or
+// one-based list numbers against the available requestable roles.
+func matchRequestableRoles(available []string, answer string) ([]string, error) {
+	var roles []string
+	for _, token := range strings.Split(answer, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(token); err == nil {
+			if n < 1 || n > len(available) {
+				return nil, trace.BadParameter("no role with number %v", n)
+			}
+			roles = append(roles, available[n-1])
+			continue
+		}
+		if !apiutils.SliceContainsStr(available, token) {
+			return nil, trace.BadParameter("role %q is not requestable", token)
+		}
+		roles = append(roles, token)
+	}
+	if len(roles) == 0 {
+		return nil, trace.BadParameter("select at least one role")
+	}
+	return roles, nil
+}
+
 func onRequestReview(cf *CLIConf) error {
 	tc, err := makeClient(cf, false)
 	if err != nil {
@@ -294,6 +412,13 @@ func onRequestReview(cf *CLIConf) error {
 
 	var req types.AccessRequest
 	err = tc.WithRootClusterClient(cf.Context, func(clt auth.ClientI) error {
+		// Approvals grant privileged access, so on clusters that enforce
+		// per-session MFA the reviewer has to pass a fresh MFA check first.
+		if state == types.RequestState_APPROVED {
+			if err := maybePromptReviewMFA(cf, tc, clt); err != nil {
+				return trace.Wrap(err)
+			}
+		}
 		req, err = clt.SubmitAccessReview(cf.Context, types.AccessReviewSubmission{
 			RequestID: cf.RequestID,
 			Review: types.AccessReview{
@@ -316,6 +441,37 @@ func onRequestReview(cf *CLIConf) error {
 	return nil
 }
 
+// maybePromptReviewMFA requires a fresh MFA check before an approval is
+// submitted when the cluster enforces per-session MFA, so an unattended
+// terminal with a valid tsh session can't be used to approve privileged
+// access. Denials don't grant anything and are not gated.
+func maybePromptReviewMFA(cf *CLIConf, tc *libclient.TeleportClient, clt auth.ClientI) error {
+	authPref, err := clt.GetAuthPreference(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !authPref.GetRequireSessionMFA() {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "This cluster requires an MFA check to approve access requests.")
+	chal, err := clt.CreateAuthenticateChallenge(cf.Context, &proto.CreateAuthenticateChallengeRequest{
+		Request: &proto.CreateAuthenticateChallengeRequest_ContextUser{
+			ContextUser: &proto.ContextUser{},
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if chal.GetTOTP() == nil && chal.GetWebauthnChallenge() == nil {
+		return trace.BadParameter("cluster requires MFA to approve access requests but you have no registered devices, see 'tsh mfa add'")
+	}
+	if _, err := tc.PromptMFAChallenge(cf.Context, chal, nil /* optsOverride */); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 func showRequestTable(reqs []types.AccessRequest) error {
 	sort.Slice(reqs, func(i, j int) bool {
 		return reqs[i].GetCreationTime().After(reqs[j].GetCreationTime())
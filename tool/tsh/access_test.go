@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/teleport/api/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCanAccessTarget(t *testing.T) {
+	tests := []struct {
+		target   string
+		wantKind string
+		wantName string
+	}{
+		{target: "host1", wantKind: types.KindNode, wantName: "host1"},
+		{target: "alice@host1", wantKind: types.KindNode, wantName: "alice@host1"},
+		{target: "node/host1", wantKind: types.KindNode, wantName: "host1"},
+		{target: "ssh/host1", wantKind: types.KindNode, wantName: "host1"},
+		{target: "db/postgres", wantKind: types.KindDatabase, wantName: "postgres"},
+		{target: "kube/main", wantKind: types.KindKubernetesCluster, wantName: "main"},
+		{target: "app/grafana", wantKind: types.KindApp, wantName: "grafana"},
+		{target: "desktop/win1", wantKind: "desktop", wantName: "win1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			kind, name := parseCanAccessTarget(tt.target)
+			require.Equal(t, tt.wantKind, kind)
+			require.Equal(t, tt.wantName, name)
+		})
+	}
+}
+
+func TestMatchRequestableRoles(t *testing.T) {
+	available := []string{"dba", "dev", "ops"}
+	tests := []struct {
+		name    string
+		answer  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "by name", answer: "dba,ops", want: []string{"dba", "ops"}},
+		{name: "by number", answer: "1, 3", want: []string{"dba", "ops"}},
+		{name: "mixed", answer: "2, dba", want: []string{"dev", "dba"}},
+		{name: "unknown role", answer: "root", wantErr: true},
+		{name: "number out of range", answer: "4", wantErr: true},
+		{name: "empty answer", answer: " , ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, err := matchRequestableRoles(available, tt.answer)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, roles)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/asciitable"
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+)
+
+// onAgentList implements "tsh agent ls". It lists the Teleport keys and
+// certificates currently held by the system SSH agent.
+func onAgentList(cf *CLIConf) error {
+	sshAgent := client.ConnectToSSHAgent()
+	if sshAgent == nil {
+		return trace.NotFound("no running SSH agent found, make sure %v is set", teleport.SSHAuthSock)
+	}
+
+	keys, err := sshAgent.List()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	table := asciitable.MakeTable([]string{"Identity", "Type", "Expires"})
+	var count int
+	for _, key := range keys {
+		if !strings.HasPrefix(key.Comment, "teleport:") {
+			continue
+		}
+		count++
+
+		keyType := "key"
+		expires := "never"
+		if pub, err := ssh.ParsePublicKey(key.Blob); err == nil {
+			if cert, ok := pub.(*ssh.Certificate); ok {
+				keyType = "certificate"
+				if cert.ValidBefore != 0 && cert.ValidBefore != uint64(ssh.CertTimeInfinity) {
+					expires = time.Unix(int64(cert.ValidBefore), 0).Format(time.RFC1123)
+				}
+			}
+		}
+		table.AddRow([]string{strings.TrimPrefix(key.Comment, "teleport:"), keyType, expires})
+	}
+
+	if count == 0 {
+		fmt.Println("No Teleport keys are loaded into the SSH agent.")
+		return nil
+	}
+	fmt.Println(table.AsBuffer().String())
+	return nil
+}
+
+// onAgentRefresh implements "tsh agent refresh". It reloads the certificates
+// for the current login into the SSH agent, reissuing them if necessary, so
+// third-party tools consuming the agent pick up fresh credentials.
+func onAgentRefresh(cf *CLIConf) error {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = client.RetryWithRelogin(cf.Context, tc, func() error {
+		return tc.LoadKeyForClusterWithReissue(cf.Context, tc.SiteName)
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	fmt.Printf("Reloaded Teleport certificates for cluster %q into the SSH agent.\n", tc.SiteName)
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/prompt"
+
+	"github.com/gravitational/trace"
+	"github.com/moby/term"
+)
+
+// resolveAmbiguousNode tries to turn a hostname that matched multiple nodes
+// into a single node ID instead of failing outright. A --prefer-label match
+// that narrows the candidates down to one node resolves silently; otherwise
+// --pick presents an interactive choice. Returns an empty ID when neither
+// applies, in which case the caller falls back to the usual error.
+func resolveAmbiguousNode(cf *CLIConf, host string, nodes []types.Server) (string, error) {
+	if len(nodes) == 0 {
+		return "", nil
+	}
+	candidates := nodes
+	if cf.PreferNodeLabel != "" {
+		key, value, err := parsePreferLabel(cf.PreferNodeLabel)
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		var preferred []types.Server
+		for _, node := range candidates {
+			if node.GetAllLabels()[key] == value {
+				preferred = append(preferred, node)
+			}
+		}
+		if len(preferred) == 1 {
+			return preferred[0].GetName(), nil
+		}
+		// Multiple nodes carry the label: narrow the picker down to them.
+		// None do: keep the full candidate list.
+		if len(preferred) > 1 {
+			candidates = preferred
+		}
+	}
+	if !cf.PickNode || !term.IsTerminal(os.Stdin.Fd()) {
+		return "", nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Hostname %q matches multiple nodes:\n", host)
+	for i, node := range candidates {
+		fmt.Fprintf(os.Stderr, "  %v) %v [%v] %v\n", i+1, node.GetHostname(), node.GetName(), node.LabelsString())
+	}
+	for {
+		answer, err := prompt.Input(cf.Context, os.Stderr, prompt.Stdin(), "Select a node (number or id, empty to abort)")
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		if strings.TrimSpace(answer) == "" {
+			return "", nil
+		}
+		nodeID, err := matchAmbiguousNode(candidates, answer)
+		if err == nil {
+			return nodeID, nil
+		}
+		fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
+	}
+}
+
+// matchAmbiguousNode resolves a picker answer to a node ID, accepting either
+// a node ID or a 1-based number from the printed list.
+func matchAmbiguousNode(nodes []types.Server, answer string) (string, error) {
+	answer = strings.TrimSpace(answer)
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(nodes) {
+			return "", trace.BadParameter("node number %v is out of range", n)
+		}
+		return nodes[n-1].GetName(), nil
+	}
+	for _, node := range nodes {
+		if node.GetName() == answer {
+			return node.GetName(), nil
+		}
+	}
+	return "", trace.BadParameter("no node matches %q", answer)
+}
+
+// parsePreferLabel parses the key=value argument of --prefer-label.
+func parsePreferLabel(label string) (string, string, error) {
+	parts := strings.SplitN(label, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", trace.BadParameter("--prefer-label expects key=value, got %q", label)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/api/types"
+)
+
+func ambiguousTestNodes(t *testing.T) []types.Server {
+	t.Helper()
+	var nodes []types.Server
+	for _, fixture := range []struct {
+		id     string
+		labels map[string]string
+	}{
+		{"node-a", map[string]string{"env": "prod"}},
+		{"node-b", map[string]string{"env": "staging"}},
+	} {
+		node, err := types.NewServerWithLabels(fixture.id, types.KindNode, types.ServerSpecV2{
+			Hostname: "web",
+		}, fixture.labels)
+		require.NoError(t, err)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func TestResolveAmbiguousNodeByLabel(t *testing.T) {
+	nodes := ambiguousTestNodes(t)
+
+	// A label matching exactly one node resolves silently.
+	cf := &CLIConf{PreferNodeLabel: "env=prod"}
+	nodeID, err := resolveAmbiguousNode(cf, "web", nodes)
+	require.NoError(t, err)
+	require.Equal(t, "node-a", nodeID)
+
+	// A label matching no node leaves the ambiguity unresolved.
+	cf = &CLIConf{PreferNodeLabel: "env=dev"}
+	nodeID, err = resolveAmbiguousNode(cf, "web", nodes)
+	require.NoError(t, err)
+	require.Empty(t, nodeID)
+
+	// Malformed label arguments are rejected.
+	cf = &CLIConf{PreferNodeLabel: "env"}
+	_, err = resolveAmbiguousNode(cf, "web", nodes)
+	require.Error(t, err)
+}
+
+func TestMatchAmbiguousNode(t *testing.T) {
+	nodes := ambiguousTestNodes(t)
+
+	nodeID, err := matchAmbiguousNode(nodes, "2")
+	require.NoError(t, err)
+	require.Equal(t, "node-b", nodeID)
+
+	nodeID, err = matchAmbiguousNode(nodes, " node-a ")
+	require.NoError(t, err)
+	require.Equal(t, "node-a", nodeID)
+
+	_, err = matchAmbiguousNode(nodes, "3")
+	require.Error(t, err)
+	_, err = matchAmbiguousNode(nodes, "unknown")
+	require.Error(t, err)
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/gravitational/teleport"
@@ -62,6 +65,42 @@ func onAppLogin(cf *CLIConf) error {
 		}
 	}
 
+	if err := appLogin(cf, tc, app, profile, arn); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if app.IsAWSConsole() {
+		err = awsCliTpl.Execute(os.Stdout, map[string]string{
+			"awsAppName": app.GetName(),
+			"awsCmd":     "s3 ls",
+		})
+	} else {
+		var curlCmd string
+		curlCmd, err = formatAppConfig(tc, profile, app.GetName(), app.GetPublicAddr(), appFormatCURL, rootCluster)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = appLoginTpl.Execute(os.Stdout, map[string]string{
+			"appName": app.GetName(),
+			"curlCmd": curlCmd,
+		})
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if cf.AppKeepAlive {
+		return trace.Wrap(keepAliveAppSession(cf, tc, app, profile, arn))
+	}
+	return nil
+}
+
+// appLogin creates an app session and reissues the user certificate with the
+// app route embedded, saving the resulting materials to the tsh profile.
+// When the cluster requires per-session MFA, or --mfa was passed for apps
+// gated on MFA by role options, the certificate is issued through the
+// single-use MFA flow and the user is prompted for a tap.
+func appLogin(cf *CLIConf, tc *client.TeleportClient, app types.Application, profile *client.ProfileStatus, arn string) error {
 	ws, err := tc.CreateAppSession(cf.Context, types.CreateAppSessionRequest{
 		Username:    tc.Username,
 		PublicAddr:  app.GetPublicAddr(),
@@ -71,7 +110,7 @@ func onAppLogin(cf *CLIConf) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = tc.ReissueUserCerts(cf.Context, client.CertCacheKeep, client.ReissueParams{
+	key, err := tc.IssueUserCertsWithMFA(cf.Context, client.ReissueParams{
 		RouteToCluster: tc.SiteName,
 		RouteToApp: proto.RouteToApp{
 			Name:        app.GetName(),
@@ -81,28 +120,58 @@ func onAppLogin(cf *CLIConf) error {
 			AWSRoleARN:  arn,
 		},
 		AccessRequests: profile.ActiveRequests.AccessRequests,
+		RequireMFA:     cf.AppMFA,
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
-
-	if err := tc.SaveProfile(cf.HomePath, true); err != nil {
+	if err := tc.LocalAgent().AddAppKey(key); err != nil {
 		return trace.Wrap(err)
 	}
-	if app.IsAWSConsole() {
-		return awsCliTpl.Execute(os.Stdout, map[string]string{
-			"awsAppName": app.GetName(),
-			"awsCmd":     "s3 ls",
-		})
+
+	return trace.Wrap(tc.SaveProfile(cf.HomePath, true))
+}
+
+// keepAliveAppSession refreshes the app certificate before it expires and
+// rewrites the on-disk materials, so long-running consumers of "tsh apps
+// config" paths keep working. It blocks until the process is interrupted.
+func keepAliveAppSession(cf *CLIConf, tc *client.TeleportClient, app types.Application, profile *client.ProfileStatus, arn string) error {
+	fmt.Printf("Keeping credentials for app %q refreshed, press Ctrl-C to stop.\n", app.GetName())
+	for {
+		delay, err := appCertRefreshInterval(tc, app.GetName())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		log.Debugf("Refreshing certificate for app %q in %v.", app.GetName(), delay)
+		select {
+		case <-cf.Context.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		if err := client.RetryWithRelogin(cf.Context, tc, func() error {
+			return appLogin(cf, tc, app, profile, arn)
+		}); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// appCertRefreshInterval returns how long to wait before refreshing the app
+// certificate, half of the remaining certificate lifetime.
+func appCertRefreshInterval(tc *client.TeleportClient, appName string) (time.Duration, error) {
+	key, err := tc.LocalAgent().GetKey(tc.SiteName, client.WithAppCerts{})
+	if err != nil {
+		return 0, trace.Wrap(err)
 	}
-	curlCmd, err := formatAppConfig(tc, profile, app.GetName(), app.GetPublicAddr(), appFormatCURL, rootCluster)
+	cert, err := key.AppTLSCertificate(appName)
 	if err != nil {
-		return trace.Wrap(err)
+		return 0, trace.Wrap(err)
 	}
-	return appLoginTpl.Execute(os.Stdout, map[string]string{
-		"appName": app.GetName(),
-		"curlCmd": curlCmd,
-	})
+	remaining := time.Until(cert.NotAfter)
+	if remaining <= 0 {
+		return 0, nil
+	}
+	return remaining / 2, nil
 }
 
 // appLoginTpl is the message that gets printed to a user upon successful app login.
@@ -199,6 +268,14 @@ func onAppConfig(cf *CLIConf) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if cf.AppConfigAll {
+		conf, err := formatAllAppsConfig(tc, profile, cf.Format)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Print(conf)
+		return nil
+	}
 	app, err := pickActiveApp(cf)
 	if err != nil {
 		return trace.Wrap(err)
@@ -239,6 +316,14 @@ func formatAppConfig(tc *client.TeleportClient, profile *client.ProfileStatus, a
 		return profile.KeyPath(), nil
 	case appFormatCURL:
 		return curlCmd, nil
+	case appFormatEnv:
+		prefix := appEnvVarPrefix(appName)
+		return fmt.Sprintf(`export %[1]vURI=%[2]v
+export %[1]vCA=%[3]v
+export %[1]vCERT=%[4]v
+export %[1]vKEY=%[5]v
+`, prefix, uri, profile.CACertPathForCluster(cluster),
+			profile.AppCertPath(appName), profile.KeyPath()), nil
 	case appFormatJSON, appFormatYAML:
 		appConfig := &appConfigInfo{
 			appName, uri, profile.CACertPathForCluster(cluster),
@@ -308,6 +393,182 @@ func pickActiveApp(cf *CLIConf) (*tlsca.RouteToApp, error) {
 	return nil, trace.NotFound("not logged into app %q", name)
 }
 
+// formatAllAppsConfig prints connection information for every app the
+// profile is logged into, so users with several app logins can hydrate
+// their shell in a single call, e.g. eval "$(tsh apps config --all --format=env)".
+func formatAllAppsConfig(tc *client.TeleportClient, profile *client.ProfileStatus, format string) (string, error) {
+	if strings.ToLower(format) != appFormatEnv {
+		return "", trace.BadParameter("--all requires --format=%v", appFormatEnv)
+	}
+	if len(profile.Apps) == 0 {
+		return "", trace.NotFound("please login using 'tsh app login' first")
+	}
+	var sb strings.Builder
+	for _, app := range profile.Apps {
+		conf, err := formatAppConfig(tc, profile, app.Name, app.PublicAddr, appFormatEnv, "")
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		sb.WriteString(conf)
+	}
+	return sb.String(), nil
+}
+
+// appEnvVarPrefix converts an app name into an environment variable prefix,
+// e.g. "my-app" becomes "TELEPORT_APP_MY_APP_".
+func appEnvVarPrefix(appName string) string {
+	var sb strings.Builder
+	sb.WriteString("TELEPORT_APP_")
+	for _, r := range strings.ToUpper(appName) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	sb.WriteString("_")
+	return sb.String()
+}
+
+// certInfo describes a single certificate on disk for "tsh apps inspect".
+type certInfo struct {
+	Type            string                 `json:"type"`
+	Name            string                 `json:"name,omitempty"`
+	User            string                 `json:"user"`
+	Roles           []string               `json:"roles,omitempty"`
+	Usage           []string               `json:"usage,omitempty"`
+	RouteToCluster  string                 `json:"route_to_cluster,omitempty"`
+	RouteToApp      *tlsca.RouteToApp      `json:"route_to_app,omitempty"`
+	RouteToDatabase *tlsca.RouteToDatabase `json:"route_to_database,omitempty"`
+	Expires         time.Time              `json:"expires"`
+	Valid           bool                   `json:"valid"`
+}
+
+// onAppInspect implements "tsh apps inspect". It decodes the user, app and
+// database certificates on disk for the current profile without contacting
+// the cluster.
+func onAppInspect(cf *CLIConf) error {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	key, err := tc.LocalAgent().GetKey(tc.SiteName, client.WithAllCerts...)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	userCert, err := makeCertInfo("user", "", key.TLSCert)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	certs := []certInfo{*userCert}
+	for _, name := range sortedCertNames(key.AppTLSCerts) {
+		info, err := makeCertInfo("app", name, key.AppTLSCerts[name])
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		certs = append(certs, *info)
+	}
+	for _, name := range sortedCertNames(key.DBTLSCerts) {
+		info, err := makeCertInfo("db", name, key.DBTLSCerts[name])
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		certs = append(certs, *info)
+	}
+
+	if strings.ToLower(cf.Format) == teleport.JSON {
+		out, err := json.MarshalIndent(certs, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	printCertInfo(certs)
+	return nil
+}
+
+// makeCertInfo decodes a PEM-encoded TLS certificate into the fields shown
+// by "tsh apps inspect".
+func makeCertInfo(certType, name string, certPEM []byte) (*certInfo, error) {
+	cert, err := tlsca.ParseCertificatePEM(certPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	identity, err := tlsca.FromSubject(cert.Subject, cert.NotAfter)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	info := &certInfo{
+		Type:           certType,
+		Name:           name,
+		User:           identity.Username,
+		Roles:          identity.Groups,
+		Usage:          identity.Usage,
+		RouteToCluster: identity.RouteToCluster,
+		Expires:        identity.Expires,
+		Valid:          time.Now().Before(identity.Expires),
+	}
+	if identity.RouteToApp.Name != "" {
+		route := identity.RouteToApp
+		info.RouteToApp = &route
+	}
+	if identity.RouteToDatabase.ServiceName != "" {
+		route := identity.RouteToDatabase
+		info.RouteToDatabase = &route
+	}
+	return info, nil
+}
+
+// sortedCertNames returns the keys of a certificate map in sorted order.
+func sortedCertNames(certs map[string][]byte) []string {
+	names := make([]string, 0, len(certs))
+	for name := range certs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printCertInfo prints certificate details in a human-readable form.
+func printCertInfo(certs []certInfo) {
+	for i, info := range certs {
+		if i > 0 {
+			fmt.Println()
+		}
+		header := info.Type
+		if info.Name != "" {
+			header = fmt.Sprintf("%v %q", info.Type, info.Name)
+		}
+		fmt.Printf("Certificate (%v):\n", header)
+		fmt.Printf("  User:     %v\n", info.User)
+		if len(info.Roles) > 0 {
+			fmt.Printf("  Roles:    %v\n", strings.Join(info.Roles, ", "))
+		}
+		if len(info.Usage) > 0 {
+			fmt.Printf("  Usage:    %v\n", strings.Join(info.Usage, ", "))
+		}
+		if info.RouteToCluster != "" {
+			fmt.Printf("  Cluster:  %v\n", info.RouteToCluster)
+		}
+		if info.RouteToApp != nil {
+			fmt.Printf("  App:      %v (public address %v, cluster %v)\n",
+				info.RouteToApp.Name, info.RouteToApp.PublicAddr, info.RouteToApp.ClusterName)
+		}
+		if info.RouteToDatabase != nil {
+			fmt.Printf("  Database: service %v, protocol %v, user %v, name %v\n",
+				info.RouteToDatabase.ServiceName, info.RouteToDatabase.Protocol,
+				info.RouteToDatabase.Username, info.RouteToDatabase.Database)
+		}
+		validity := "valid"
+		if !info.Valid {
+			validity = "EXPIRED"
+		}
+		fmt.Printf("  Expires:  %v (%v)\n", info.Expires.Format(time.RFC1123), validity)
+	}
+}
+
 const (
 	// appFormatURI prints app URI.
 	appFormatURI = "uri"
@@ -323,4 +584,6 @@ const (
 	appFormatJSON = "json"
 	// appFormatYAML prints app URI, CA cert path, cert path, key path, and curl command in YAML format.
 	appFormatYAML = "yaml"
+	// appFormatEnv prints app URI and cert paths as shell-exportable environment variables.
+	appFormatEnv = "env"
 )
@@ -0,0 +1,41 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gravitational/teleport/lib/client"
+)
+
+func TestAppEnvVarPrefix(t *testing.T) {
+	require.Equal(t, "TELEPORT_APP_GRAFANA_", appEnvVarPrefix("grafana"))
+	require.Equal(t, "TELEPORT_APP_MY_APP_2_", appEnvVarPrefix("my-app.2"))
+}
+
+func TestFormatAllAppsConfig(t *testing.T) {
+	// --all only makes sense with the env format.
+	_, err := formatAllAppsConfig(nil, &client.ProfileStatus{}, appFormatJSON)
+	require.True(t, trace.IsBadParameter(err))
+
+	// Not logged into any apps.
+	_, err = formatAllAppsConfig(nil, &client.ProfileStatus{}, appFormatEnv)
+	require.True(t, trace.IsNotFound(err))
+}
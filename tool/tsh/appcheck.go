@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+)
+
+// onAppCheck implements "tsh apps check". It sends an authenticated request
+// to the app through the Teleport proxy and reports the response status, the
+// observed latency and the presented certificate chain, giving a quick
+// end-to-end smoke test for a freshly registered application.
+func onAppCheck(cf *CLIConf) error {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	app, err := pickActiveApp(cf)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(profile.AppCertPath(app.Name), profile.KeyPath())
+	if err != nil {
+		return trace.Wrap(err, "failed to load the app certificate, try 'tsh apps login %v' first", app.Name)
+	}
+	url := appCheckURL(app.PublicAddr, tc.WebProxyPort(), cf.AppCheckPath)
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates:       []tls.Certificate{cert},
+				InsecureSkipVerify: cf.InsecureSkipVerify,
+			},
+		},
+		Timeout: apidefaults.DefaultDialTimeout,
+	}
+
+	fmt.Printf("Checking %v...\n\n", url)
+	req, err := http.NewRequestWithContext(cf.Context, http.MethodGet, url, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return trace.Wrap(err, "request to %v failed", url)
+	}
+	defer resp.Body.Close()
+	// Read (and discard) the body so the latency covers the full response.
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024)); err != nil {
+		return trace.Wrap(err)
+	}
+	latency := time.Since(start)
+
+	fmt.Printf("Status:  %v\n", resp.Status)
+	fmt.Printf("Latency: %v\n", latency.Round(time.Millisecond))
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		fmt.Printf("Certificate chain:\n%v", formatPeerCertificates(resp.TLS.PeerCertificates))
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		fmt.Fprintf(os.Stderr, "\nApp %q responded with %v.\n", app.Name, resp.Status)
+		return trace.Wrap(&exitCodeError{code: 1})
+	}
+	return nil
+}
+
+// appCheckURL builds the URL probed by "tsh apps check". The standard HTTPS
+// port is left implicit, matching the URIs printed by "tsh apps config".
+func appCheckURL(publicAddr string, webPort int, path string) string {
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if webPort == teleport.StandardHTTPSPort {
+		return fmt.Sprintf("https://%v%v", publicAddr, path)
+	}
+	return fmt.Sprintf("https://%v:%v%v", publicAddr, webPort, path)
+}
+
+// formatPeerCertificates renders the certificate chain presented by the
+// server, one line per certificate.
+func formatPeerCertificates(certs []*x509.Certificate) string {
+	var sb strings.Builder
+	for i, cert := range certs {
+		fmt.Fprintf(&sb, "  %v: subject %q issued by %q, expires %v\n",
+			i, cert.Subject.CommonName, cert.Issuer.CommonName,
+			cert.NotAfter.Format(time.RFC3339))
+	}
+	return sb.String()
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppCheckURL(t *testing.T) {
+	require.Equal(t, "https://grafana.example.com:3080/", appCheckURL("grafana.example.com", 3080, ""))
+	require.Equal(t, "https://grafana.example.com:3080/api/health", appCheckURL("grafana.example.com", 3080, "api/health"))
+	require.Equal(t, "https://grafana.example.com/healthz", appCheckURL("grafana.example.com", 443, "/healthz"))
+}
+
+func TestFormatPeerCertificates(t *testing.T) {
+	expiry := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+	out := formatPeerCertificates([]*x509.Certificate{
+		{
+			Subject:  pkix.Name{CommonName: "grafana.example.com"},
+			Issuer:   pkix.Name{CommonName: "example.com"},
+			NotAfter: expiry,
+		},
+		{
+			Subject:  pkix.Name{CommonName: "example.com"},
+			Issuer:   pkix.Name{CommonName: "example.com"},
+			NotAfter: expiry,
+		},
+	})
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `0: subject "grafana.example.com" issued by "example.com"`)
+	require.Contains(t, lines[0], "2022-09-01T00:00:00Z")
+	require.Contains(t, lines[1], `1: subject "example.com"`)
+}
@@ -23,6 +23,7 @@ import (
 	"crypto/x509/pkix"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
@@ -30,7 +31,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/google/uuid"
 	"github.com/gravitational/trace"
 
@@ -99,14 +104,34 @@ func onAWS(cf *CLIConf) error {
 		Scheme: "https",
 	}
 
-	endpointFlag := fmt.Sprintf("--endpoint-url=%s", url.String())
-	bundleFlag := fmt.Sprintf("--ca-bundle=%s", tmpCert.getCAPath())
+	// The "presign" subcommand is handled by tsh itself instead of being
+	// forwarded to the AWS CLI.
+	if len(cf.AWSCommandArgs) > 0 && cf.AWSCommandArgs[0] == "presign" {
+		return trace.Wrap(onAWSPresign(cf, url.String(), tmpCert.getCAPath()))
+	}
 
 	args := append([]string{}, cf.AWSCommandArgs...)
-	args = append(args, endpointFlag)
-	args = append(args, bundleFlag)
 	cmd := exec.Command(awsCLIBinaryName, args...)
+	if cf.AWSAssumeRoleChain != "" {
+		// Resolve the role chain to temporary credentials and let the AWS
+		// CLI talk to AWS directly with them, there is nothing left for the
+		// signing proxy to add.
+		chainedCreds, err := assumeRoleChain(cf, url.String(), tmpCert.getCAPath())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		env, err := awsCredentialsEnv(chainedCreds)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		cmd.Env = append(os.Environ(), env...)
+	} else {
+		cmd.Args = append(cmd.Args,
+			fmt.Sprintf("--endpoint-url=%s", url.String()),
+			fmt.Sprintf("--ca-bundle=%s", tmpCert.getCAPath()))
+	}
 
+	auditHelperCommand(cmd)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
@@ -116,6 +141,161 @@ func onAWS(cf *CLIConf) error {
 	return nil
 }
 
+// onAWSPresign implements "tsh aws presign" generating an S3 presigned URL
+// for the given s3://bucket/key object. With --assume-role-chain the URL is
+// signed with the chained temporary credentials and can be used anywhere,
+// otherwise it points at the local signing proxy and is only valid while
+// this tsh process keeps running.
+func onAWSPresign(cf *CLIConf, proxyURL, caPath string) error {
+	args := cf.AWSCommandArgs[1:]
+	if len(args) < 1 || len(args) > 2 {
+		return trace.BadParameter("usage: tsh aws presign s3://bucket/key [duration]")
+	}
+	bucket, key, err := parseS3URL(args[0])
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	expires := time.Hour
+	if len(args) == 2 {
+		expires, err = time.ParseDuration(args[1])
+		if err != nil {
+			return trace.Wrap(err, "invalid expiration duration %q", args[1])
+		}
+	}
+
+	config := aws.NewConfig().WithRegion(awsRegionFromEnv())
+	if cf.AWSAssumeRoleChain != "" {
+		chainedCreds, err := assumeRoleChain(cf, proxyURL, caPath)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		config = config.WithCredentials(chainedCreds)
+	} else {
+		httpClient, err := proxyHTTPClient(caPath)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		config = config.WithEndpoint(proxyURL).WithS3ForcePathStyle(true).WithHTTPClient(httpClient)
+		fmt.Fprintln(os.Stderr, "The presigned URL points at the local proxy and is only valid while this tsh process keeps running.")
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	request, _ := s3.New(sess).GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	signedURL, err := request.Presign(expires)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Println(signedURL)
+	return nil
+}
+
+// parseS3URL splits an s3://bucket/key URL into its bucket and key parts.
+func parseS3URL(s3URL string) (bucket, key string, err error) {
+	rest := strings.TrimPrefix(s3URL, "s3://")
+	if rest == s3URL {
+		return "", "", trace.BadParameter("%q is not an s3:// URL", s3URL)
+	}
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", trace.BadParameter("%q must specify both a bucket and an object key", s3URL)
+	}
+	return parts[0], parts[1], nil
+}
+
+// assumeRoleChain assumes each role ARN from --assume-role-chain in sequence
+// and returns the temporary credentials of the last role. The first hop goes
+// through the local signing proxy which authenticates the app session, the
+// following hops talk to STS directly with the temporary credentials of the
+// previous hop.
+func assumeRoleChain(cf *CLIConf, proxyURL, caPath string) (*credentials.Credentials, error) {
+	httpClient, err := proxyHTTPClient(caPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	region := awsRegionFromEnv()
+
+	var creds *credentials.Credentials
+	for i, roleARN := range strings.Split(cf.AWSAssumeRoleChain, ",") {
+		roleARN = strings.TrimSpace(roleARN)
+		if !awsarn.IsARN(roleARN) {
+			return nil, trace.BadParameter("invalid role ARN %q in --assume-role-chain", roleARN)
+		}
+
+		config := aws.NewConfig().WithRegion(region)
+		if i == 0 {
+			config = config.WithEndpoint(proxyURL).WithHTTPClient(httpClient)
+		} else {
+			config = config.WithCredentials(creds)
+		}
+		sess, err := session.NewSession(config)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+
+		out, err := sts.New(sess).AssumeRoleWithContext(cf.Context, &sts.AssumeRoleInput{
+			RoleArn:         aws.String(roleARN),
+			RoleSessionName: aws.String("teleport-tsh"),
+		})
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to assume role %q", roleARN)
+		}
+		creds = credentials.NewStaticCredentials(
+			aws.StringValue(out.Credentials.AccessKeyId),
+			aws.StringValue(out.Credentials.SecretAccessKey),
+			aws.StringValue(out.Credentials.SessionToken))
+	}
+	return creds, nil
+}
+
+// proxyHTTPClient returns an HTTP client trusting the self-signed CA of the
+// local signing proxy.
+func proxyHTTPClient(caPath string) (*http.Client, error) {
+	caPEM, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, trace.BadParameter("failed to parse local proxy CA certificate")
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}, nil
+}
+
+// awsRegionFromEnv returns the AWS region configured in the environment,
+// falling back to us-east-1 like the AWS CLI does.
+func awsRegionFromEnv() string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+	return "us-east-1"
+}
+
+// awsCredentialsEnv renders credentials as environment variables understood
+// by the AWS CLI.
+func awsCredentialsEnv(creds *credentials.Credentials) ([]string, error) {
+	value, err := creds.Get()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []string{
+		"AWS_ACCESS_KEY_ID=" + value.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + value.SecretAccessKey,
+		"AWS_SESSION_TOKEN=" + value.SessionToken,
+	}, nil
+}
+
 // genAndSetAWSCredentials generates and returns fake AWS credential that are used
 // for signing an AWS request during aws CLI call and verified on local AWS proxy side.
 func genAndSetAWSCredentials() (*credentials.Credentials, error) {
@@ -0,0 +1,37 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseS3URL(t *testing.T) {
+	bucket, key, err := parseS3URL("s3://my-bucket/path/to/object.txt")
+	require.NoError(t, err)
+	require.Equal(t, "my-bucket", bucket)
+	require.Equal(t, "path/to/object.txt", key)
+
+	_, _, err = parseS3URL("https://my-bucket/object.txt")
+	require.Error(t, err)
+	_, _, err = parseS3URL("s3://my-bucket")
+	require.Error(t, err)
+	_, _, err = parseS3URL("s3://my-bucket/")
+	require.Error(t, err)
+}
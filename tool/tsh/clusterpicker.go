@@ -0,0 +1,137 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/utils/prompt"
+
+	"github.com/gravitational/trace"
+	"github.com/moby/term"
+)
+
+// maybeSelectCluster presents an interactive cluster picker after a fresh
+// login when the account has access to leaf clusters and no --cluster flag
+// was given, so new users don't unknowingly stay connected to the wrong
+// default cluster. It is skipped for non-interactive sessions.
+func maybeSelectCluster(cf *CLIConf, tc *client.TeleportClient) error {
+	if cf.SiteName != "" || !term.IsTerminal(os.Stdin.Fd()) {
+		return nil
+	}
+
+	var rootClusterName string
+	var leafClusters []types.RemoteCluster
+	err := client.RetryWithRelogin(cf.Context, tc, func() error {
+		proxyClient, err := tc.ConnectToProxy(cf.Context)
+		if err != nil {
+			return err
+		}
+		defer proxyClient.Close()
+
+		var rootErr, leafErr error
+		rootClusterName, rootErr = proxyClient.RootClusterName()
+		leafClusters, leafErr = proxyClient.GetLeafClusters(cf.Context)
+		return trace.NewAggregate(rootErr, leafErr)
+	})
+	if err != nil {
+		// The picker is a convenience; a failure to enumerate leaf clusters
+		// should not fail an otherwise successful login.
+		log.WithError(err).Debug("Failed to list leaf clusters for the cluster picker.")
+		return nil
+	}
+	if len(leafClusters) == 0 {
+		return nil
+	}
+
+	current := tc.SiteName
+	if current == "" {
+		current = rootClusterName
+	}
+	clusterNames := []string{rootClusterName}
+	for _, leaf := range leafClusters {
+		clusterNames = append(clusterNames, leaf.GetName())
+	}
+
+	fmt.Println("\nYou have access to multiple clusters:")
+	for i, name := range clusterNames {
+		mark := ""
+		if name == current {
+			mark = " (current)"
+		}
+		fmt.Printf("  %v) %v%v\n", i+1, name, mark)
+	}
+
+	var selected string
+	for {
+		answer, err := prompt.Input(cf.Context, os.Stdout, prompt.Stdin(), "Select a cluster (name or number, empty to keep current)")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if answer == "" {
+			return nil
+		}
+		selected, err = matchClusterName(clusterNames, answer)
+		if err == nil {
+			break
+		}
+		fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
+	}
+	if selected == current {
+		return nil
+	}
+
+	// Switching clusters is the same operation as 'tsh login --cluster': the
+	// user certificate is reissued routed to the selected cluster.
+	tc.SiteName = selected
+	if err := tc.ReissueUserCerts(cf.Context, client.CertCacheKeep, client.ReissueParams{
+		RouteToCluster: selected,
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := tc.SaveProfile(cf.HomePath, true); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := updateKubeConfig(cf, tc, ""); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// matchClusterName resolves a picker answer to a cluster name, accepting
+// either an exact name or a 1-based number from the printed list.
+func matchClusterName(clusterNames []string, answer string) (string, error) {
+	answer = strings.TrimSpace(answer)
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(clusterNames) {
+			return "", trace.BadParameter("cluster number %v is out of range", n)
+		}
+		return clusterNames[n-1], nil
+	}
+	for _, name := range clusterNames {
+		if name == answer {
+			return name, nil
+		}
+	}
+	return "", trace.BadParameter("no cluster matches %q", answer)
+}
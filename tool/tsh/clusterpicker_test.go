@@ -0,0 +1,48 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchClusterName(t *testing.T) {
+	clusters := []string{"root", "leaf-one", "leaf-two"}
+
+	name, err := matchClusterName(clusters, "leaf-one")
+	require.NoError(t, err)
+	require.Equal(t, "leaf-one", name)
+
+	name, err = matchClusterName(clusters, "3")
+	require.NoError(t, err)
+	require.Equal(t, "leaf-two", name)
+
+	name, err = matchClusterName(clusters, " 1 ")
+	require.NoError(t, err)
+	require.Equal(t, "root", name)
+
+	_, err = matchClusterName(clusters, "0")
+	require.Error(t, err)
+
+	_, err = matchClusterName(clusters, "4")
+	require.Error(t, err)
+
+	_, err = matchClusterName(clusters, "nonexistent")
+	require.Error(t, err)
+}
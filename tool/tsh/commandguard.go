@@ -0,0 +1,115 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/utils/prompt"
+
+	"github.com/gravitational/trace"
+	"github.com/moby/term"
+)
+
+// defaultGuardPatterns are the built-in regular expressions that flag a
+// remote command as destructive. They intentionally target commands that
+// are almost never intended against production hosts rather than trying to
+// catch every way to lose data; additional patterns can be configured via
+// the command_guard section of the tsh config file.
+var defaultGuardPatterns = []string{
+	// rm with recursive and force flags aimed at the filesystem root.
+	`\brm\s+(-\S+\s+)*-\S*(rf|fr)\S*\s+(-\S+\s+)*/\*?(\s|$)`,
+	`\brm\s+.*--recursive\b.*\s+/\*?(\s|$)`,
+	// Creating a filesystem wipes the target device.
+	`\bmkfs(\.\S+)?\b`,
+	// Raw writes to block devices.
+	`\bdd\s+.*\bof=/dev/`,
+	// Destructive SQL statements.
+	`(?i)\bdrop\s+(database|table|schema)\b`,
+	`(?i)\btruncate\s+table\b`,
+}
+
+// compileGuardPatterns compiles the built-in dangerous command patterns
+// plus any extra patterns from the tsh config file.
+func compileGuardPatterns(extra []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(defaultGuardPatterns)+len(extra))
+	for _, pattern := range defaultGuardPatterns {
+		patterns = append(patterns, regexp.MustCompile(pattern))
+	}
+	for _, pattern := range extra {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, trace.BadParameter("invalid command_guard pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// matchDangerousCommand returns the pattern that matched the command, or an
+// empty string when the command matches no dangerous pattern.
+func matchDangerousCommand(patterns []*regexp.Regexp, command string) string {
+	for _, pattern := range patterns {
+		if pattern.MatchString(command) {
+			return pattern.String()
+		}
+	}
+	return ""
+}
+
+// confirmDangerousCommand asks for confirmation before running a remote
+// command that matches a dangerous pattern. Interactive runs prompt on the
+// terminal; non-interactive runs fail so scripts can't destroy a host by
+// accident. Both are bypassed by --no-confirm or by disabling the guard in
+// the tsh config file.
+func confirmDangerousCommand(cf *CLIConf, command []string) error {
+	if len(command) == 0 || cf.NoConfirm {
+		return nil
+	}
+	guard := cf.CommandGuard
+	if guard != nil && guard.Disabled {
+		return nil
+	}
+	var extra []string
+	if guard != nil {
+		extra = guard.Patterns
+	}
+	patterns, err := compileGuardPatterns(extra)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	commandLine := strings.Join(command, " ")
+	pattern := matchDangerousCommand(patterns, commandLine)
+	if pattern == "" {
+		return nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return trace.BadParameter("remote command matches the dangerous pattern %q, re-run with --no-confirm to run it anyway", pattern)
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: the remote command matches the dangerous pattern %q:\n\n    %v\n\n", pattern, commandLine)
+	answer, err := prompt.Input(cf.Context, os.Stderr, prompt.Stdin(), "Type 'yes' to run the command anyway")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if strings.ToLower(strings.TrimSpace(answer)) != "yes" {
+		return trace.BadParameter("command aborted")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchDangerousCommand(t *testing.T) {
+	patterns, err := compileGuardPatterns(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		command   string
+		dangerous bool
+	}{
+		{command: "rm -rf /", dangerous: true},
+		{command: "rm -rf /*", dangerous: true},
+		{command: "sudo rm -fr /", dangerous: true},
+		{command: "rm -rf /tmp/build", dangerous: false},
+		{command: "rm file.txt", dangerous: false},
+		{command: "mkfs.ext4 /dev/sda1", dangerous: true},
+		{command: "dd if=/dev/zero of=/dev/sda", dangerous: true},
+		{command: "dd if=/dev/sda of=backup.img", dangerous: false},
+		{command: "psql -c 'DROP DATABASE prod'", dangerous: true},
+		{command: "mysql -e 'drop table users'", dangerous: true},
+		{command: "ls -la /", dangerous: false},
+		{command: "uptime", dangerous: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			match := matchDangerousCommand(patterns, tt.command)
+			if tt.dangerous {
+				require.NotEmpty(t, match, "expected %q to match a dangerous pattern", tt.command)
+			} else {
+				require.Empty(t, match, "expected %q to match no dangerous pattern, matched %q", tt.command, match)
+			}
+		})
+	}
+}
+
+func TestCompileGuardPatterns(t *testing.T) {
+	// Extra patterns from the config file are appended and matched.
+	patterns, err := compileGuardPatterns([]string{`\bshutdown\b`})
+	require.NoError(t, err)
+	require.NotEmpty(t, matchDangerousCommand(patterns, "shutdown -h now"))
+
+	// Invalid extra patterns are rejected.
+	_, err = compileGuardPatterns([]string{`(`})
+	require.True(t, trace.IsBadParameter(err))
+}
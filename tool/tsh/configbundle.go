@@ -0,0 +1,150 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/teleport/api/profile"
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// configBundleKind identifies an exported tsh configuration bundle.
+	configBundleKind = "tsh_config_bundle"
+	// configBundleVersion is the current bundle format version.
+	configBundleVersion = "v1"
+)
+
+// configBundle is the shareable file written by "tsh config export" and read
+// by "tsh config import". It carries only non-secret configuration so teams
+// can distribute a standard tsh setup; keys, certificates and profiles are
+// never included.
+type configBundle struct {
+	// Kind identifies the file as a tsh configuration bundle.
+	Kind string `yaml:"kind"`
+	// Version is the bundle format version.
+	Version string `yaml:"version"`
+	// Proxy is the web address of the exporter's proxy, included as a
+	// login hint for recipients without an existing profile.
+	Proxy string `yaml:"proxy,omitempty"`
+	// Config is the tsh configuration being shared.
+	Config TshConfig `yaml:"config"`
+}
+
+// userConfigPath returns the path of the user's tsh config file.
+func userConfigPath(cf *CLIConf) string {
+	return filepath.Join(profile.FullProfilePath(cf.HomePath), tshConfigPath)
+}
+
+// onConfigExport implements "tsh config export". It bundles the user's tsh
+// configuration and the current proxy address into a YAML document written
+// to stdout or --out.
+func onConfigExport(cf *CLIConf) error {
+	conf, err := loadConfig(userConfigPath(cf))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bundle := configBundle{
+		Kind:    configBundleKind,
+		Version: configBundleVersion,
+		Config:  *conf,
+	}
+	// The proxy address is a convenience for recipients; exporting still
+	// works without an active profile.
+	if profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy); err == nil {
+		bundle.Proxy = profile.ProxyURL.Host
+	}
+	out, err := yaml.Marshal(bundle)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if cf.ConfigBundlePath == "" {
+		fmt.Print(string(out))
+		return nil
+	}
+	if err := os.WriteFile(cf.ConfigBundlePath, out, 0644); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	fmt.Printf("Configuration exported to %v.\n", cf.ConfigBundlePath)
+	return nil
+}
+
+// onConfigImport implements "tsh config import". It merges the bundled
+// configuration into the user's tsh config file; local settings win on
+// conflict unless --force is passed.
+func onConfigImport(cf *CLIConf) error {
+	data, err := os.ReadFile(cf.ConfigBundlePath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	bundle, err := parseConfigBundle(data)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	confPath := userConfigPath(cf)
+	existing, err := loadConfig(confPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	merged := mergeConfigBundle(existing, &bundle.Config, cf.ConfigImportForce)
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(confPath), 0700); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.WriteFile(confPath, out, 0600); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	fmt.Printf("Configuration imported into %v.\n", confPath)
+	if bundle.Proxy != "" {
+		fmt.Printf("To log in to the shared proxy, run:\n\n  tsh login --proxy=%v\n", bundle.Proxy)
+	}
+	return nil
+}
+
+// parseConfigBundle decodes and validates an exported configuration bundle.
+func parseConfigBundle(data []byte) (*configBundle, error) {
+	var bundle configBundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, trace.BadParameter("failed to parse configuration bundle: %v", err)
+	}
+	if bundle.Kind != configBundleKind {
+		return nil, trace.BadParameter("file is not a tsh configuration bundle, found kind %q", bundle.Kind)
+	}
+	if bundle.Version != configBundleVersion {
+		return nil, trace.BadParameter("unsupported configuration bundle version %q", bundle.Version)
+	}
+	return &bundle, nil
+}
+
+// mergeConfigBundle combines an imported configuration with the local one.
+// Local settings have priority so imports don't clobber personal tweaks;
+// with force the imported settings win instead.
+func mergeConfigBundle(local, imported *TshConfig, force bool) TshConfig {
+	if force {
+		return local.Merge(imported)
+	}
+	return imported.Merge(local)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseConfigBundle(t *testing.T) {
+	bundle := configBundle{
+		Kind:    configBundleKind,
+		Version: configBundleVersion,
+		Proxy:   "proxy.example.com:3080",
+		Config: TshConfig{
+			Telemetry: &TelemetryConfig{Enabled: true},
+		},
+	}
+	data, err := yaml.Marshal(bundle)
+	require.NoError(t, err)
+
+	parsed, err := parseConfigBundle(data)
+	require.NoError(t, err)
+	require.Equal(t, "proxy.example.com:3080", parsed.Proxy)
+	require.NotNil(t, parsed.Config.Telemetry)
+	require.True(t, parsed.Config.Telemetry.Enabled)
+
+	// Files of a different kind or version are rejected.
+	_, err = parseConfigBundle([]byte("kind: something_else\nversion: v1\n"))
+	require.True(t, trace.IsBadParameter(err))
+	_, err = parseConfigBundle([]byte("kind: tsh_config_bundle\nversion: v99\n"))
+	require.True(t, trace.IsBadParameter(err))
+	_, err = parseConfigBundle([]byte("{{not yaml"))
+	require.True(t, trace.IsBadParameter(err))
+}
+
+func TestMergeConfigBundle(t *testing.T) {
+	local := &TshConfig{
+		Telemetry: &TelemetryConfig{Enabled: false},
+	}
+	imported := &TshConfig{
+		Telemetry:  &TelemetryConfig{Enabled: true},
+		SessionEnv: &SessionEnv{Enabled: true},
+	}
+
+	// Local settings win by default, imported values fill the gaps.
+	merged := mergeConfigBundle(local, imported, false)
+	require.False(t, merged.Telemetry.Enabled)
+	require.NotNil(t, merged.SessionEnv)
+
+	// With force the imported settings override local ones.
+	merged = mergeConfigBundle(local, imported, true)
+	require.True(t, merged.Telemetry.Enabled)
+	require.NotNil(t, merged.SessionEnv)
+}
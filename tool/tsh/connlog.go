@@ -0,0 +1,97 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitational/trace"
+)
+
+// connLogIDEnv optionally carries an externally-assigned correlation ID, so
+// wrappers like Ansible can stamp their own task ID on the connection log.
+const connLogIDEnv = "TSH_CONNECTION_ID"
+
+// connLog writes structured per-connection log entries to a file. Every
+// entry carries the same correlation ID, so the stages of one connection can
+// be picked out of a file shared by hundreds of concurrent ProxyCommand
+// invocations.
+type connLog struct {
+	f      *os.File
+	connID string
+	target string
+}
+
+// connLogEntry is a single stage record in the connection log.
+type connLogEntry struct {
+	Time       string `json:"time"`
+	ConnID     string `json:"conn_id"`
+	Target     string `json:"target"`
+	Stage      string `json:"stage"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// newConnLog opens the log file for appending. Entries are written as single
+// lines so concurrent invocations can share one file.
+func newConnLog(path, target string) (*connLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	connID := os.Getenv(connLogIDEnv)
+	if connID == "" {
+		connID = uuid.New().String()
+	}
+	return &connLog{f: f, connID: connID, target: target}, nil
+}
+
+// stage records a completed stage with its duration and error, if any.
+func (c *connLog) stage(name string, start time.Time, stageErr error) {
+	if c == nil {
+		return
+	}
+	entry := connLogEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		ConnID:     c.connID,
+		Target:     c.target,
+		Stage:      name,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if stageErr != nil {
+		entry.Error = stageErr.Error()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.WithError(err).Debug("Failed to marshal connection log entry.")
+		return
+	}
+	if _, err := c.f.Write(append(data, '\n')); err != nil {
+		log.WithError(err).Debug("Failed to write connection log entry.")
+	}
+}
+
+// Close closes the underlying log file.
+func (c *connLog) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.f.Close()
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conn.log")
+
+	clog, err := newConnLog(path, "node-1:3022")
+	require.NoError(t, err)
+	clog.stage("dns", time.Now(), nil)
+	clog.stage("tunnel_dial", time.Now(), trace.ConnectionProblem(nil, "connection refused"))
+	require.NoError(t, clog.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := splitLines(string(data))
+	require.Len(t, lines, 2)
+
+	var first, second connLogEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	require.Equal(t, "dns", first.Stage)
+	require.Equal(t, "node-1:3022", first.Target)
+	require.Empty(t, first.Error)
+	require.Equal(t, "tunnel_dial", second.Stage)
+	require.Contains(t, second.Error, "connection refused")
+	// All entries of one invocation share the correlation ID.
+	require.Equal(t, first.ConnID, second.ConnID)
+
+	// A nil connection log drops entries instead of panicking.
+	var disabled *connLog
+	disabled.stage("dns", time.Now(), nil)
+	require.NoError(t, disabled.Close())
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/api/profile"
+
+	"github.com/gravitational/trace"
+)
+
+// crashDirName is the directory under the tsh profile directory where crash
+// reports are stored.
+const crashDirName = "crashes"
+
+// sensitiveFlagPatterns are substrings of flag names whose values are
+// redacted in crash reports.
+var sensitiveFlagPatterns = []string{"token", "password", "secret"}
+
+// writeCrashReport writes a crash report with the panic value, stack trace,
+// version information and sanitized command line arguments to the crashes
+// directory under the tsh profile directory. It returns the report path.
+func writeCrashReport(homePath string, reason interface{}, args []string) (string, error) {
+	crashDir := filepath.Join(profile.FullProfilePath(homePath), crashDirName)
+	if err := os.MkdirAll(crashDir, 0700); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Time: %v\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Version: %v git:%v %v\n", teleport.Version, teleport.Gitref, runtime.Version())
+	fmt.Fprintf(&buf, "OS/Arch: %v/%v\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&buf, "Arguments: %v\n", strings.Join(sanitizeArgs(args), " "))
+	fmt.Fprintf(&buf, "Panic: %v\n\n", reason)
+	buf.Write(debug.Stack())
+
+	path := filepath.Join(crashDir, fmt.Sprintf("crash_%v.txt", time.Now().Format("2006-01-02_15-04-05")))
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return path, nil
+}
+
+// sanitizeArgs redacts the values of sensitive flags so crash reports can be
+// shared without leaking credentials.
+func sanitizeArgs(args []string) []string {
+	sanitized := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext {
+			sanitized[i] = "<redacted>"
+			redactNext = false
+			continue
+		}
+		sanitized[i] = arg
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		if idx := strings.Index(name, "="); idx >= 0 {
+			name, value = name[:idx], name[idx+1:]
+		}
+		if !isSensitiveFlag(name) {
+			continue
+		}
+		if value != "" {
+			sanitized[i] = arg[:len(arg)-len(value)] + "<redacted>"
+		} else {
+			redactNext = true
+		}
+	}
+	return sanitized
+}
+
+// isSensitiveFlag checks if the flag name looks like it carries a secret.
+func isSensitiveFlag(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range sensitiveFlagPatterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
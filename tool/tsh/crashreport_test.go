@@ -0,0 +1,73 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		out  []string
+	}{
+		{
+			name: "no sensitive flags",
+			args: []string{"ssh", "--proxy=proxy.example.com", "root@node"},
+			out:  []string{"ssh", "--proxy=proxy.example.com", "root@node"},
+		},
+		{
+			name: "sensitive flag with equals sign",
+			args: []string{"login", "--token=s3cr3t"},
+			out:  []string{"login", "--token=<redacted>"},
+		},
+		{
+			name: "sensitive flag with separate value",
+			args: []string{"login", "--token", "s3cr3t"},
+			out:  []string{"login", "--token", "<redacted>"},
+		},
+		{
+			name: "substring match",
+			args: []string{"join", "--join-token=abc", "--password", "hunter2"},
+			out:  []string{"join", "--join-token=<redacted>", "--password", "<redacted>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.out, sanitizeArgs(tt.args))
+		})
+	}
+}
+
+func TestWriteCrashReport(t *testing.T) {
+	home := t.TempDir()
+
+	path, err := writeCrashReport(home, "boom", []string{"ssh", "--token=abc", "node"})
+	require.NoError(t, err)
+
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report := string(out)
+	require.Contains(t, report, "Panic: boom")
+	require.Contains(t, report, "--token=<redacted>")
+	require.False(t, strings.Contains(report, "abc"))
+}
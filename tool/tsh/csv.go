@@ -0,0 +1,196 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/asciitable"
+
+	"github.com/gravitational/trace"
+)
+
+// labelColumnPrefix selects a single resource label, e.g. "labels.env".
+const labelColumnPrefix = "labels."
+
+// Default column sets used for CSV output when --columns was not provided.
+var (
+	defaultNodeColumns     = []string{"hostname", "id", "addr", "labels"}
+	defaultAppColumns      = []string{"name", "description", "public_addr", "uri", "labels"}
+	defaultDatabaseColumns = []string{"name", "description", "protocol", "type", "uri", "labels"}
+)
+
+// parseColumns splits a --columns flag value into individual column
+// selectors, dropping empty entries.
+func parseColumns(spec string) []string {
+	var columns []string
+	for _, column := range strings.Split(spec, ",") {
+		column = strings.TrimSpace(column)
+		if column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// checkColumnsFormat makes sure --columns is only combined with formats
+// that render tabular output.
+func checkColumnsFormat(format, columns string) error {
+	if columns == "" {
+		return nil
+	}
+	switch strings.ToLower(format) {
+	case teleport.Text, teleport.CSV, "":
+		return nil
+	}
+	return trace.BadParameter("--columns can only be used with %q or %q output", teleport.Text, teleport.CSV)
+}
+
+// nodeColumnValue returns the value of a single node column.
+func nodeColumnValue(node types.Server, column string) (string, error) {
+	if label := strings.TrimPrefix(column, labelColumnPrefix); label != column {
+		return node.GetAllLabels()[label], nil
+	}
+	switch column {
+	case "hostname":
+		return node.GetHostname(), nil
+	case "id":
+		return node.GetName(), nil
+	case "addr":
+		return node.GetAddr(), nil
+	case "labels":
+		return node.LabelsString(), nil
+	}
+	return "", trace.BadParameter("unsupported node column %q", column)
+}
+
+// appColumnValue returns the value of a single application column.
+func appColumnValue(app types.Application, column string) (string, error) {
+	if label := strings.TrimPrefix(column, labelColumnPrefix); label != column {
+		return app.GetAllLabels()[label], nil
+	}
+	switch column {
+	case "name":
+		return app.GetName(), nil
+	case "description":
+		return app.GetDescription(), nil
+	case "public_addr":
+		return app.GetPublicAddr(), nil
+	case "uri":
+		return app.GetURI(), nil
+	case "labels":
+		return app.LabelsString(), nil
+	}
+	return "", trace.BadParameter("unsupported app column %q", column)
+}
+
+// databaseColumnValue returns the value of a single database column.
+func databaseColumnValue(database types.Database, column string) (string, error) {
+	if label := strings.TrimPrefix(column, labelColumnPrefix); label != column {
+		return database.GetAllLabels()[label], nil
+	}
+	switch column {
+	case "name":
+		return database.GetName(), nil
+	case "description":
+		return database.GetDescription(), nil
+	case "protocol":
+		return database.GetProtocol(), nil
+	case "type":
+		return database.GetType(), nil
+	case "uri":
+		return database.GetURI(), nil
+	case "labels":
+		return database.LabelsString(), nil
+	}
+	return "", trace.BadParameter("unsupported database column %q", column)
+}
+
+// nodeRows resolves the given column selectors against each node.
+func nodeRows(nodes []types.Server, columns []string) ([][]string, error) {
+	rows := make([][]string, 0, len(nodes))
+	for _, node := range nodes {
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			value, err := nodeColumnValue(node, column)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// appRows resolves the given column selectors against each application.
+func appRows(apps []types.Application, columns []string) ([][]string, error) {
+	rows := make([][]string, 0, len(apps))
+	for _, app := range apps {
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			value, err := appColumnValue(app, column)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// databaseRows resolves the given column selectors against each database.
+func databaseRows(databases []types.Database, columns []string) ([][]string, error) {
+	rows := make([][]string, 0, len(databases))
+	for _, database := range databases {
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			value, err := databaseColumnValue(database, column)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// writeCSV prints a CSV document with the given header and rows to w.
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(out.WriteAll(rows))
+}
+
+// printRowsAsText prints selected columns as a text table, used when
+// --columns is combined with the default text format.
+func printRowsAsText(columns []string, rows [][]string) {
+	t := asciitable.MakeTable(columns)
+	for _, row := range rows {
+		t.AddRow(row)
+	}
+	fmt.Println(t.AsBuffer().String())
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gravitational/teleport/api/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColumns(t *testing.T) {
+	require.Nil(t, parseColumns(""))
+	require.Equal(t, []string{"hostname", "addr", "labels.env"},
+		parseColumns("hostname, addr,labels.env,"))
+}
+
+func TestNodesAsCSV(t *testing.T) {
+	node, err := types.NewServerWithLabels("node-id", types.KindNode, types.ServerSpecV2{
+		Hostname: "node-one",
+		Addr:     "1.2.3.4:3022",
+	}, map[string]string{"env": "prod"})
+	require.NoError(t, err)
+
+	columns := parseColumns("hostname,addr,labels.env")
+	rows, err := nodeRows([]types.Server{node}, columns)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeCSV(&buf, columns, rows))
+	require.Equal(t, "hostname,addr,labels.env\nnode-one,1.2.3.4:3022,prod\n", buf.String())
+
+	_, err = nodeRows([]types.Server{node}, []string{"bogus"})
+	require.Error(t, err)
+}
@@ -17,16 +17,21 @@ limitations under the License.
 package main
 
 import (
+	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"net"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
+	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/client/proto"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
 	"github.com/gravitational/teleport/api/types"
+	"github.com/gravitational/teleport/lib/asciitable"
 	"github.com/gravitational/teleport/lib/client"
 	dbprofile "github.com/gravitational/teleport/lib/client/db"
 	"github.com/gravitational/teleport/lib/client/db/dbcmd"
@@ -44,69 +49,81 @@ func onListDatabases(cf *CLIConf) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	var databases []types.Database
-	err = client.RetryWithRelogin(cf.Context, tc, func() error {
-		databases, err = tc.ListDatabases(cf.Context, nil /* custom filter */)
-		return trace.Wrap(err)
-	})
-	if err != nil {
-		if utils.IsPredicateError(err) {
-			return trace.Wrap(utils.PredicateError{Err: err})
-		}
-		return trace.Wrap(err)
+	// Sweep expired credentials first so the listing doesn't mark databases
+	// the user can no longer connect to as active.
+	if err := cleanupExpiredDatabaseCredentials(tc); err != nil {
+		log.WithError(err).Debug("Failed to clean up expired database credentials.")
 	}
+	list := func() error {
+		var databases []types.Database
+		err = client.RetryWithRelogin(cf.Context, tc, func() error {
+			databases, err = tc.ListDatabases(cf.Context, nil /* custom filter */)
+			return trace.Wrap(err)
+		})
+		if err != nil {
+			if utils.IsPredicateError(err) {
+				return trace.Wrap(utils.PredicateError{Err: err})
+			}
+			return trace.Wrap(err)
+		}
 
-	proxy, err := tc.ConnectToProxy(cf.Context)
-	if err != nil {
-		return trace.Wrap(err)
-	}
+		proxy, err := tc.ConnectToProxy(cf.Context)
+		if err != nil {
+			return trace.Wrap(err)
+		}
 
-	cluster, err := proxy.ConnectToCurrentCluster(cf.Context, false)
-	if err != nil {
-		return trace.Wrap(err)
-	}
-	defer cluster.Close()
+		cluster, err := proxy.ConnectToCurrentCluster(cf.Context, false)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer cluster.Close()
 
-	// Retrieve profile to be able to show which databases user is logged into.
-	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
-	if err != nil {
-		return trace.Wrap(err)
-	}
+		// Retrieve profile to be able to show which databases user is logged into.
+		profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+		if err != nil {
+			return trace.Wrap(err)
+		}
 
-	// get roles and traits. default to the set from profile, try to get up-to-date version from server point of view.
-	roles := profile.Roles
-	traits := profile.Traits
+		// get roles and traits. default to the set from profile, try to get up-to-date version from server point of view.
+		roles := profile.Roles
+		traits := profile.Traits
+
+		// GetCurrentUser() may not be implemented, fail gracefully.
+		user, err := cluster.GetCurrentUser(cf.Context)
+		if err == nil {
+			roles = user.GetRoles()
+			traits = user.GetTraits()
+		} else {
+			log.Debugf("Failed to fetch current user information: %v.", err)
+		}
 
-	// GetCurrentUser() may not be implemented, fail gracefully.
-	user, err := cluster.GetCurrentUser(cf.Context)
-	if err == nil {
-		roles = user.GetRoles()
-		traits = user.GetTraits()
-	} else {
-		log.Debugf("Failed to fetch current user information: %v.", err)
-	}
+		// get the role definition for all roles of user.
+		// this may only fail if the role which we are looking for does not exist, or we don't have access to it.
+		// example scenario when this may happen:
+		// 1. we have set of roles [foo bar] from profile.
+		// 2. the cluster is remote and maps the [foo, bar] roles to single role [guest]
+		// 3. the remote cluster doesn't implement GetCurrentUser(), so we have no way to learn of [guest].
+		// 4. services.FetchRoles([foo bar], ..., ...) fails as [foo bar] does not exist on remote cluster.
+		roleSet, err := services.FetchRoles(roles, cluster, traits)
+		if err != nil {
+			log.Debugf("Failed to fetch user roles: %v.", err)
+		}
 
-	// get the role definition for all roles of user.
-	// this may only fail if the role which we are looking for does not exist, or we don't have access to it.
-	// example scenario when this may happen:
-	// 1. we have set of roles [foo bar] from profile.
-	// 2. the cluster is remote and maps the [foo, bar] roles to single role [guest]
-	// 3. the remote cluster doesn't implement GetCurrentUser(), so we have no way to learn of [guest].
-	// 4. services.FetchRoles([foo bar], ..., ...) fails as [foo bar] does not exist on remote cluster.
-	roleSet, err := services.FetchRoles(roles, cluster, traits)
-	if err != nil {
-		log.Debugf("Failed to fetch user roles: %v.", err)
-	}
+		sort.Slice(databases, func(i, j int) bool {
+			return databases[i].GetName() < databases[j].GetName()
+		})
 
-	sort.Slice(databases, func(i, j int) bool {
-		return databases[i].GetName() < databases[j].GetName()
-	})
+		activeDatabases, err := profile.DatabasesForCluster(tc.SiteName)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(showDatabases(cf.SiteName, databases, activeDatabases, roleSet, cf.Format, cf.Columns, cf.Verbose))
+	}
 
-	activeDatabases, err := profile.DatabasesForCluster(tc.SiteName)
-	if err != nil {
-		return trace.Wrap(err)
+	if cf.Watch {
+		return trace.Wrap(watchResourceListing(cf, tc, types.KindDatabaseServer, list))
 	}
-	return trace.Wrap(showDatabases(cf.SiteName, databases, activeDatabases, roleSet, cf.Format, cf.Verbose))
+	return trace.Wrap(list())
 }
 
 // onDatabaseLogin implements "tsh db login" command.
@@ -189,10 +206,18 @@ func databaseLogin(cf *CLIConf, tc *client.TeleportClient, db tlsca.RouteToDatab
 
 // onDatabaseLogout implements "tsh db logout" command.
 func onDatabaseLogout(cf *CLIConf) error {
+	if cf.DatabaseService != "" && cf.DatabaseLogoutAll {
+		return trace.BadParameter("please specify either a database name or --all")
+	}
 	tc, err := makeClient(cf, false)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// Expired credentials aren't reported as active below so remove them
+	// explicitly, otherwise they would survive the logout.
+	if err := cleanupExpiredDatabaseCredentials(tc); err != nil {
+		log.WithError(err).Debug("Failed to clean up expired database credentials.")
+	}
 	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
 	if err != nil {
 		return trace.Wrap(err)
@@ -244,6 +269,39 @@ func databaseLogout(tc *client.TeleportClient, db tlsca.RouteToDatabase) error {
 	return nil
 }
 
+// cleanupExpiredDatabaseCredentials removes expired database certificates
+// and their connection profile entries from the user profile directory so
+// dead credentials don't accumulate in ~/.tsh.
+func cleanupExpiredDatabaseCredentials(tc *client.TeleportClient) error {
+	key, err := tc.LocalAgent().GetKey(tc.SiteName, client.WithDBCerts{})
+	if err != nil {
+		if trace.IsNotFound(err) { // Not logged in, nothing to sweep.
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	for name, certPEM := range key.DBTLSCerts {
+		cert, err := tlsca.ParseCertificatePEM(certPEM)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// Use the same expiration slack as the active database detection so
+		// a certificate is never both active and swept.
+		if time.Until(cert.NotAfter) >= 5*time.Second {
+			continue
+		}
+		identity, err := tlsca.FromSubject(cert.Subject, cert.NotAfter)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		log.Debugf("Removing expired credentials for database %q.", name)
+		if err := databaseLogout(tc, identity.RouteToDatabase); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
 // onDatabaseEnv implements "tsh db env" command.
 func onDatabaseEnv(cf *CLIConf) error {
 	tc, err := makeClient(cf, false)
@@ -529,11 +587,40 @@ func onDatabaseConnect(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 	if relogin {
+		err := retryWithAccessRequest(cf, tc, func() error {
+			return trace.Wrap(databaseLogin(cf, tc, *routeToDatabase, true))
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	err = connectToDatabase(cf, tc, profile, routeToDatabase, database)
+	if err != nil {
+		// The relogin check above passes as long as the certificate is valid
+		// when it runs, but a short-lived certificate (such as one issued
+		// with per-session MFA) can expire between the check and the TLS
+		// handshake. Users don't associate the resulting handshake error
+		// with cert expiry, so detect it, reissue and retry once.
+		if !databaseCertExpired(profile, tc, routeToDatabase.ServiceName) {
+			return trace.Wrap(err)
+		}
+		log.Debug("Database certificate expired, reissuing and retrying.")
 		if err := databaseLogin(cf, tc, *routeToDatabase, true); err != nil {
 			return trace.Wrap(err)
 		}
+		profile, err = client.StatusCurrent(cf.HomePath, cf.Proxy)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(connectToDatabase(cf, tc, profile, routeToDatabase, database))
 	}
+	return nil
+}
 
+// connectToDatabase starts the local proxy when required and runs the
+// database client command for a single connection attempt.
+func connectToDatabase(cf *CLIConf, tc *client.TeleportClient, profile *client.ProfileStatus, routeToDatabase *tlsca.RouteToDatabase, database types.Database) error {
 	key, err := tc.LocalAgent().GetCoreKey()
 	if err != nil {
 		return trace.Wrap(err)
@@ -548,11 +635,20 @@ func onDatabaseConnect(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 	opts = append(opts, dbcmd.WithLogger(log))
+
+	if len(cf.DatabaseParams) > 0 {
+		params, err := parseDatabaseParams(cf.DatabaseParams, routeToDatabase.Protocol)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		opts = append(opts, dbcmd.WithStartupParameters(params))
+	}
+
 	cmd, err := dbcmd.NewCmdBuilder(tc, profile, routeToDatabase, rootClusterName, opts...).GetConnectCommand()
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	log.Debug(cmd.String())
+	auditHelperCommand(cmd)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
@@ -563,6 +659,394 @@ func onDatabaseConnect(cf *CLIConf) error {
 	return nil
 }
 
+// parseDatabaseParams parses --db-param key=value arguments into a map,
+// validating that the database protocol can carry startup parameters.
+func parseDatabaseParams(args []string, protocol string) (map[string]string, error) {
+	switch protocol {
+	case defaults.ProtocolPostgres, defaults.ProtocolCockroachDB:
+	default:
+		return nil, trace.BadParameter("--db-param is only supported for PostgreSQL databases, got protocol %v", protocol)
+	}
+	params := make(map[string]string, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, trace.BadParameter("--db-param expects key=value, got %q", arg)
+		}
+		params[parts[0]] = parts[1]
+	}
+	return params, nil
+}
+
+// databaseCertExpired reports whether the database certificate for the given
+// service has expired. Errors reading the certificate are treated as not
+// expired so the original connection error is surfaced instead.
+func databaseCertExpired(profile *client.ProfileStatus, tc *client.TeleportClient, serviceName string) bool {
+	buff, err := os.ReadFile(profile.DatabaseCertPathForCluster(tc.SiteName, serviceName))
+	if err != nil {
+		return false
+	}
+	cert, err := tlsca.ParseCertificatePEM(buff)
+	if err != nil {
+		return false
+	}
+	return time.Now().After(cert.NotAfter)
+}
+
+// postgresSSLRequest is a Postgres SSLRequest startup packet. Any answer
+// confirms a Postgres speaker on the other end of the connection.
+var postgresSSLRequest = []byte{0x0, 0x0, 0x0, 0x8, 0x4, 0xd2, 0x16, 0x2f}
+
+// onDatabasePing executes the 'tsh db ping' command. It exercises the full
+// connection path hop by hop (proxy, database agent, database) and reports
+// per-hop timing to pinpoint where connectivity breaks.
+func onDatabasePing(cf *CLIConf) error {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	routeToDatabase, _, err := getDatabaseInfo(cf, tc, cf.DatabaseService)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	relogin, err := needRelogin(cf, tc, routeToDatabase, profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if relogin {
+		if err := databaseLogin(cf, tc, *routeToDatabase, true); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	alpnProtocol, err := common.ToALPNProtocol(routeToDatabase.Protocol)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	address, err := utils.ParseAddr(tc.WebProxyAddr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cert, err := tls.LoadX509KeyPair(
+		profile.DatabaseCertPathForCluster(tc.SiteName, routeToDatabase.ServiceName),
+		profile.KeyPath(),
+	)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	t := asciitable.MakeTable([]string{"Hop", "Latency"})
+	printHops := func() {
+		fmt.Println(t.AsBuffer().String())
+	}
+
+	// Hop 1: TCP connection to the Teleport proxy.
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", tc.WebProxyAddr, apidefaults.DefaultDialTimeout)
+	if err != nil {
+		return trace.Wrap(err, "failed to reach Teleport proxy at %v", tc.WebProxyAddr)
+	}
+	defer conn.Close()
+	t.AddRow([]string{"proxy (tcp)", time.Since(start).Round(time.Millisecond).String()})
+
+	// Hop 2: TLS handshake with the proxy. ALPN routes the connection to
+	// the agent proxying the target database.
+	tlsConn := tls.Client(conn, &tls.Config{
+		NextProtos:         []string{string(alpnProtocol)},
+		ServerName:         address.Host(),
+		InsecureSkipVerify: cf.InsecureSkipVerify,
+		Certificates:       []tls.Certificate{cert},
+	})
+	if err := tlsConn.HandshakeContext(cf.Context); err != nil {
+		printHops()
+		return trace.Wrap(err, "TLS handshake with Teleport proxy failed, check the database certificate with 'tsh db login %v'", routeToDatabase.ServiceName)
+	}
+	t.AddRow([]string{"proxy (tls routing)", time.Since(start).Round(time.Millisecond).String()})
+
+	// Hop 3: a trivial protocol-level exchange that has to traverse the
+	// database agent and the database itself.
+	checked := true
+	tlsConn.SetDeadline(time.Now().Add(apidefaults.DefaultDialTimeout))
+	switch routeToDatabase.Protocol {
+	case defaults.ProtocolPostgres, defaults.ProtocolCockroachDB:
+		// Any answer to an SSLRequest confirms a Postgres speaker on the
+		// other end.
+		if _, err := tlsConn.Write(postgresSSLRequest); err != nil {
+			printHops()
+			return trace.Wrap(err, "failed to send Postgres SSLRequest through the tunnel")
+		}
+		if _, err := tlsConn.Read(make([]byte, 1)); err != nil {
+			printHops()
+			return trace.Wrap(err, "no Postgres response received, the database agent or the database may be down")
+		}
+	case defaults.ProtocolMySQL:
+		// MySQL servers speak first with a handshake packet.
+		if _, err := tlsConn.Read(make([]byte, 1)); err != nil {
+			printHops()
+			return trace.Wrap(err, "no MySQL handshake received, the database agent or the database may be down")
+		}
+	default:
+		checked = false
+	}
+	if checked {
+		t.AddRow([]string{"database (protocol)", time.Since(start).Round(time.Millisecond).String()})
+	}
+
+	printHops()
+	if !checked {
+		fmt.Printf("Protocol-level check is not supported for %q databases, verified connectivity up to the Teleport proxy.\n", routeToDatabase.Protocol)
+		return nil
+	}
+	fmt.Printf("Successfully reached database %q.\n", routeToDatabase.ServiceName)
+	return nil
+}
+
+// onDatabaseRun implements "tsh db run" command.
+func onDatabaseRun(cf *CLIConf) error {
+	script, err := os.ReadFile(cf.DatabaseScript)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	statements := splitSQLStatements(string(script))
+	if len(statements) == 0 {
+		return trace.BadParameter("no SQL statements found in %v", cf.DatabaseScript)
+	}
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	routeToDatabase, database, err := getDatabaseInfo(cf, tc, cf.DatabaseService)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch routeToDatabase.Protocol {
+	case defaults.ProtocolPostgres, defaults.ProtocolCockroachDB, defaults.ProtocolMySQL:
+	default:
+		return trace.BadParameter("'tsh db run' supports PostgreSQL, CockroachDB and MySQL databases, database %q speaks %v",
+			routeToDatabase.ServiceName, routeToDatabase.Protocol)
+	}
+	relogin, err := needRelogin(cf, tc, routeToDatabase, profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if relogin {
+		if err := databaseLogin(cf, tc, *routeToDatabase, true); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	key, err := tc.LocalAgent().GetCoreKey()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	rootClusterName, err := key.RootClusterName()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	opts, err := maybeStartLocalProxy(cf, tc, profile, routeToDatabase, database, rootClusterName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	opts = append(opts, dbcmd.WithLogger(log))
+	cmd, err := dbcmd.NewCmdBuilder(tc, profile, routeToDatabase, rootClusterName, opts...).GetConnectCommand()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	auditHelperCommand(cmd)
+
+	// Wrapping the script in BEGIN/COMMIT makes the whole run atomic: any
+	// failing statement aborts the transaction and nothing is applied.
+	if cf.DatabaseTransaction {
+		statements = append(append([]string{"BEGIN;"}, statements...), "COMMIT;")
+	}
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := cmd.Start(); err != nil {
+		return trace.Wrap(err)
+	}
+	for i, statement := range statements {
+		fmt.Fprintf(os.Stderr, "[%v/%v] %v\n", i+1, len(statements), summarizeSQLStatement(statement))
+		if _, err := fmt.Fprintln(stdin, statement); err != nil {
+			stdin.Close()
+			cmd.Wait()
+			return trace.Wrap(err)
+		}
+	}
+	if err := stdin.Close(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Executed %v statements from %v against %q.\n", len(statements), cf.DatabaseScript, routeToDatabase.ServiceName)
+	return nil
+}
+
+// onListDatabaseSessions implements "tsh db sessions ls" command.
+func onListDatabaseSessions(cf *CLIConf) error {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	proxy, err := tc.ConnectToProxy(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	site, err := proxy.ConnectToCurrentCluster(cf.Context, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	sessions, err := site.GetActiveSessionTrackers(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	dbSessions := make([]types.SessionTracker, 0)
+	for _, session := range sessions {
+		if session.GetSessionKind() == types.DatabaseSessionKind {
+			dbSessions = append(dbSessions, session)
+		}
+	}
+
+	sort.Slice(dbSessions, func(i, j int) bool {
+		return dbSessions[i].GetCreated().Before(dbSessions[j].GetCreated())
+	})
+
+	format := strings.ToLower(cf.Format)
+	switch format {
+	case teleport.Text, "":
+		printDatabaseSessions(dbSessions)
+	case teleport.JSON, teleport.YAML:
+		out, err := serializeDatabaseSessions(dbSessions, format)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(out)
+	default:
+		return trace.BadParameter("unsupported format %q", cf.Format)
+	}
+	return nil
+}
+
+func serializeDatabaseSessions(sessions []types.SessionTracker, format string) (string, error) {
+	var out []byte
+	var err error
+	if format == teleport.JSON {
+		out, err = utils.FastMarshalIndent(sessions, "", "  ")
+	} else {
+		out, err = yaml.Marshal(sessions)
+	}
+	return string(out), trace.Wrap(err)
+}
+
+func printDatabaseSessions(sessions []types.SessionTracker) {
+	table := asciitable.MakeTable([]string{"ID", "State", "Created", "Hostname", "Address", "Login"})
+	for _, s := range sessions {
+		table.AddRow([]string{s.GetSessionID(), s.GetState().String(), s.GetCreated().Format(time.RFC3339), s.GetHostname(), s.GetAddress(), s.GetLogin()})
+	}
+	fmt.Println(table.AsBuffer().String())
+}
+
+// onKillDatabaseSession implements "tsh db sessions kill" command.
+func onKillDatabaseSession(cf *CLIConf) error {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	proxy, err := tc.ConnectToProxy(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	site, err := proxy.ConnectToCurrentCluster(cf.Context, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	sessions, err := site.GetActiveSessionTrackers(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	var found bool
+	for _, session := range sessions {
+		if session.GetSessionKind() == types.DatabaseSessionKind && session.GetSessionID() == cf.SessionID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return trace.NotFound("no active database session with ID %q, use 'tsh db sessions ls' to list active sessions", cf.SessionID)
+	}
+
+	if err := site.RemoveSessionTracker(cf.Context, cf.SessionID); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Terminated database session %v.\n", cf.SessionID)
+	return nil
+}
+
+// splitSQLStatements splits a SQL script into statements terminated by
+// semicolons, skipping semicolons inside quoted strings and dropping line
+// comments and empty statements.
+func splitSQLStatements(script string) (statements []string) {
+	var b strings.Builder
+	var quote rune
+	flush := func() {
+		if statement := strings.TrimSpace(b.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		b.Reset()
+	}
+	lines := strings.Split(script, "\n")
+	for _, line := range lines {
+		if quote == 0 && strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		for _, r := range line {
+			switch {
+			case quote != 0:
+				if r == quote {
+					quote = 0
+				}
+			case r == '\'' || r == '"' || r == '`':
+				quote = r
+			case r == ';':
+				b.WriteRune(r)
+				flush()
+				continue
+			}
+			b.WriteRune(r)
+		}
+		b.WriteRune('\n')
+	}
+	flush()
+	return statements
+}
+
+// summarizeSQLStatement returns a short single-line summary of a SQL
+// statement suitable for progress output.
+func summarizeSQLStatement(statement string) string {
+	summary := strings.Join(strings.Fields(statement), " ")
+	const maxLen = 60
+	if len(summary) > maxLen {
+		summary = summary[:maxLen] + "..."
+	}
+	return summary
+}
+
 // getDatabaseInfo fetches information about the database from tsh profile is DB is active in profile. Otherwise,
 // the ListDatabases endpoint is called.
 func getDatabaseInfo(cf *CLIConf, tc *client.TeleportClient, dbName string) (*tlsca.RouteToDatabase, types.Database, error) {
@@ -240,6 +240,45 @@ func TestDBInfoHasChanged(t *testing.T) {
 	}
 }
 
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "multiple statements",
+			script: "CREATE TABLE t (id int);\nINSERT INTO t VALUES (1);",
+			want:   []string{"CREATE TABLE t (id int);", "INSERT INTO t VALUES (1);"},
+		},
+		{
+			name:   "semicolon inside quotes",
+			script: "INSERT INTO t VALUES ('a;b');",
+			want:   []string{"INSERT INTO t VALUES ('a;b');"},
+		},
+		{
+			name:   "line comments and blank lines are dropped",
+			script: "-- a comment\n\nSELECT 1;\n",
+			want:   []string{"SELECT 1;"},
+		},
+		{
+			name:   "statement spanning multiple lines",
+			script: "CREATE TABLE t (\n  id int\n);",
+			want:   []string{"CREATE TABLE t (\n  id int\n);"},
+		},
+		{
+			name:   "empty script",
+			script: "  \n\n",
+			want:   nil,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, splitSQLStatements(tc.script))
+		})
+	}
+}
+
 func makeTestDatabaseServer(t *testing.T, auth *service.TeleportProcess, proxy *service.TeleportProcess, dbs ...service.Database) (db *service.TeleportProcess) {
 	// Proxy uses self-signed certificates in tests.
 	lib.SetInsecureDevMode(true)
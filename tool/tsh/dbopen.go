@@ -0,0 +1,158 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/tlsca"
+
+	"github.com/gravitational/trace"
+)
+
+// onDatabaseOpen implements "tsh db open". It builds a protocol deep link
+// (postgres://, mysql://, mongodb://, ...) pointing at the Teleport proxy
+// with the session credentials and hands it to the operating system, so the
+// user's registered GUI client opens already connected.
+func onDatabaseOpen(cf *CLIConf) error {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	database, err := pickActiveDatabase(cf)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	rootCluster, err := tc.RootClusterName()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	link, err := databaseDeepLink(tc, profile, rootCluster, database)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if cf.DatabaseOpenPrint {
+		fmt.Println(link)
+		return nil
+	}
+	if err := openDeepLink(cf, link); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open the link automatically: %v\n", err)
+	}
+	fmt.Printf("If the GUI client does not open automatically, use the link:\n %v\n", link)
+	return nil
+}
+
+// databaseDeepLink builds the protocol URL for the database, including the
+// certificate paths GUI clients need to authenticate through the proxy.
+func databaseDeepLink(tc *client.TeleportClient, profile *client.ProfileStatus, rootCluster string, database *tlsca.RouteToDatabase) (string, error) {
+	// Postgres proxy listens on web proxy port while MySQL proxy listens on
+	// a separate port due to the specifics of the protocol.
+	var scheme, host string
+	var port int
+	switch database.Protocol {
+	case defaults.ProtocolPostgres, defaults.ProtocolCockroachDB:
+		scheme = "postgres"
+		host, port = tc.PostgresProxyHostPort()
+	case defaults.ProtocolMySQL:
+		scheme = "mysql"
+		host, port = tc.MySQLProxyHostPort()
+	case defaults.ProtocolMongoDB:
+		scheme = "mongodb"
+		host, port = tc.WebProxyHostPort()
+	case defaults.ProtocolRedis:
+		scheme = "rediss"
+		host, port = tc.WebProxyHostPort()
+	default:
+		return "", trace.BadParameter("deep links are not supported for protocol %q, use 'tsh db config' to configure the GUI client manually", database.Protocol)
+	}
+
+	link := url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+	}
+	if database.Username != "" {
+		link.User = url.User(database.Username)
+	}
+	if database.Database != "" {
+		link.Path = "/" + database.Database
+	}
+
+	caPath := profile.CACertPathForCluster(rootCluster)
+	certPath := profile.DatabaseCertPathForCluster(tc.SiteName, database.ServiceName)
+	keyPath := profile.KeyPath()
+	query := url.Values{}
+	switch database.Protocol {
+	case defaults.ProtocolPostgres, defaults.ProtocolCockroachDB:
+		query.Set("sslmode", "verify-full")
+		query.Set("sslrootcert", caPath)
+		query.Set("sslcert", certPath)
+		query.Set("sslkey", keyPath)
+	case defaults.ProtocolMongoDB:
+		query.Set("tls", "true")
+		query.Set("tlsCAFile", caPath)
+		query.Set("tlsCertificateKeyFile", certPath)
+	}
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
+
+// openDeepLink hands the link to the operating system so the registered
+// handler for the protocol opens it, using the same launchers as the SSO
+// login flow. With --browser=none the link is only printed.
+func openDeepLink(cf *CLIConf, link string) error {
+	if cf.Browser == teleport.BrowserNone {
+		return nil
+	}
+	var opener *exec.Cmd
+	switch runtime.GOOS {
+	case constants.DarwinOS:
+		path, err := exec.LookPath(teleport.OpenBrowserDarwin)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		opener = exec.Command(path, link)
+	case constants.WindowsOS:
+		path, err := exec.LookPath(teleport.OpenBrowserWindows)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		opener = exec.Command(path, "url.dll,FileProtocolHandler", link)
+	default:
+		path, err := exec.LookPath(teleport.OpenBrowserLinux)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		opener = exec.Command(path, link)
+	}
+	return trace.Wrap(opener.Start())
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/tlsca"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseDeepLink(t *testing.T) {
+	tc := &client.TeleportClient{Config: client.Config{
+		WebProxyAddr: "proxy.example.com:3080",
+		SiteName:     "root",
+	}}
+	profile := &client.ProfileStatus{Dir: "/home/alice/.tsh/keys/proxy.example.com", Name: "proxy.example.com", Username: "alice"}
+
+	link, err := databaseDeepLink(tc, profile, "root", &tlsca.RouteToDatabase{
+		ServiceName: "pg",
+		Protocol:    defaults.ProtocolPostgres,
+		Username:    "alice",
+		Database:    "metrics",
+	})
+	require.NoError(t, err)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	require.Equal(t, "postgres", u.Scheme)
+	require.Equal(t, "alice", u.User.Username())
+	require.Equal(t, "/metrics", u.Path)
+	require.Equal(t, "verify-full", u.Query().Get("sslmode"))
+	require.NotEmpty(t, u.Query().Get("sslrootcert"))
+
+	link, err = databaseDeepLink(tc, profile, "root", &tlsca.RouteToDatabase{
+		ServiceName: "mongo",
+		Protocol:    defaults.ProtocolMongoDB,
+	})
+	require.NoError(t, err)
+	u, err = url.Parse(link)
+	require.NoError(t, err)
+	require.Equal(t, "mongodb", u.Scheme)
+	require.Equal(t, "true", u.Query().Get("tls"))
+
+	_, err = databaseDeepLink(tc, profile, "root", &tlsca.RouteToDatabase{
+		ServiceName: "mssql",
+		Protocol:    defaults.ProtocolSQLServer,
+	})
+	require.Error(t, err)
+}
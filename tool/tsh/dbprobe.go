@@ -0,0 +1,188 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/srv/alpnproxy/common"
+	"github.com/gravitational/teleport/lib/tlsca"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// onDatabaseProbe implements "tsh db probe". It periodically runs the
+// trivial protocol exchange from "tsh db ping" through the full Teleport
+// path and reports the observed latency, optionally emitting statsd metrics
+// so access-path health can be monitored from client vantage points.
+func onDatabaseProbe(cf *CLIConf) error {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	routeToDatabase, _, err := getDatabaseInfo(cf, tc, cf.DatabaseService)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch routeToDatabase.Protocol {
+	case defaults.ProtocolPostgres, defaults.ProtocolCockroachDB, defaults.ProtocolMySQL:
+	default:
+		return trace.BadParameter("probing is not supported for %q databases", routeToDatabase.Protocol)
+	}
+	relogin, err := needRelogin(cf, tc, routeToDatabase, profile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if relogin {
+		if err := databaseLogin(cf, tc, *routeToDatabase, true); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	var statsd net.Conn
+	if cf.StatsdAddr != "" {
+		statsd, err = net.Dial("udp", cf.StatsdAddr)
+		if err != nil {
+			return trace.Wrap(err, "failed to set up statsd emitter for %v", cf.StatsdAddr)
+		}
+		defer statsd.Close()
+	}
+	metricPrefix := statsdMetricPrefix(routeToDatabase.ServiceName)
+
+	fmt.Printf("Probing database %q every %v, press Ctrl-C to stop.\n",
+		routeToDatabase.ServiceName, cf.DatabaseProbeInterval)
+	ticker := time.NewTicker(cf.DatabaseProbeInterval)
+	defer ticker.Stop()
+	for {
+		latency, err := probeDatabaseOnce(cf, tc, profile, routeToDatabase)
+		timestamp := time.Now().Format(time.RFC3339)
+		if err != nil {
+			fmt.Printf("%v probe failed: %v\n", timestamp, utils.UserMessageFromError(err))
+			emitStatsd(statsd, statsdCounter(metricPrefix+".failures"))
+		} else {
+			fmt.Printf("%v probe ok latency=%v\n", timestamp, latency.Round(time.Millisecond))
+			emitStatsd(statsd, statsdTiming(metricPrefix+".latency", latency))
+		}
+		select {
+		case <-ticker.C:
+		case <-cf.Context.Done():
+			return nil
+		}
+	}
+}
+
+// probeDatabaseOnce dials through the Teleport proxy, performs the TLS
+// routing handshake and a trivial protocol exchange with the database, and
+// returns the end-to-end latency.
+func probeDatabaseOnce(cf *CLIConf, tc *client.TeleportClient, profile *client.ProfileStatus, route *tlsca.RouteToDatabase) (time.Duration, error) {
+	alpnProtocol, err := common.ToALPNProtocol(route.Protocol)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	address, err := utils.ParseAddr(tc.WebProxyAddr)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	cert, err := tls.LoadX509KeyPair(
+		profile.DatabaseCertPathForCluster(tc.SiteName, route.ServiceName),
+		profile.KeyPath(),
+	)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", tc.WebProxyAddr, apidefaults.DefaultDialTimeout)
+	if err != nil {
+		return 0, trace.Wrap(err, "failed to reach Teleport proxy at %v", tc.WebProxyAddr)
+	}
+	defer conn.Close()
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		NextProtos:         []string{string(alpnProtocol)},
+		ServerName:         address.Host(),
+		InsecureSkipVerify: cf.InsecureSkipVerify,
+		Certificates:       []tls.Certificate{cert},
+	})
+	if err := tlsConn.HandshakeContext(cf.Context); err != nil {
+		return 0, trace.Wrap(err, "TLS handshake with Teleport proxy failed")
+	}
+
+	tlsConn.SetDeadline(time.Now().Add(apidefaults.DefaultDialTimeout))
+	switch route.Protocol {
+	case defaults.ProtocolPostgres, defaults.ProtocolCockroachDB:
+		if _, err := tlsConn.Write(postgresSSLRequest); err != nil {
+			return 0, trace.Wrap(err, "failed to send Postgres SSLRequest through the tunnel")
+		}
+		if _, err := tlsConn.Read(make([]byte, 1)); err != nil {
+			return 0, trace.Wrap(err, "no Postgres response received")
+		}
+	case defaults.ProtocolMySQL:
+		if _, err := tlsConn.Read(make([]byte, 1)); err != nil {
+			return 0, trace.Wrap(err, "no MySQL handshake received")
+		}
+	}
+	return time.Since(start), nil
+}
+
+// statsdMetricPrefix builds the metric prefix for a database, replacing
+// characters that are not safe in statsd metric names.
+func statsdMetricPrefix(serviceName string) string {
+	var sb strings.Builder
+	for _, r := range serviceName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	return "teleport.db.probe." + sb.String()
+}
+
+// statsdTiming formats a statsd timing metric in milliseconds.
+func statsdTiming(name string, d time.Duration) string {
+	return fmt.Sprintf("%s:%d|ms", name, d.Milliseconds())
+}
+
+// statsdCounter formats a statsd counter increment.
+func statsdCounter(name string) string {
+	return fmt.Sprintf("%s:1|c", name)
+}
+
+// emitStatsd sends a metric line to the statsd sink. Metric delivery is
+// best-effort; UDP send failures are only logged.
+func emitStatsd(conn net.Conn, metric string) {
+	if conn == nil {
+		return
+	}
+	if _, err := conn.Write([]byte(metric)); err != nil {
+		log.WithError(err).Debugf("Failed to emit statsd metric %q.", metric)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsdMetricFormatting(t *testing.T) {
+	require.Equal(t, "teleport.db.probe.prod-postgres", statsdMetricPrefix("prod-postgres"))
+	require.Equal(t, "teleport.db.probe.prod_postgres_eu", statsdMetricPrefix("prod postgres/eu"))
+	require.Equal(t, "teleport.db.probe.db.latency:123|ms", statsdTiming("teleport.db.probe.db.latency", 123*time.Millisecond))
+	require.Equal(t, "teleport.db.probe.db.failures:1|c", statsdCounter("teleport.db.probe.db.failures"))
+}
+
+func TestEmitStatsd(t *testing.T) {
+	// A nil sink is a no-op.
+	emitStatsd(nil, "metric:1|c")
+
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("udp", server.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	emitStatsd(conn, "teleport.db.probe.db.latency:42|ms")
+
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := server.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, "teleport.db.probe.db.latency:42|ms", string(buf[:n]))
+}
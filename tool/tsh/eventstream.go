@@ -0,0 +1,58 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// outputModeJSONStream makes long-running commands emit structured progress
+// events on stderr as JSON lines, so GUIs and wrappers can display live
+// state without scraping human-oriented text. Stdout is left untouched for
+// the command's regular output.
+const outputModeJSONStream = "json-stream"
+
+// streamEvent is a single progress event emitted in json-stream mode.
+type streamEvent struct {
+	Time time.Time `json:"time"`
+	// Type identifies the event, e.g. "port_forward_open" or
+	// "access_request_resolved".
+	Type string `json:"type"`
+	// Fields carries event-specific details.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// emitStreamEvent writes a progress event to stderr when json-stream output
+// is enabled and is a no-op otherwise. Emission failures are ignored: the
+// event stream is advisory and must never break the command.
+func emitStreamEvent(cf *CLIConf, eventType string, fields map[string]interface{}) {
+	if cf.OutputMode != outputModeJSONStream {
+		return
+	}
+	line, err := json.Marshal(streamEvent{
+		Time:   time.Now().UTC(),
+		Type:   eventType,
+		Fields: fields,
+	})
+	if err != nil {
+		log.WithError(err).Debug("Failed to encode stream event.")
+		return
+	}
+	os.Stderr.Write(append(line, '\n'))
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitStreamEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stderr")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	oldStderr := os.Stderr
+	os.Stderr = f
+	t.Cleanup(func() {
+		os.Stderr = oldStderr
+		f.Close()
+	})
+
+	// Without json-stream mode nothing is emitted.
+	emitStreamEvent(&CLIConf{}, "port_forward_open", nil)
+
+	cf := &CLIConf{OutputMode: outputModeJSONStream}
+	emitStreamEvent(cf, "proxy_listening", map[string]interface{}{
+		"address": "127.0.0.1:12345",
+	})
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var event streamEvent
+	require.NoError(t, json.Unmarshal(data, &event))
+	require.Equal(t, "proxy_listening", event.Type)
+	require.Equal(t, "127.0.0.1:12345", event.Fields["address"])
+	require.False(t, event.Time.IsZero())
+}
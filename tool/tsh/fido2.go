@@ -17,7 +17,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/gravitational/teleport/lib/asciitable"
 	wancli "github.com/gravitational/teleport/lib/auth/webauthncli"
 	"github.com/gravitational/trace"
 )
@@ -38,3 +40,21 @@ func onFIDO2Diag(cf *CLIConf) error {
 
 	return trace.Wrap(err)
 }
+
+func onFIDO2Devices(cf *CLIConf) error {
+	devs, err := wancli.FIDO2Devices(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(devs) == 0 {
+		fmt.Println("No FIDO2 devices found.")
+		return nil
+	}
+
+	t := asciitable.MakeTable([]string{"Path", "Manufacturer", "Product", "Versions"})
+	for _, dev := range devs {
+		t.AddRow([]string{dev.Path, dev.Manufacturer, dev.Product, strings.Join(dev.Versions, ", ")})
+	}
+	fmt.Println(t.AsBuffer().String())
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// helperAuditLogEnv optionally points at a file where helper command
+// invocations are appended as JSON lines, one object per invocation, for
+// incident reconstruction. The debug log always receives the same
+// information when -d is set.
+const helperAuditLogEnv = "TSH_HELPER_AUDIT_LOG"
+
+// sensitiveEnvPattern matches environment variable names whose values must
+// not end up in logs.
+var sensitiveEnvPattern = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|PASSWD|PWD|KEY|CREDENTIAL)`)
+
+// helperInvocation is the record written for each helper command, both to
+// the debug log and, when TSH_HELPER_AUDIT_LOG is set, to the audit file.
+type helperInvocation struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Args    []string  `json:"args"`
+	Env     []string  `json:"env"`
+	Dir     string    `json:"dir,omitempty"`
+}
+
+// auditHelperCommand records the exact command line and sanitized
+// environment of a helper binary (psql, mysql, aws, ...) tsh is about to
+// execute, so incidents can reconstruct what the helper was invoked with.
+func auditHelperCommand(cmd *exec.Cmd) {
+	env := cmd.Env
+	if env == nil {
+		// A nil Env means the child inherits the parent's environment.
+		env = os.Environ()
+	}
+	inv := helperInvocation{
+		Time:    time.Now().UTC(),
+		Command: cmd.Path,
+		Args:    cmd.Args,
+		Env:     sanitizeHelperEnv(env),
+		Dir:     cmd.Dir,
+	}
+
+	log.Debugf("Running helper command: %v.", strings.Join(inv.Args, " "))
+	log.Debugf("Helper environment (sanitized): %v.", strings.Join(inv.Env, " "))
+
+	path := os.Getenv(helperAuditLogEnv)
+	if path == "" {
+		return
+	}
+	// The audit trail is best-effort: failing to record it should never
+	// prevent the helper from running.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to open helper audit log %v.", path)
+		return
+	}
+	defer f.Close()
+	line, err := json.Marshal(inv)
+	if err != nil {
+		log.WithError(err).Warn("Failed to encode helper invocation.")
+		return
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.WithError(err).Warnf("Failed to write helper audit log %v.", path)
+	}
+}
+
+// sanitizeHelperEnv returns a copy of the environment with the values of
+// credential-bearing variables replaced, keyed off the variable name.
+func sanitizeHelperEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		name := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name = kv[:i]
+		}
+		if sensitiveEnvPattern.MatchString(name) {
+			out = append(out, name+"=[redacted]")
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
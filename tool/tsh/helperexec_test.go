@@ -0,0 +1,64 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeHelperEnv(t *testing.T) {
+	out := sanitizeHelperEnv([]string{
+		"HOME=/home/alice",
+		"PGPASSWORD=hunter2",
+		"AWS_SECRET_ACCESS_KEY=abcdef",
+		"AWS_SESSION_TOKEN=xyz",
+		"MYSQL_PWD=hunter2",
+		"TERM=xterm",
+	})
+	require.Equal(t, []string{
+		"HOME=/home/alice",
+		"PGPASSWORD=[redacted]",
+		"AWS_SECRET_ACCESS_KEY=[redacted]",
+		"AWS_SESSION_TOKEN=[redacted]",
+		"MYSQL_PWD=[redacted]",
+		"TERM=xterm",
+	}, out)
+}
+
+func TestAuditHelperCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helper.log")
+	os.Setenv(helperAuditLogEnv, path)
+	defer os.Unsetenv(helperAuditLogEnv)
+
+	cmd := exec.Command("psql", "-h", "localhost", "-p", "12345")
+	cmd.Env = []string{"PGPASSWORD=hunter2", "PGHOST=localhost"}
+	auditHelperCommand(cmd)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var inv helperInvocation
+	require.NoError(t, json.Unmarshal(data, &inv))
+	require.Contains(t, inv.Args, "-h")
+	require.Contains(t, inv.Env, "PGPASSWORD=[redacted]")
+	require.Contains(t, inv.Env, "PGHOST=localhost")
+}
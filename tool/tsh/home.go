@@ -0,0 +1,78 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gravitational/teleport/api/profile"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// xdgDataHomeEnvVar is the XDG base directory spec variable pointing at
+	// the user's data directory, defaulting to ~/.local/share.
+	xdgDataHomeEnvVar = "XDG_DATA_HOME"
+	// xdgProfileDir is the name of the tsh directory inside the XDG data
+	// directory.
+	xdgProfileDir = "tsh"
+)
+
+// xdgProfilePath returns the XDG base directory spec location for tsh
+// profiles and keys: $XDG_DATA_HOME/tsh, or ~/.local/share/tsh when
+// XDG_DATA_HOME is unset. Returns an empty string if the user home
+// directory cannot be determined.
+func xdgProfilePath(fn envGetter) string {
+	if dataHome := fn(xdgDataHomeEnvVar); dataHome != "" {
+		return filepath.Join(path.Clean(dataHome), xdgProfileDir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", xdgProfileDir)
+}
+
+// onMigrateHome executes the 'tsh migrate-home' command, moving the legacy
+// ~/.tsh directory to the XDG data directory. Once moved, tsh picks the new
+// location up automatically, with TELEPORT_HOME still taking precedence.
+func onMigrateHome(cf *CLIConf) error {
+	legacyPath := profile.FullProfilePath("")
+	xdgPath := xdgProfilePath(os.Getenv)
+	if xdgPath == "" {
+		return trace.NotFound("could not determine the user home directory")
+	}
+	if _, err := os.Stat(xdgPath); err == nil {
+		return trace.AlreadyExists("%v already exists, remove it first to migrate", xdgPath)
+	}
+	if fi, err := os.Stat(legacyPath); err != nil || !fi.IsDir() {
+		return trace.NotFound("no profile directory found at %v, nothing to migrate", legacyPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(xdgPath), 0700); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(legacyPath, xdgPath); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	fmt.Printf("Moved %v to %v.\n", legacyPath, xdgPath)
+	fmt.Printf("tsh will use the new location automatically, unless %v overrides it.\n", "TELEPORT_HOME")
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXDGProfilePath(t *testing.T) {
+	tmp := t.TempDir()
+
+	t.Run("XDG_DATA_HOME set", func(t *testing.T) {
+		fn := func(key string) string {
+			if key == xdgDataHomeEnvVar {
+				return tmp
+			}
+			return ""
+		}
+		require.Equal(t, filepath.Join(tmp, "tsh"), xdgProfilePath(fn))
+	})
+
+	t.Run("XDG_DATA_HOME unset", func(t *testing.T) {
+		fn := func(string) string { return "" }
+		got := xdgProfilePath(fn)
+		if got != "" {
+			require.Equal(t, filepath.Join(".local", "share", "tsh"), got[len(got)-len(filepath.Join(".local", "share", "tsh")):])
+		}
+	})
+
+	t.Run("falls back to XDG directory when it exists", func(t *testing.T) {
+		fn := func(key string) string {
+			if key == xdgDataHomeEnvVar {
+				return tmp
+			}
+			return ""
+		}
+		var cf CLIConf
+		// Directory does not exist yet, so the legacy default is kept.
+		setTeleportHomeFromEnv(&cf, fn)
+		require.Empty(t, cf.HomePath)
+
+		require.NoError(t, os.MkdirAll(filepath.Join(tmp, "tsh"), 0700))
+		setTeleportHomeFromEnv(&cf, fn)
+		require.Equal(t, filepath.Join(tmp, "tsh"), cf.HomePath)
+	})
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+)
+
+// identityEnvVar carries identity file material (the output of 'tctl auth
+// sign' or 'tsh login -o'), either raw PEM or base64-encoded, so minimal
+// containers can run tsh without a home directory or any files on disk.
+const identityEnvVar = "TELEPORT_IDENTITY"
+
+// keyFromIdentityEnv parses the identity material supplied via the
+// TELEPORT_IDENTITY environment variable.
+func keyFromIdentityEnv(value string) (*client.Key, error) {
+	data := []byte(value)
+	// Raw PEM identities are recognizable by their armor; anything else is
+	// expected to be base64 so the variable survives quoting and newlines.
+	if !strings.Contains(value, "-----BEGIN") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, trace.BadParameter("%v must contain an identity file, either raw PEM or base64-encoded: %v", identityEnvVar, err)
+		}
+		data = decoded
+	}
+	key, err := client.KeyFromIdentityReader(bytes.NewReader(data))
+	return key, trace.Wrap(err)
+}
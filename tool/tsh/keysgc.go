@@ -0,0 +1,233 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/api/profile"
+	"github.com/gravitational/teleport/api/utils/keypaths"
+	"github.com/gravitational/teleport/lib/tlsca"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
+)
+
+// key file suffixes mirrored from api/utils/keypaths; the package keeps its
+// constants unexported so the garbage collector matches on the same strings.
+const (
+	sshCertSuffix    = "-cert.pub"
+	tlsCertSuffix    = "-x509.pem"
+	kubeConfigSuffix = "-kubeconfig"
+)
+
+// expiredKeyFile describes a certificate in the key store whose validity
+// period has passed.
+type expiredKeyFile struct {
+	// Path is the absolute path of the certificate file.
+	Path string
+	// Expiry is when the certificate stopped being valid.
+	Expiry time.Time
+	// Extra holds companion files that are useless without the certificate,
+	// such as the standalone kubeconfig next to a kube access cert.
+	Extra []string
+}
+
+// onKeysGC implements "tsh keys gc". It scans the session key directory for
+// SSH and TLS certificates that are past their expiry and removes them,
+// along with any cluster directories left empty by the removal. Private keys
+// and trusted CA material are never touched.
+func onKeysGC(cf *CLIConf) error {
+	keyDir := keypaths.KeyDir(profile.FullProfilePath(cf.HomePath))
+	expired, err := expiredKeyFiles(keyDir, time.Now())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(expired) == 0 {
+		fmt.Println("No expired certificates found.")
+		return nil
+	}
+	for _, file := range expired {
+		fmt.Printf("%v (expired %v)\n", file.Path,
+			file.Expiry.Local().Format(constants.HumanDateFormatSeconds))
+		if cf.KeysGCDryRun {
+			continue
+		}
+		for _, path := range append([]string{file.Path}, file.Extra...) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return trace.ConvertSystemError(err)
+			}
+		}
+	}
+	if cf.KeysGCDryRun {
+		fmt.Printf("Would remove %v expired certificates. Re-run without --dry-run to remove them.\n", len(expired))
+		return nil
+	}
+	if err := removeEmptyCertDirs(keyDir); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Removed %v expired certificates.\n", len(expired))
+	return nil
+}
+
+// expiredKeyFiles walks the session key directory and returns the
+// certificates that expired before the given time, sorted by path. Files
+// that fail to parse are skipped rather than treated as garbage.
+func expiredKeyFiles(keyDir string, now time.Time) ([]expiredKeyFile, error) {
+	var expired []expiredKeyFile
+	err := filepath.Walk(keyDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return trace.ConvertSystemError(err)
+		}
+		if info.IsDir() {
+			return nil
+		}
+		expiry, ok := certFileExpiry(path)
+		if !ok || !now.After(expiry) {
+			return nil
+		}
+		file := expiredKeyFile{Path: path, Expiry: expiry}
+		// A kube access cert has a standalone kubeconfig generated next to
+		// it that references the cert and is useless once it is gone.
+		if strings.HasSuffix(path, tlsCertSuffix) {
+			kubeConfig := strings.TrimSuffix(path, tlsCertSuffix) + kubeConfigSuffix
+			if _, err := os.Stat(kubeConfig); err == nil {
+				file.Extra = append(file.Extra, kubeConfig)
+			}
+		}
+		expired = append(expired, file)
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].Path < expired[j].Path
+	})
+	return expired, nil
+}
+
+// certFileExpiry returns the expiry time of the certificate stored at path.
+// It returns false for files that are not certificates, certificates without
+// an expiry, and files that fail to parse.
+func certFileExpiry(path string) (time.Time, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	switch {
+	case strings.HasSuffix(path, sshCertSuffix):
+		pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return time.Time{}, false
+		}
+		cert, ok := pub.(*ssh.Certificate)
+		if !ok || cert.ValidBefore == 0 || cert.ValidBefore == uint64(ssh.CertTimeInfinity) {
+			return time.Time{}, false
+		}
+		return time.Unix(int64(cert.ValidBefore), 0), true
+	case strings.HasSuffix(path, tlsCertSuffix):
+		cert, err := tlsca.ParseCertificatePEM(data)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return cert.NotAfter, true
+	}
+	return time.Time{}, false
+}
+
+// removeEmptyCertDirs removes per-cluster and per-user certificate
+// directories that no longer contain any files, so the key store does not
+// accumulate empty directories for clusters the user logged out of. The
+// per-proxy directories themselves are kept since they hold keys and CAs.
+func removeEmptyCertDirs(keyDir string) error {
+	proxies, err := os.ReadDir(keyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+	for _, proxy := range proxies {
+		if !proxy.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(keyDir, proxy.Name()))
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || !isCertDir(entry.Name()) {
+				continue
+			}
+			if err := removeEmptyDirTree(filepath.Join(keyDir, proxy.Name(), entry.Name())); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	}
+	return nil
+}
+
+// isCertDir reports whether a directory under a proxy's key directory holds
+// only certificates, making it safe to prune when empty.
+func isCertDir(name string) bool {
+	for _, suffix := range []string{"-ssh", "-app", "-db", "-kube"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEmptyDirTree removes dir if it contains nothing but empty
+// directories, pruning those along the way.
+func removeEmptyDirTree(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := removeEmptyDirTree(filepath.Join(dir, entry.Name())); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	entries, err = os.ReadDir(dir)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if len(entries) == 0 {
+		if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// testCerts generates a PEM-encoded self-signed TLS certificate and a
+// marshaled SSH certificate, both valid until the given time.
+func testCerts(t *testing.T, notAfter time.Time) (tlsCert, sshCert []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	tlsCert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	cert := &ssh.Certificate{
+		Key:         signer.PublicKey(),
+		CertType:    ssh.UserCert,
+		ValidAfter:  uint64(notAfter.Add(-time.Hour).Unix()),
+		ValidBefore: uint64(notAfter.Unix()),
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, signer))
+	sshCert = ssh.MarshalAuthorizedKey(cert)
+	return tlsCert, sshCert
+}
+
+func TestExpiredKeyFiles(t *testing.T) {
+	now := time.Now()
+	expiredTLS, expiredSSH := testCerts(t, now.Add(-time.Hour))
+	validTLS, validSSH := testCerts(t, now.Add(time.Hour))
+
+	keyDir := t.TempDir()
+	proxyDir := filepath.Join(keyDir, "proxy.example.com")
+	writeFile := func(path string, data []byte) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, data, 0600))
+	}
+	writeFile(filepath.Join(proxyDir, "alice"), []byte("private key"))
+	writeFile(filepath.Join(proxyDir, "alice-x509.pem"), validTLS)
+	writeFile(filepath.Join(proxyDir, "alice-ssh", "root-cert.pub"), validSSH)
+	writeFile(filepath.Join(proxyDir, "alice-ssh", "leaf-cert.pub"), expiredSSH)
+	writeFile(filepath.Join(proxyDir, "alice-db", "root", "postgres-x509.pem"), expiredTLS)
+	kubeCert := filepath.Join(proxyDir, "alice-kube", "root", "kubeA-x509.pem")
+	kubeConfig := filepath.Join(proxyDir, "alice-kube", "root", "kubeA-kubeconfig")
+	writeFile(kubeCert, expiredTLS)
+	writeFile(kubeConfig, []byte("kubeconfig"))
+
+	expired, err := expiredKeyFiles(keyDir, now)
+	require.NoError(t, err)
+	require.Len(t, expired, 3)
+	require.Equal(t, filepath.Join(proxyDir, "alice-db", "root", "postgres-x509.pem"), expired[0].Path)
+	require.Equal(t, kubeCert, expired[1].Path)
+	require.Equal(t, []string{kubeConfig}, expired[1].Extra)
+	require.Equal(t, filepath.Join(proxyDir, "alice-ssh", "leaf-cert.pub"), expired[2].Path)
+
+	// A missing key directory is not an error.
+	expired, err = expiredKeyFiles(filepath.Join(keyDir, "missing"), now)
+	require.NoError(t, err)
+	require.Empty(t, expired)
+}
+
+func TestRemoveEmptyCertDirs(t *testing.T) {
+	keyDir := t.TempDir()
+	proxyDir := filepath.Join(keyDir, "proxy.example.com")
+	require.NoError(t, os.MkdirAll(filepath.Join(proxyDir, "alice-db", "root"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(proxyDir, "alice-ssh"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(proxyDir, "cas"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(proxyDir, "alice-ssh", "root-cert.pub"), []byte("cert"), 0600))
+
+	require.NoError(t, removeEmptyCertDirs(keyDir))
+
+	// The empty db tree is pruned, the non-empty ssh directory and the
+	// non-certificate cas directory are kept.
+	_, err := os.Stat(filepath.Join(proxyDir, "alice-db"))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(proxyDir, "alice-ssh", "root-cert.pub"))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(proxyDir, "cas"))
+	require.NoError(t, err)
+}
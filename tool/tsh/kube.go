@@ -17,12 +17,15 @@ limitations under the License.
 package main
 
 import (
+	"archive/tar"
 	"context"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -33,8 +36,10 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/trace"
 
+	apiclient "github.com/gravitational/teleport/api/client"
 	"github.com/gravitational/teleport/api/client/proto"
 	"github.com/gravitational/teleport/api/constants"
+	apidefaults "github.com/gravitational/teleport/api/defaults"
 	"github.com/gravitational/teleport/api/profile"
 	"github.com/gravitational/teleport/api/types"
 	apiutils "github.com/gravitational/teleport/api/utils"
@@ -56,6 +61,7 @@ import (
 	"k8s.io/client-go/pkg/apis/clientauthentication"
 	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
 	restclient "k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/client-go/tools/remotecommand"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/cmd/util/podcmd"
@@ -70,7 +76,10 @@ type kubeCommands struct {
 	login       *kubeLoginCommand
 	sessions    *kubeSessionsCommand
 	exec        *kubeExecCommand
+	cp          *kubeCpCommand
 	join        *kubeJoinCommand
+	config      *kubeConfigCommand
+	prune       *kubePruneCommand
 }
 
 func newKubeCommand(app *kingpin.Application) kubeCommands {
@@ -81,11 +90,174 @@ func newKubeCommand(app *kingpin.Application) kubeCommands {
 		login:       newKubeLoginCommand(kube),
 		sessions:    newKubeSessionsCommand(kube),
 		exec:        newKubeExecCommand(kube),
+		cp:          newKubeCpCommand(kube),
 		join:        newKubeJoinCommand(kube),
+		config:      newKubeConfigCommand(kube),
+		prune:       newKubePruneCommand(kube),
 	}
 	return cmds
 }
 
+type kubeConfigCommand struct {
+	*kingpin.CmdClause
+	kubeCluster string
+	exec        bool
+}
+
+func newKubeConfigCommand(parent *kingpin.CmdClause) *kubeConfigCommand {
+	c := &kubeConfigCommand{
+		CmdClause: parent.Command("config", "Print a self-contained kubeconfig for a kubernetes cluster to stdout"),
+	}
+	c.Arg("kube-cluster", "Name of the kubernetes cluster. Check 'tsh kube ls' for a list of available clusters.").Required().StringVar(&c.kubeCluster)
+	c.Flag("exec", "Configure tsh as a kubeconfig exec plugin instead of embedding static credentials.").BoolVar(&c.exec)
+	return c
+}
+
+func (c *kubeConfigCommand) run(cf *CLIConf) error {
+	// Set CLIConf.KubernetesCluster so that the kube cluster's context is automatically selected.
+	cf.KubernetesCluster = c.kubeCluster
+
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	kubeStatus, err := fetchKubeStatus(cf.Context, tc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !apiutils.SliceContainsStr(kubeStatus.kubeClusters, c.kubeCluster) {
+		return trace.NotFound("kubernetes cluster %q not found, check 'tsh kube ls' for a list of known clusters", c.kubeCluster)
+	}
+
+	values, err := buildKubeConfigUpdate(cf, kubeStatus)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if c.exec {
+		if values.Exec == nil {
+			return trace.BadParameter("exec plugin mode is not supported by this Teleport cluster")
+		}
+		// Only generate a context for the requested kube cluster.
+		values.Exec.KubeClusters = []string{c.kubeCluster}
+	} else {
+		values.Exec = nil
+		// Embed a certificate issued for the requested kubernetes cluster so
+		// the kubeconfig works without access to the tsh profile directory.
+		var k *client.Key
+		err = client.RetryWithRelogin(cf.Context, tc, func() error {
+			var err error
+			k, err = tc.IssueUserCertsWithMFA(cf.Context, client.ReissueParams{
+				RouteToCluster:    kubeStatus.teleportClusterName,
+				KubernetesCluster: c.kubeCluster,
+			})
+			return err
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		credentials := *kubeStatus.credentials
+		credentials.TLSCert = k.KubeTLSCerts[c.kubeCluster]
+		values.Credentials = &credentials
+	}
+
+	config, err := kubeconfig.Create(*values)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	out, err := kubeconfig.Marshal(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Fprint(cf.Stdout(), string(out))
+	return nil
+}
+
+type kubePruneCommand struct {
+	*kingpin.CmdClause
+	dryRun bool
+}
+
+func newKubePruneCommand(parent *kingpin.CmdClause) *kubePruneCommand {
+	c := &kubePruneCommand{
+		CmdClause: parent.Command("prune", "Remove kubeconfig contexts for kubernetes clusters that no longer exist or are no longer accessible"),
+	}
+	c.Flag("dry-run", "Only print the contexts that would be removed.").BoolVar(&c.dryRun)
+	return c
+}
+
+// run implements "tsh kube prune". It compares the kubeconfig contexts
+// generated by tsh for the current Teleport cluster against the kubernetes
+// clusters the user can currently access and removes the stale ones.
+func (c *kubePruneCommand) run(cf *CLIConf) error {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	teleportCluster, kubeClusters, err := fetchKubeClusters(cf.Context, tc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	config, err := kubeconfig.Load("")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	stale := staleKubeContexts(config, teleportCluster, kubeClusters)
+	if len(stale) == 0 {
+		fmt.Println("No stale kubeconfig contexts found.")
+		return nil
+	}
+
+	for _, contextName := range stale {
+		if c.dryRun {
+			fmt.Printf("Would remove context %q.\n", contextName)
+			continue
+		}
+		delete(config.AuthInfos, contextName)
+		delete(config.Clusters, contextName)
+		delete(config.Contexts, contextName)
+		fmt.Printf("Removed context %q.\n", contextName)
+	}
+	if c.dryRun {
+		return nil
+	}
+
+	// If the removed context was active, switch to any remaining one so
+	// kubectl doesn't end up pointing at a dangling context.
+	if _, ok := config.Contexts[config.CurrentContext]; !ok {
+		config.CurrentContext = ""
+		for name := range config.Contexts {
+			config.CurrentContext = name
+			break
+		}
+	}
+	return trace.Wrap(kubeconfig.Save("", *config))
+}
+
+// staleKubeContexts returns the sorted names of tsh-generated kubeconfig
+// contexts that belong to the given Teleport cluster but reference a
+// kubernetes cluster missing from the accessible ones. Contexts with custom
+// names or created for other Teleport clusters are left alone.
+func staleKubeContexts(config *clientcmdapi.Config, teleportCluster string, kubeClusters []string) []string {
+	accessible := make(map[string]struct{}, len(kubeClusters))
+	for _, kubeCluster := range kubeClusters {
+		accessible[kubeCluster] = struct{}{}
+	}
+	var stale []string
+	for contextName := range config.Contexts {
+		kubeCluster := kubeconfig.KubeClusterFromContext(contextName, teleportCluster)
+		if kubeCluster == "" {
+			continue
+		}
+		if _, ok := accessible[kubeCluster]; ok {
+			continue
+		}
+		stale = append(stale, contextName)
+	}
+	sort.Strings(stale)
+	return stale
+}
+
 type kubeJoinCommand struct {
 	*kingpin.CmdClause
 	session  string
@@ -98,7 +270,7 @@ func newKubeJoinCommand(parent *kingpin.CmdClause) *kubeJoinCommand {
 		CmdClause: parent.Command("join", "Join an active Kubernetes session."),
 	}
 
-	c.Flag("mode", "Mode of joining the session, valid modes are observer and moderator").Short('m').Default("moderator").StringVar(&c.mode)
+	c.Flag("mode", joinModeHelp).Short('m').Default(string(types.SessionModeratorMode)).EnumVar(&c.mode, participantModes...)
 	c.Flag("cluster", clusterHelp).Short('c').StringVar(&c.siteName)
 	c.Arg("session", "The ID of the target session.").Required().StringVar(&c.session)
 	return c
@@ -465,23 +637,286 @@ func (c *kubeExecCommand) run(cf *CLIConf) error {
 	}
 
 	p.PodClient = clientset.CoreV1()
+
+	// If the session may require a moderator, tell the initiator who has
+	// been notified and how to get the session approved or canceled before
+	// the remote prompt appears.
+	if c.invited != "" {
+		fmt.Fprintf(os.Stderr, "Invited participants have been notified: %v\n", c.invited)
+	}
+	if c.invited != "" || c.displayParticipantRequirements {
+		fmt.Fprintln(os.Stderr, "If the session requires a moderator it stays pending until one joins,"+
+			" e.g. with \"tsh kube sessions approve <id>\". Press <ctrl-c> to cancel the pending session.")
+	}
+	return trace.Wrap(p.Run())
+}
+
+type kubeCpCommand struct {
+	*kingpin.CmdClause
+	src                            string
+	dst                            string
+	container                      string
+	quiet                          bool
+	displayParticipantRequirements bool
+}
+
+func newKubeCpCommand(parent *kingpin.CmdClause) *kubeCpCommand {
+	c := &kubeCpCommand{
+		CmdClause: parent.Command("cp", "Copy files to or from a kubernetes pod"),
+	}
+
+	c.Flag("container", "Container name. If omitted, use the kubectl.kubernetes.io/default-container annotation for selecting the container or the first container in the pod will be chosen").Short('c').StringVar(&c.container)
+	c.Flag("quiet", "Suppress progress output").Short('q').BoolVar(&c.quiet)
+	c.Flag("participant-req", "Displays a verbose list of required participants in a moderated session.").BoolVar(&c.displayParticipantRequirements)
+	c.Arg("src", "Source path. Prefix with <pod>: to copy from a pod, e.g. nginx:/var/log/nginx").Required().StringVar(&c.src)
+	c.Arg("dst", "Destination path. Prefix with <pod>: to copy to a pod").Required().StringVar(&c.dst)
+	return c
+}
+
+func (c *kubeCpCommand) run(cf *CLIConf) error {
+	return trace.Wrap(c.copy(cf, ""))
+}
+
+// copy performs the actual file transfer. A non-empty kubeContext overrides
+// the kubeconfig context used, which allows callers like "tsh scp" to target
+// a specific kubernetes cluster instead of the currently selected one.
+func (c *kubeCpCommand) copy(cf *CLIConf, kubeContext string) error {
+	srcPod, srcPath := parseKubeCopySpec(c.src)
+	dstPod, dstPath := parseKubeCopySpec(c.dst)
+	switch {
+	case srcPod == "" && dstPod == "":
+		return trace.BadParameter("one of <src> and <dst> must be a pod path like <pod>:/path")
+	case srcPod != "" && dstPod != "":
+		return trace.BadParameter("copying directly between pods is not supported")
+	}
+
+	var p ExecOptions
+	var err error
+
+	p.IOStreams = genericclioptions.IOStreams{
+		Out:    os.Stdout,
+		ErrOut: os.Stderr,
+	}
+	kubeConfigFlags := genericclioptions.NewConfigFlags(true).WithDeprecatedPasswordFlag()
+	if kubeContext != "" {
+		kubeConfigFlags.Context = &kubeContext
+	}
+	matchVersionKubeConfigFlags := cmdutil.NewMatchVersionFlags(kubeConfigFlags)
+	f := cmdutil.NewFactory(matchVersionKubeConfigFlags)
+	p.ContainerName = c.container
+	p.Quiet = c.quiet
+	p.ExecutablePodFn = polymorphichelpers.AttachablePodForObjectFn
+	p.GetPodTimeout = time.Second * 5
+	p.Builder = f.NewBuilder
+	p.restClientGetter = f
+	p.Executor = &DefaultRemoteExecutor{}
+	p.displayParticipantRequirements = c.displayParticipantRequirements
+	p.Namespace, p.EnforceNamespace, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	p.Config, err = f.ToRESTConfig()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.PodClient = clientset.CoreV1()
+
+	if srcPod != "" {
+		p.ResourceName = srcPod
+		return trace.Wrap(c.copyFromPod(&p, srcPath, dstPath))
+	}
+	p.ResourceName = dstPod
+	return trace.Wrap(c.copyToPod(&p, srcPath, dstPath))
+}
+
+// copyFromPod runs tar inside the pod and extracts the resulting stream
+// locally into dstPath.
+func (c *kubeCpCommand) copyFromPod(p *ExecOptions, srcPath, dstPath string) error {
+	reader, writer := io.Pipe()
+	p.Command = []string{"tar", "cf", "-", "-C", path.Dir(srcPath), path.Base(srcPath)}
+	p.Out = writer
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer writer.Close()
+		errCh <- p.Run()
+	}()
+	if err := untarStream(reader, dstPath); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(<-errCh)
+}
+
+// copyToPod streams srcPath as a tar archive into tar running inside the pod.
+func (c *kubeCpCommand) copyToPod(p *ExecOptions, srcPath, dstPath string) error {
+	if _, err := os.Stat(srcPath); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	// A destination ending with a separator means "into this directory"
+	// keeping the source name, otherwise the last component renames the
+	// copied file or directory.
+	dstDir, dstName := path.Split(dstPath)
+	if dstName == "" {
+		dstName = filepath.Base(srcPath)
+	}
+	if dstDir == "" {
+		dstDir = "."
+	}
+
+	reader, writer := io.Pipe()
+	p.Command = []string{"tar", "xf", "-", "-C", dstDir}
+	p.In = reader
+	p.Stdin = true
+
+	go func() {
+		writer.CloseWithError(tarPath(writer, srcPath, dstName))
+	}()
 	return trace.Wrap(p.Run())
 }
 
+// parseKubeCopySpec splits a copy argument of the form <pod>:<path> into its
+// pod and path parts. Plain local paths return an empty pod name.
+func parseKubeCopySpec(spec string) (pod, path string) {
+	// Don't mistake Windows drive letters (e.g. C:\temp) for pod names.
+	if idx := strings.Index(spec, ":"); idx > 1 {
+		return spec[:idx], spec[idx+1:]
+	}
+	return "", spec
+}
+
+// tarPath writes a local file or directory to w as a tar stream with the
+// top-level entry renamed to name.
+func tarPath(w io.Writer, srcPath, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(srcPath, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		rel, err := filepath.Rel(srcPath, file)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		header, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		header.Name = path.Join(name, filepath.ToSlash(rel))
+		if err := tw.WriteHeader(header); err != nil {
+			return trace.Wrap(err)
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return trace.Wrap(err)
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(tw.Close())
+}
+
+// untarStream extracts a tar stream produced by tar running inside a pod
+// into dstPath. If dstPath is an existing directory entries are extracted
+// into it, otherwise the single top-level entry is renamed to dstPath.
+func untarStream(r io.Reader, dstPath string) error {
+	intoDir := false
+	if fi, err := os.Stat(dstPath); err == nil && fi.IsDir() {
+		intoDir = true
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		name := path.Clean(header.Name)
+		if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return trace.BadParameter("tar entry %q escapes the destination directory", header.Name)
+		}
+		target := dstPath
+		if intoDir {
+			target = filepath.Join(dstPath, filepath.FromSlash(name))
+		} else if idx := strings.IndexRune(name, '/'); idx >= 0 {
+			target = filepath.Join(dstPath, filepath.FromSlash(name[idx+1:]))
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+				return trace.ConvertSystemError(err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), teleport.PrivateDirMode); err != nil {
+				return trace.ConvertSystemError(err)
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode))
+			if err != nil {
+				return trace.ConvertSystemError(err)
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return trace.Wrap(err)
+			}
+			if err := f.Close(); err != nil {
+				return trace.ConvertSystemError(err)
+			}
+		default:
+			// Other entry types (symlinks, devices) are not copied.
+		}
+	}
+	return nil
+}
+
 type kubeSessionsCommand struct {
+	// CmdClause is the "sessions ls" subcommand, kept as the default so
+	// plain "tsh kube sessions" lists sessions like it always did.
 	*kingpin.CmdClause
 	format string
+
+	// approve is the "sessions approve" subcommand used by moderators to
+	// approve a pending moderated session.
+	approve   *kingpin.CmdClause
+	approveID string
 }
 
 func newKubeSessionsCommand(parent *kingpin.CmdClause) *kubeSessionsCommand {
+	sessions := parent.Command("sessions", "Manage active kubernetes sessions.")
 	c := &kubeSessionsCommand{
-		CmdClause: parent.Command("sessions", "Get a list of active kubernetes sessions."),
+		CmdClause: sessions.Command("ls", "Get a list of active kubernetes sessions.").Default(),
 	}
 	c.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&c.format, defaultFormats...)
 
+	c.approve = sessions.Command("approve", "Approve a kubernetes session waiting for a moderator.")
+	c.approve.Arg("session", "The ID of the target session.").Required().StringVar(&c.approveID)
 	return c
 }
 
+// runApprove implements "tsh kube sessions approve" by joining the target
+// session as a moderator, which releases a pending session once the access
+// policy is satisfied.
+func (c *kubeSessionsCommand) runApprove(cf *CLIConf) error {
+	join := &kubeJoinCommand{
+		session: c.approveID,
+		mode:    string(types.SessionModeratorMode),
+	}
+	fmt.Printf("Approving session %v by joining as a moderator. Press 't' to forcefully terminate the session, <ctrl-c> to leave.\n", c.approveID)
+	return trace.Wrap(join.run(cf))
+}
+
 func (c *kubeSessionsCommand) run(cf *CLIConf) error {
 	tc, err := makeClient(cf, true)
 	if err != nil {
@@ -588,6 +1023,7 @@ func (c *kubeCredentialsCommand) run(cf *CLIConf) error {
 		}
 		if crt != nil && time.Until(crt.NotAfter) > time.Minute {
 			log.Debugf("Re-using existing TLS cert for kubernetes cluster %q", c.kubeCluster)
+			logKubeCredentialIssuance(cf, c.teleportCluster, c.kubeCluster, true)
 			return c.writeResponse(k, c.kubeCluster)
 		}
 		// Otherwise, cert for this k8s cluster is missing or expired. Request
@@ -612,6 +1048,7 @@ func (c *kubeCredentialsCommand) run(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
+	logKubeCredentialIssuance(cf, c.teleportCluster, c.kubeCluster, false)
 	return c.writeResponse(k, c.kubeCluster)
 }
 
@@ -647,6 +1084,7 @@ type kubeLSCommand struct {
 	predicateExpr  string
 	searchKeywords string
 	format         string
+	verbose        bool
 }
 
 func newKubeLSCommand(parent *kingpin.CmdClause) *kubeLSCommand {
@@ -656,6 +1094,7 @@ func newKubeLSCommand(parent *kingpin.CmdClause) *kubeLSCommand {
 	c.Flag("search", searchHelp).StringVar(&c.searchKeywords)
 	c.Flag("query", queryHelp).StringVar(&c.predicateExpr)
 	c.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&c.format, defaultFormats...)
+	c.Flag("verbose", "Show cluster labels, login status and last heartbeat").Short('v').BoolVar(&c.verbose)
 	c.Arg("labels", labelHelp).StringVar(&c.labels)
 	return c
 }
@@ -664,62 +1103,119 @@ func (c *kubeLSCommand) run(cf *CLIConf) error {
 	cf.SearchKeywords = c.searchKeywords
 	cf.UserHost = c.labels
 	cf.PredicateExpression = c.predicateExpr
+	return trace.Wrap(listKubeClusters(cf, c.format, c.verbose))
+}
 
+// kubeClusterInfo describes a kubernetes cluster registered in the Teleport
+// cluster, aggregated across the kube services that proxy it.
+type kubeClusterInfo struct {
+	// KubeClusterName is the name of the kubernetes cluster.
+	KubeClusterName string `json:"kube_cluster_name"`
+	// Labels is the combined static and dynamic labels of the cluster.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Selected is true when the current kubeconfig context points at the
+	// cluster.
+	Selected bool `json:"selected"`
+	// LoggedIn is true when the local profile holds a valid certificate
+	// routed to the cluster, i.e. kubectl will work without 'tsh kube login'.
+	LoggedIn bool `json:"logged_in"`
+	// LastHeartbeat is the most recent heartbeat from a kube service
+	// proxying the cluster.
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+}
+
+// listKubeClusters prints the kubernetes clusters accessible with the
+// filters already set on cf.
+func listKubeClusters(cf *CLIConf, format string, verbose bool) error {
 	tc, err := makeClient(cf, true)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	currentTeleportCluster, kubeClusters, err := fetchKubeClusters(cf.Context, tc)
+	currentTeleportCluster, clusters, err := fetchKubeClusterDetails(cf.Context, tc)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
 	selectedCluster := selectedKubeCluster(currentTeleportCluster)
-	format := strings.ToLower(c.format)
+	for i := range clusters {
+		clusters[i].Selected = clusters[i].KubeClusterName == selectedCluster
+		clusters[i].LoggedIn = kubeClusterLoggedIn(tc, clusters[i].KubeClusterName)
+	}
+
+	format = strings.ToLower(format)
 	switch format {
 	case teleport.Text, "":
 		var t asciitable.Table
-		if cf.Quiet {
+		switch {
+		case cf.Quiet:
 			t = asciitable.MakeHeadlessTable(2)
-		} else {
+			for _, cluster := range clusters {
+				t.AddRow([]string{cluster.KubeClusterName, selectedMark(cluster.Selected)})
+			}
+		case verbose:
+			t = asciitable.MakeTable([]string{"Kube Cluster Name", "Labels", "Selected", "Logged In", "Last Heartbeat"})
+			for _, cluster := range clusters {
+				heartbeat := ""
+				if !cluster.LastHeartbeat.IsZero() {
+					heartbeat = cluster.LastHeartbeat.Format(time.RFC3339)
+				}
+				t.AddRow([]string{
+					cluster.KubeClusterName,
+					sortedLabels(cluster.Labels),
+					selectedMark(cluster.Selected),
+					selectedMark(cluster.LoggedIn),
+					heartbeat,
+				})
+			}
+		default:
 			t = asciitable.MakeTable([]string{"Kube Cluster Name", "Selected"})
-		}
-		for _, cluster := range kubeClusters {
-			var selectedMark string
-			if cluster == selectedCluster {
-				selectedMark = "*"
+			for _, cluster := range clusters {
+				t.AddRow([]string{cluster.KubeClusterName, selectedMark(cluster.Selected)})
 			}
-			t.AddRow([]string{cluster, selectedMark})
 		}
 		fmt.Println(t.AsBuffer().String())
 	case teleport.JSON, teleport.YAML:
-		out, err := serializeKubeClusters(kubeClusters, selectedCluster, format)
+		out, err := serializeKubeClusters(clusters, format)
 		if err != nil {
 			return trace.Wrap(err)
 		}
 		fmt.Println(out)
 	default:
-		return trace.BadParameter("unsupported format %q", cf.Format)
+		return trace.BadParameter("unsupported format %q", format)
 	}
 
 	return nil
 }
 
-func serializeKubeClusters(kubeClusters []string, selectedCluster, format string) (string, error) {
-	type cluster struct {
-		KubeClusterName string `json:"kube_cluster_name"`
-		Selected        bool   `json:"selected"`
+// selectedMark renders a boolean as the "*" marker used in cluster listings.
+func selectedMark(selected bool) string {
+	if selected {
+		return "*"
+	}
+	return ""
+}
+
+// kubeClusterLoggedIn returns true when the local profile holds an unexpired
+// certificate routed to the given kubernetes cluster.
+func kubeClusterLoggedIn(tc *client.TeleportClient, kubeCluster string) bool {
+	k, err := tc.LocalAgent().GetKey(tc.SiteName, client.WithKubeCerts{})
+	if err != nil {
+		return false
 	}
-	clusterInfo := make([]cluster, 0, len(kubeClusters))
-	for _, cl := range kubeClusters {
-		clusterInfo = append(clusterInfo, cluster{cl, cl == selectedCluster})
+	cert, err := k.KubeTLSCertificate(kubeCluster)
+	if err != nil {
+		return false
 	}
+	return time.Now().Before(cert.NotAfter)
+}
+
+func serializeKubeClusters(clusters []kubeClusterInfo, format string) (string, error) {
 	var out []byte
 	var err error
 	if format == teleport.JSON {
-		out, err = utils.FastMarshalIndent(clusterInfo, "", "  ")
+		out, err = utils.FastMarshalIndent(clusters, "", "  ")
 	} else {
-		out, err = yaml.Marshal(clusterInfo)
+		out, err = yaml.Marshal(clusters)
 	}
 	return string(out), trace.Wrap(err)
 }
@@ -733,9 +1229,23 @@ func selectedKubeCluster(currentTeleportCluster string) string {
 	return kubeconfig.KubeClusterFromContext(kc.CurrentContext, currentTeleportCluster)
 }
 
+// impersonationConfig holds Kubernetes impersonation parameters recorded in
+// the generated kubeconfig context at 'tsh kube login' time.
+type impersonationConfig struct {
+	// kubernetesUser specifies the Kubernetes user to impersonate.
+	kubernetesUser string
+	// kubernetesGroups specifies the Kubernetes groups to impersonate.
+	kubernetesGroups []string
+}
+
 type kubeLoginCommand struct {
 	*kingpin.CmdClause
-	kubeCluster string
+	kubeCluster         string
+	impersonateUser     string
+	impersonateGroups   []string
+	namespace           string
+	contextNameTemplate string
+	requestAccess       bool
 }
 
 func newKubeLoginCommand(parent *kingpin.CmdClause) *kubeLoginCommand {
@@ -743,48 +1253,63 @@ func newKubeLoginCommand(parent *kingpin.CmdClause) *kubeLoginCommand {
 		CmdClause: parent.Command("login", "Login to a kubernetes cluster"),
 	}
 	c.Arg("kube-cluster", "Name of the kubernetes cluster to login to. Check 'tsh kube ls' for a list of available clusters.").Required().StringVar(&c.kubeCluster)
+	c.Flag("as", "Configure custom Kubernetes user impersonation.").StringVar(&c.impersonateUser)
+	c.Flag("as-groups", "Configure custom Kubernetes group impersonation.").StringsVar(&c.impersonateGroups)
+	c.Flag("kube-namespace", "Default namespace to configure on the generated kubeconfig context.").Short('n').StringVar(&c.namespace)
+	c.Flag("context-name", "Context name template, '{{cluster}}' and '{{kube}}' are replaced with the Teleport and kubernetes cluster names (e.g. '{{cluster}}-{{kube}}').").StringVar(&c.contextNameTemplate)
+	c.Flag("request", requestAccessHelp).BoolVar(&c.requestAccess)
 	return c
 }
 
 func (c *kubeLoginCommand) run(cf *CLIConf) error {
 	// Set CLIConf.KubernetesCluster so that the kube cluster's context is automatically selected.
 	cf.KubernetesCluster = c.kubeCluster
+	cf.kubernetesImpersonationConfig = impersonationConfig{
+		kubernetesUser:   c.impersonateUser,
+		kubernetesGroups: c.impersonateGroups,
+	}
+	cf.kubeNamespace = c.namespace
+	cf.kubeContextNameTemplate = c.contextNameTemplate
+	cf.RequestAccess = c.requestAccess
 
 	tc, err := makeClient(cf, true)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	// Check that this kube cluster exists.
-	currentTeleportCluster, kubeClusters, err := fetchKubeClusters(cf.Context, tc)
-	if err != nil {
-		return trace.Wrap(err)
-	}
-	if !apiutils.SliceContainsStr(kubeClusters, c.kubeCluster) {
-		return trace.NotFound("kubernetes cluster %q not found, check 'tsh kube ls' for a list of known clusters", c.kubeCluster)
-	}
-
-	// Try updating the active kubeconfig context.
-	if err := kubeconfig.SelectContext(currentTeleportCluster, c.kubeCluster); err != nil {
-		if !trace.IsNotFound(err) {
+	err = retryWithAccessRequest(cf, tc, func() error {
+		// Check that this kube cluster exists.
+		currentTeleportCluster, kubeClusters, err := fetchKubeClusters(cf.Context, tc)
+		if err != nil {
 			return trace.Wrap(err)
 		}
-		// We know that this kube cluster exists from the API, but there isn't
-		// a context for it in the current kubeconfig. This is probably a new
-		// cluster, added after the last 'tsh login'.
-		//
-		// Re-generate kubeconfig contexts and try selecting this kube cluster
-		// again.
-		if err := updateKubeConfig(cf, tc, ""); err != nil {
-			return trace.Wrap(err)
+		if !apiutils.SliceContainsStr(kubeClusters, c.kubeCluster) {
+			return trace.NotFound("kubernetes cluster %q not found, check 'tsh kube ls' for a list of known clusters", c.kubeCluster)
 		}
-	}
 
-	// Generate a profile specific kubeconfig which can be used
-	// by setting the kubeconfig environment variable (with `tsh env`)
-	profileKubeconfigPath := keypaths.KubeConfigPath(
-		profile.FullProfilePath(cf.HomePath), tc.WebProxyHost(), tc.Username, currentTeleportCluster, c.kubeCluster,
-	)
-	if err := updateKubeConfig(cf, tc, profileKubeconfigPath); err != nil {
+		// Try updating the active kubeconfig context.
+		if err := kubeconfig.SelectContextFromTemplate(c.contextNameTemplate, currentTeleportCluster, c.kubeCluster); err != nil {
+			if !trace.IsNotFound(err) {
+				return trace.Wrap(err)
+			}
+			// We know that this kube cluster exists from the API, but there isn't
+			// a context for it in the current kubeconfig. This is probably a new
+			// cluster, added after the last 'tsh login'.
+			//
+			// Re-generate kubeconfig contexts and try selecting this kube cluster
+			// again.
+			if err := updateKubeConfig(cf, tc, ""); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+
+		// Generate a profile specific kubeconfig which can be used
+		// by setting the kubeconfig environment variable (with `tsh env`)
+		profileKubeconfigPath := keypaths.KubeConfigPath(
+			profile.FullProfilePath(cf.HomePath), tc.WebProxyHost(), tc.Username, currentTeleportCluster, c.kubeCluster,
+		)
+		return trace.Wrap(updateKubeConfig(cf, tc, profileKubeconfigPath))
+	})
+	if err != nil {
 		return trace.Wrap(err)
 	}
 
@@ -843,6 +1368,94 @@ func fetchKubeClusters(ctx context.Context, tc *client.TeleportClient) (teleport
 	return teleportCluster, kubeClusters, nil
 }
 
+// fetchKubeClusterDetails lists the kubernetes clusters accessible with the
+// filters set on tc, aggregating labels and the most recent heartbeat across
+// the kube services proxying each cluster.
+func fetchKubeClusterDetails(ctx context.Context, tc *client.TeleportClient) (teleportCluster string, clusters []kubeClusterInfo, err error) {
+	err = client.RetryWithRelogin(ctx, tc, func() error {
+		pc, err := tc.ConnectToProxy(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer pc.Close()
+		ac, err := pc.ConnectToCurrentCluster(ctx, true)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer ac.Close()
+
+		cn, err := ac.GetClusterName()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		teleportCluster = cn.GetClusterName()
+
+		var kss []types.Server
+		resources, err := apiclient.GetResourcesWithFilters(ctx, ac, proto.ListResourcesRequest{
+			ResourceType:        types.KindKubeService,
+			SearchKeywords:      tc.SearchKeywords,
+			PredicateExpression: tc.PredicateExpression,
+			Labels:              tc.Labels,
+		})
+		switch {
+		case err == nil:
+			kss, err = types.ResourcesWithLabels(resources).AsServers()
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		// ListResources for kube service not available, provide fallback.
+		// Fallback does not support filters, so if users provide them, it
+		// does nothing.
+		//
+		// DELETE IN 11.0.0
+		case trace.IsNotImplemented(err):
+			kss, err = ac.GetKubeServices(ctx)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		case utils.IsPredicateError(err):
+			return trace.Wrap(utils.PredicateError{Err: err})
+		default:
+			return trace.Wrap(err)
+		}
+
+		byName := make(map[string]*kubeClusterInfo)
+		for _, ks := range kss {
+			// The heartbeat keeps the kube service resource alive for the
+			// announce TTL, so the expiry reveals when it last checked in.
+			var heartbeat time.Time
+			if !ks.Expiry().IsZero() {
+				heartbeat = ks.Expiry().Add(-apidefaults.ServerAnnounceTTL)
+			}
+			for _, kc := range ks.GetKubernetesClusters() {
+				info, ok := byName[kc.Name]
+				if !ok {
+					info = &kubeClusterInfo{
+						KubeClusterName: kc.Name,
+						Labels:          types.CombineLabels(kc.StaticLabels, kc.DynamicLabels),
+					}
+					byName[kc.Name] = info
+				}
+				if heartbeat.After(info.LastHeartbeat) {
+					info.LastHeartbeat = heartbeat
+				}
+			}
+		}
+		clusters = make([]kubeClusterInfo, 0, len(byName))
+		for _, info := range byName {
+			clusters = append(clusters, *info)
+		}
+		sort.Slice(clusters, func(i, j int) bool {
+			return clusters[i].KubeClusterName < clusters[j].KubeClusterName
+		})
+		return nil
+	})
+	if err != nil {
+		return "", nil, trace.Wrap(err)
+	}
+	return teleportCluster, clusters, nil
+}
+
 // kubernetesStatus holds teleport client information necessary to populate the user's kubeconfig.
 type kubernetesStatus struct {
 	clusterAddr         string
@@ -903,6 +1516,10 @@ func buildKubeConfigUpdate(cf *CLIConf, kubeStatus *kubernetesStatus) (*kubeconf
 		Credentials:         kubeStatus.credentials,
 		ProxyAddr:           cf.Proxy,
 		TLSServerName:       kubeStatus.tlsServerName,
+		Impersonate:         cf.kubernetesImpersonationConfig.kubernetesUser,
+		ImpersonateGroups:   cf.kubernetesImpersonationConfig.kubernetesGroups,
+		Namespace:           cf.kubeNamespace,
+		ContextNameTemplate: cf.kubeContextNameTemplate,
 	}
 
 	if cf.executablePath == "" {
@@ -17,9 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/gravitational/teleport/lib/client"
 )
@@ -75,3 +79,66 @@ func TestGetKubeTLSServerName(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKubeCopySpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		wantPod  string
+		wantPath string
+	}{
+		{spec: "nginx:/var/log/nginx", wantPod: "nginx", wantPath: "/var/log/nginx"},
+		{spec: "nginx:relative/path", wantPod: "nginx", wantPath: "relative/path"},
+		{spec: "/tmp/local", wantPod: "", wantPath: "/tmp/local"},
+		{spec: `C:\temp\file`, wantPod: "", wantPath: `C:\temp\file`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			pod, path := parseKubeCopySpec(tt.spec)
+			require.Equal(t, tt.wantPod, pod)
+			require.Equal(t, tt.wantPath, path)
+		})
+	}
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "top.txt"), []byte("top"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "sub", "nested.txt"), []byte("nested"), 0600))
+
+	var buf bytes.Buffer
+	require.NoError(t, tarPath(&buf, srcDir, "copied"))
+
+	dstDir := t.TempDir()
+	require.NoError(t, untarStream(&buf, dstDir))
+
+	top, err := os.ReadFile(filepath.Join(dstDir, "copied", "top.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "top", string(top))
+	nested, err := os.ReadFile(filepath.Join(dstDir, "copied", "sub", "nested.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "nested", string(nested))
+}
+
+func TestStaleKubeContexts(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Contexts = map[string]*clientcmdapi.Context{
+		"main-prod":      {Cluster: "main", AuthInfo: "main"},
+		"main-staging":   {Cluster: "main", AuthInfo: "main"},
+		"main-gone":      {Cluster: "main", AuthInfo: "main"},
+		"leaf-prod":      {Cluster: "leaf", AuthInfo: "leaf"},
+		"custom-context": {Cluster: "elsewhere", AuthInfo: "elsewhere"},
+	}
+
+	stale := staleKubeContexts(config, "main", []string{"prod", "staging"})
+	require.Equal(t, []string{"main-gone"}, stale)
+
+	// All contexts accessible, nothing to prune.
+	stale = staleKubeContexts(config, "main", []string{"prod", "staging", "gone"})
+	require.Empty(t, stale)
+
+	// Contexts of other Teleport clusters or with custom names are ignored
+	// even if nothing is accessible.
+	stale = staleKubeContexts(config, "other", nil)
+	require.Empty(t, stale)
+}
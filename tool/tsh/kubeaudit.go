@@ -0,0 +1,95 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/api/profile"
+)
+
+// kubeCredentialLogFile is the profile-local file where credential issuances
+// triggered through the kubeconfig exec plugin are appended as JSON lines.
+// kubectl invokes "tsh kube credentials" behind the user's back, so this
+// trail lets security teams attribute kube API activity to the local tool
+// that requested the certificate. Server-side audit events already cover the
+// issuance itself; the caller attribution only exists client-side.
+const kubeCredentialLogFile = "kube_credentials.log"
+
+// kubeCredentialEvent is the record written for each credential request
+// served to a kubeconfig exec plugin caller.
+type kubeCredentialEvent struct {
+	Time            time.Time `json:"time"`
+	TeleportCluster string    `json:"teleport_cluster"`
+	KubeCluster     string    `json:"kube_cluster"`
+	// Reused is true when a cached certificate was served without
+	// contacting the cluster.
+	Reused    bool   `json:"reused"`
+	CallerPID int    `json:"caller_pid,omitempty"`
+	Caller    string `json:"caller,omitempty"`
+}
+
+// logKubeCredentialIssuance records that "tsh kube credentials" served a
+// certificate, including the calling process where the platform exposes it.
+// Recording is best-effort and never fails the credential request.
+func logKubeCredentialIssuance(cf *CLIConf, teleportCluster, kubeCluster string, reused bool) {
+	event := kubeCredentialEvent{
+		Time:            time.Now().UTC(),
+		TeleportCluster: teleportCluster,
+		KubeCluster:     kubeCluster,
+		Reused:          reused,
+	}
+	event.CallerPID, event.Caller = callerProcessInfo()
+
+	log.Debugf("Serving kube credentials for cluster %q to caller %q (pid %v).",
+		kubeCluster, event.Caller, event.CallerPID)
+
+	path := filepath.Join(profile.FullProfilePath(cf.HomePath), kubeCredentialLogFile)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		log.WithError(err).Debugf("Failed to open kube credential log %v.", path)
+		return
+	}
+	defer f.Close()
+	line, err := json.Marshal(event)
+	if err != nil {
+		log.WithError(err).Debug("Failed to encode kube credential event.")
+		return
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.WithError(err).Debugf("Failed to write kube credential log %v.", path)
+	}
+}
+
+// callerProcessInfo returns the pid and command line of the process that
+// invoked tsh, read from /proc where available. On platforms without /proc
+// only the pid is returned.
+func callerProcessInfo() (int, string) {
+	pid := os.Getppid()
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%v/cmdline", pid))
+	if err != nil {
+		return pid, ""
+	}
+	// The kernel separates and terminates cmdline arguments with NULs.
+	cmdline := strings.TrimRight(string(data), "\x00")
+	return pid, strings.ReplaceAll(cmdline, "\x00", " ")
+}
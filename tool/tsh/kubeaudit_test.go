@@ -0,0 +1,49 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogKubeCredentialIssuance(t *testing.T) {
+	cf := &CLIConf{HomePath: t.TempDir()}
+
+	logKubeCredentialIssuance(cf, "root", "kube-prod", true)
+	logKubeCredentialIssuance(cf, "root", "kube-prod", false)
+
+	data, err := os.ReadFile(filepath.Join(cf.HomePath, kubeCredentialLogFile))
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var event kubeCredentialEvent
+	require.NoError(t, json.Unmarshal(lines[0], &event))
+	require.Equal(t, "root", event.TeleportCluster)
+	require.Equal(t, "kube-prod", event.KubeCluster)
+	require.True(t, event.Reused)
+	require.Equal(t, os.Getppid(), event.CallerPID)
+
+	require.NoError(t, json.Unmarshal(lines[1], &event))
+	require.False(t, event.Reused)
+}
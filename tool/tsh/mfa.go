@@ -19,10 +19,12 @@ package main
 import (
 	"context"
 	"encoding/base32"
+	"encoding/json"
 	"fmt"
 	"image/png"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -33,6 +35,7 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/client/proto"
 	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/api/profile"
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/lib/asciitable"
 	"github.com/gravitational/teleport/lib/auth/touchid"
@@ -69,20 +72,54 @@ func initWebDevs() []string {
 }
 
 type mfaCommands struct {
-	ls  *mfaLSCommand
-	add *mfaAddCommand
-	rm  *mfaRemoveCommand
+	ls     *mfaLSCommand
+	add    *mfaAddCommand
+	rm     *mfaRemoveCommand
+	rename *mfaRenameCommand
 }
 
 func newMFACommand(app *kingpin.Application) mfaCommands {
 	mfa := app.Command("mfa", "Manage multi-factor authentication (MFA) devices.")
 	return mfaCommands{
-		ls:  newMFALSCommand(mfa),
-		add: newMFAAddCommand(mfa),
-		rm:  newMFARemoveCommand(mfa),
+		ls:     newMFALSCommand(mfa),
+		add:    newMFAAddCommand(mfa),
+		rm:     newMFARemoveCommand(mfa),
+		rename: newMFARenameCommand(mfa),
 	}
 }
 
+// mfaNicknamesPath returns the path of the file holding client-side MFA
+// device nicknames, keyed by device name.
+func mfaNicknamesPath(homePath string) string {
+	return filepath.Join(profile.FullProfilePath(homePath), "mfa_nicknames.json")
+}
+
+// loadMFANicknames reads the client-side device nickname map. A missing file
+// is not an error, nicknames are optional.
+func loadMFANicknames(homePath string) (map[string]string, error) {
+	data, err := os.ReadFile(mfaNicknamesPath(homePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]string), nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	nicknames := make(map[string]string)
+	if err := json.Unmarshal(data, &nicknames); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return nicknames, nil
+}
+
+// saveMFANicknames writes the client-side device nickname map.
+func saveMFANicknames(homePath string, nicknames map[string]string) error {
+	data, err := json.Marshal(nicknames)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.ConvertSystemError(os.WriteFile(mfaNicknamesPath(homePath), data, 0600))
+}
+
 type mfaLSCommand struct {
 	*kingpin.CmdClause
 	verbose bool
@@ -130,10 +167,15 @@ func (c *mfaLSCommand) run(cf *CLIConf) error {
 	// Sort by name before printing.
 	sort.Slice(devs, func(i, j int) bool { return devs[i].GetName() < devs[j].GetName() })
 
+	nicknames, err := loadMFANicknames(cf.HomePath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
 	format := strings.ToLower(c.format)
 	switch format {
 	case teleport.Text, "":
-		printMFADevices(devs, c.verbose)
+		printMFADevices(devs, nicknames, c.verbose)
 	case teleport.JSON, teleport.YAML:
 		out, err := serializeMFADevices(devs, format)
 		if err != nil {
@@ -158,12 +200,13 @@ func serializeMFADevices(devs []*types.MFADevice, format string) (string, error)
 	return string(out), trace.Wrap(err)
 }
 
-func printMFADevices(devs []*types.MFADevice, verbose bool) {
+func printMFADevices(devs []*types.MFADevice, nicknames map[string]string, verbose bool) {
 	if verbose {
-		t := asciitable.MakeTable([]string{"Name", "ID", "Type", "Added at", "Last used"})
+		t := asciitable.MakeTable([]string{"Name", "Nickname", "ID", "Type", "Added at", "Last used"})
 		for _, dev := range devs {
 			t.AddRow([]string{
 				dev.Metadata.Name,
+				nicknames[dev.Metadata.Name],
 				dev.Id,
 				dev.MFAType(),
 				dev.AddedAt.Format(time.RFC1123),
@@ -172,10 +215,11 @@ func printMFADevices(devs []*types.MFADevice, verbose bool) {
 		}
 		fmt.Println(t.AsBuffer().String())
 	} else {
-		t := asciitable.MakeTable([]string{"Name", "Type", "Added at", "Last used"})
+		t := asciitable.MakeTable([]string{"Name", "Nickname", "Type", "Added at", "Last used"})
 		for _, dev := range devs {
 			t.AddRow([]string{
 				dev.GetName(),
+				nicknames[dev.GetName()],
 				dev.MFAType(),
 				dev.AddedAt.Format(time.RFC1123),
 				dev.LastUsed.Format(time.RFC1123),
@@ -537,6 +581,19 @@ func (c *mfaRemoveCommand) run(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
+	// Resolve a client-side nickname to the real device name. Removal is
+	// verified with an existing factor by the server below.
+	nicknames, err := loadMFANicknames(cf.HomePath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for name, nickname := range nicknames {
+		if nickname == c.name {
+			c.name = name
+			break
+		}
+	}
+
 	if err := client.RetryWithRelogin(cf.Context, tc, func() error {
 		pc, err := tc.ConnectToProxy(cf.Context)
 		if err != nil {
@@ -595,10 +652,86 @@ func (c *mfaRemoveCommand) run(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
+	// Drop the nickname of the removed device, if any.
+	if _, ok := nicknames[c.name]; ok {
+		delete(nicknames, c.name)
+		if err := saveMFANicknames(cf.HomePath, nicknames); err != nil {
+			log.WithError(err).Debug("Failed to update MFA device nicknames.")
+		}
+	}
+
 	fmt.Printf("MFA device %q removed.\n", c.name)
 	return nil
 }
 
+type mfaRenameCommand struct {
+	*kingpin.CmdClause
+	name     string
+	nickname string
+}
+
+func newMFARenameCommand(parent *kingpin.CmdClause) *mfaRenameCommand {
+	c := &mfaRenameCommand{
+		CmdClause: parent.Command("rename", "Set a nickname for an MFA device on this machine"),
+	}
+	c.Arg("name", "Name of the MFA device to rename").Required().StringVar(&c.name)
+	c.Arg("nickname", "Nickname to set for the device").Required().StringVar(&c.nickname)
+	return c
+}
+
+func (c *mfaRenameCommand) run(cf *CLIConf) error {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// Check the device exists before recording a nickname for it.
+	var devs []*types.MFADevice
+	if err := client.RetryWithRelogin(cf.Context, tc, func() error {
+		pc, err := tc.ConnectToProxy(cf.Context)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer pc.Close()
+		aci, err := pc.ConnectToRootCluster(cf.Context, false)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer aci.Close()
+
+		resp, err := aci.GetMFADevices(cf.Context, &proto.GetMFADevicesRequest{})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		devs = resp.Devices
+		return nil
+	}); err != nil {
+		return trace.Wrap(err)
+	}
+	found := false
+	for _, dev := range devs {
+		if dev.GetName() == c.name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return trace.NotFound("MFA device %q not found, run 'tsh mfa ls' to see registered devices", c.name)
+	}
+
+	nicknames, err := loadMFANicknames(cf.HomePath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	nicknames[c.name] = c.nickname
+	if err := saveMFANicknames(cf.HomePath, nicknames); err != nil {
+		return trace.Wrap(err)
+	}
+
+	fmt.Printf("MFA device %q is now shown as %q. Nicknames are local to this machine.\n", c.name, c.nickname)
+	return nil
+}
+
 func showOTPQRCode(k *otp.Key) (cleanup func(), retErr error) {
 	var imageViewer string
 	switch runtime.GOOS {
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// oidcTokenHeader is the HTTP header carrying the OIDC workload identity
+	// token on requests to the proxy.
+	oidcTokenHeader = "Teleport-Workload-Token"
+
+	// maxOIDCTokenSize is the maximum accepted size of the workload identity
+	// token file, OIDC tokens are small JWTs.
+	maxOIDCTokenSize = 64 * 1024
+)
+
+// addOIDCWorkloadToken reads the OIDC workload identity token (issued to a
+// CI job by GitHub Actions, GitLab CI and the like) from the file given with
+// --oidc-token-file and attaches it to the requests the client sends to the
+// proxy during login.
+func addOIDCWorkloadToken(cf *CLIConf, tc *client.TeleportClient) error {
+	if cf.OIDCTokenFile == "" {
+		return nil
+	}
+	token, err := readOIDCToken(cf.OIDCTokenFile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if tc.ExtraProxyHeaders == nil {
+		tc.ExtraProxyHeaders = map[string]string{}
+	}
+	tc.ExtraProxyHeaders[oidcTokenHeader] = token
+	return nil
+}
+
+// readOIDCToken reads and validates the workload identity token file. The
+// token must be a JWT; validating the shape early gives CI jobs a clear
+// error instead of a rejected login.
+func readOIDCToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", trace.BadParameter("OIDC token file %v is empty", path)
+	}
+	if len(token) > maxOIDCTokenSize {
+		return "", trace.BadParameter("OIDC token file %v is larger than %v bytes", path, maxOIDCTokenSize)
+	}
+	if !isJWT(token) {
+		return "", trace.BadParameter("OIDC token file %v does not contain a JWT", path)
+	}
+	return token, nil
+}
+
+// isJWT reports whether the token has the three base64url-encoded segments
+// of a JWT, with a JSON object in the header segment.
+func isJWT(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	var decoded map[string]interface{}
+	return json.Unmarshal(header, &decoded) == nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOIDCToken(t *testing.T) {
+	dir := t.TempDir()
+	write := func(contents string) string {
+		path := filepath.Join(dir, "token")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"repo:org/repo"}`))
+	jwt := fmt.Sprintf("%v.%v.signature", header, payload)
+
+	token, err := readOIDCToken(write(jwt + "\n"))
+	require.NoError(t, err)
+	require.Equal(t, jwt, token)
+
+	_, err = readOIDCToken(write(""))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "empty")
+
+	_, err = readOIDCToken(write("not-a-jwt"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "JWT")
+
+	_, err = readOIDCToken(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
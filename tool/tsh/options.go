@@ -129,9 +129,9 @@ type Options struct {
 	// are "yes", "no", and "local".
 	ForwardAgent client.AgentForwardingMode
 
-	// RequestTTY specifies whether to request a pseudo-tty for the session.
-	// Supported option values are "yes" and "no".
-	RequestTTY bool
+	// RequestTTY specifies when to request a pseudo-tty for the session.
+	// Supported option values are "yes", "no", "force" and "auto".
+	RequestTTY client.TTYRequestMode
 
 	// StrictHostKeyChecking is used control if tsh will automatically add host
 	// keys to the ~/.tsh/known_hosts file. Supported option values are "yes"
@@ -217,11 +217,18 @@ func setForwardX11TrustedOption(o *Options, val string) error {
 }
 
 func setRequestTTYOption(o *Options, val string) error {
-	parsedValue, err := parseBoolOption(val)
-	if err != nil {
-		return trace.Wrap(err)
+	switch strings.ToLower(val) {
+	case "auto":
+		o.RequestTTY = client.TTYRequestAuto
+	case "yes":
+		o.RequestTTY = client.TTYRequestYes
+	case "force":
+		o.RequestTTY = client.TTYRequestForce
+	case "no":
+		o.RequestTTY = client.TTYRequestNo
+	default:
+		return trace.BadParameter("invalid RequestTTY mode: %q, supported are: yes, no, force, auto", val)
 	}
-	o.RequestTTY = parsedValue
 	return nil
 }
 
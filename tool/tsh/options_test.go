@@ -107,6 +107,44 @@ func TestOptions(t *testing.T) {
 			inOptions:   []string{"ForwardAgent potato"},
 			assertError: require.Error,
 		},
+		// RequestTTY tests
+		{
+			desc:        "Request TTY Yes",
+			inOptions:   []string{"RequestTTY yes"},
+			assertError: require.NoError,
+			assertOptions: func(t *testing.T, opts Options) {
+				require.Equal(t, client.TTYRequestYes, opts.RequestTTY)
+			},
+		},
+		{
+			desc:        "Request TTY No",
+			inOptions:   []string{"RequestTTY no"},
+			assertError: require.NoError,
+			assertOptions: func(t *testing.T, opts Options) {
+				require.Equal(t, client.TTYRequestNo, opts.RequestTTY)
+			},
+		},
+		{
+			desc:        "Request TTY Force",
+			inOptions:   []string{"RequestTTY force"},
+			assertError: require.NoError,
+			assertOptions: func(t *testing.T, opts Options) {
+				require.Equal(t, client.TTYRequestForce, opts.RequestTTY)
+			},
+		},
+		{
+			desc:        "Request TTY Auto",
+			inOptions:   []string{"RequestTTY auto"},
+			assertError: require.NoError,
+			assertOptions: func(t *testing.T, opts Options) {
+				require.Equal(t, client.TTYRequestAuto, opts.RequestTTY)
+			},
+		},
+		{
+			desc:        "Request TTY InvalidValue",
+			inOptions:   []string{"RequestTTY potato"},
+			assertError: require.Error,
+		},
 		// ForwardX11 tests
 		{
 			desc:        "Forward X11",
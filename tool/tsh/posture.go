@@ -0,0 +1,94 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os/exec"
+	"time"
+
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// devicePostureHeader is the HTTP header carrying the base64-encoded
+	// device posture attestation on requests to the proxy.
+	devicePostureHeader = "Teleport-Device-Attestation"
+
+	// defaultPostureTimeout bounds the posture check run time when the
+	// configuration doesn't specify a timeout.
+	defaultPostureTimeout = 30 * time.Second
+
+	// maxPostureOutputSize is the maximum accepted size of the posture check
+	// output, attestations are expected to be small documents.
+	maxPostureOutputSize = 16 * 1024
+)
+
+// addDevicePostureAttestation runs the configured device posture check binary
+// and attaches its attestation to the requests the client sends to the proxy.
+// A failed check aborts the login only when the check is marked as required.
+func addDevicePostureAttestation(cf *CLIConf, tc *client.TeleportClient) error {
+	config := cf.DevicePosture
+	if config == nil || config.Command == "" {
+		return nil
+	}
+	attestation, err := runDevicePostureCheck(cf.Context, config)
+	if err != nil {
+		if config.Required {
+			return trace.Wrap(err, "device posture check failed")
+		}
+		log.WithError(err).Warn("Device posture check failed, continuing without attestation.")
+		return nil
+	}
+	if tc.ExtraProxyHeaders == nil {
+		tc.ExtraProxyHeaders = map[string]string{}
+	}
+	tc.ExtraProxyHeaders[devicePostureHeader] = attestation
+	return nil
+}
+
+// runDevicePostureCheck executes the posture check binary and returns its
+// standard output as a base64-encoded attestation suitable for an HTTP
+// header.
+func runDevicePostureCheck(ctx context.Context, config *DevicePostureConfig) (string, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultPostureTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, config.Command, config.Args...)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", trace.Wrap(err, "running posture check command %q", config.Command)
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	if len(output) == 0 {
+		return "", trace.BadParameter("posture check command %q produced no output", config.Command)
+	}
+	if len(output) > maxPostureOutputSize {
+		return "", trace.BadParameter("posture check command %q produced more than %v bytes of output", config.Command, maxPostureOutputSize)
+	}
+	return base64.StdEncoding.EncodeToString(output), nil
+}
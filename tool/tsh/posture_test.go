@@ -0,0 +1,55 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDevicePostureCheck(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("output is encoded", func(t *testing.T) {
+		attestation, err := runDevicePostureCheck(ctx, &DevicePostureConfig{
+			Command: "sh",
+			Args:    []string{"-c", `echo '{"disk_encrypted":true}'`},
+		})
+		require.NoError(t, err)
+		decoded, err := base64.StdEncoding.DecodeString(attestation)
+		require.NoError(t, err)
+		require.Equal(t, `{"disk_encrypted":true}`, string(decoded))
+	})
+
+	t.Run("command failure", func(t *testing.T) {
+		_, err := runDevicePostureCheck(ctx, &DevicePostureConfig{
+			Command: "sh",
+			Args:    []string{"-c", "exit 1"},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("empty output", func(t *testing.T) {
+		_, err := runDevicePostureCheck(ctx, &DevicePostureConfig{
+			Command: "true",
+		})
+		require.Error(t, err)
+	})
+}
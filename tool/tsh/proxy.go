@@ -31,6 +31,7 @@ import (
 
 	"github.com/gravitational/trace"
 
+	apidefaults "github.com/gravitational/teleport/api/defaults"
 	"github.com/gravitational/teleport/api/profile"
 	"github.com/gravitational/teleport/api/utils/keypaths"
 	"github.com/gravitational/teleport/lib/client"
@@ -50,6 +51,7 @@ import (
 // from destination node address to support multiple platform where 'cut -d' command is not provided.
 // For more details please look at: Generate Windows-compatible OpenSSH config https://github.com/gravitational/teleport/pull/7848
 func onProxyCommandSSH(cf *CLIConf) error {
+	start := time.Now()
 	tc, err := makeClient(cf, false)
 	if err != nil {
 		return trace.Wrap(err)
@@ -61,11 +63,74 @@ func onProxyCommandSSH(cf *CLIConf) error {
 	}
 	targetHost = cleanTargetHost(targetHost, tc.WebProxyHost(), tc.SiteName)
 
+	var clog *connLog
+	if cf.ProxySSHLogFile != "" {
+		clog, err = newConnLog(cf.ProxySSHLogFile, net.JoinHostPort(targetHost, targetPort))
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer clog.Close()
+		clog.stage("client_init", start, nil)
+		probeProxyConnection(cf, tc, clog)
+	}
+
 	if tc.TLSRoutingEnabled {
-		return trace.Wrap(sshProxyWithTLSRouting(cf, tc, targetHost, targetPort))
+		start = time.Now()
+		err = sshProxyWithTLSRouting(cf, tc, targetHost, targetPort)
+		clog.stage("session", start, err)
+		return trace.Wrap(err)
+	}
+
+	start = time.Now()
+	err = sshProxy(tc, targetHost, targetPort)
+	clog.stage("session", start, err)
+	return trace.Wrap(err)
+}
+
+// probeProxyConnection measures the stages of reaching the proxy (DNS
+// resolution, TCP dial and, with TLS routing, the TLS handshake) and records
+// them in the connection log. The probe is only run when connection logging
+// was requested, and its failures are recorded rather than returned: the
+// real connection that follows will surface them to the caller.
+func probeProxyConnection(cf *CLIConf, tc *libclient.TeleportClient, clog *connLog) {
+	proxyAddr := tc.WebProxyAddr
+	if !tc.TLSRoutingEnabled {
+		host, port := tc.SSHProxyHostPort()
+		proxyAddr = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	host, _, err := net.SplitHostPort(proxyAddr)
+	if err != nil {
+		clog.stage("dns", time.Now(), err)
+		return
+	}
+
+	if net.ParseIP(host) == nil {
+		start := time.Now()
+		_, err := net.LookupHost(host)
+		clog.stage("dns", start, err)
+		if err != nil {
+			return
+		}
 	}
 
-	return trace.Wrap(sshProxy(tc, targetHost, targetPort))
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", proxyAddr, apidefaults.DefaultDialTimeout)
+	clog.stage("tunnel_dial", start, err)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if tc.TLSRoutingEnabled {
+		start = time.Now()
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: cf.InsecureSkipVerify,
+			NextProtos:         []string{string(alpncommon.ProtocolProxySSH)},
+		})
+		err = tlsConn.Handshake()
+		clog.stage("tls_handshake", start, err)
+	}
 }
 
 // cleanTargetHost cleans the targetHost and remote site and proxy suffixes.
@@ -161,13 +226,33 @@ func onProxyCommandDB(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
-	addr := "localhost:0"
-	if cf.LocalProxyPort != "" {
-		addr = fmt.Sprintf("127.0.0.1:%s", cf.LocalProxyPort)
-	}
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		return trace.Wrap(err)
+	var listener net.Listener
+	if cf.LocalProxySocket != "" {
+		if cf.LocalProxyPort != "" {
+			return trace.BadParameter("--port and --socket cannot both be set")
+		}
+		if cf.LocalProxyTunnel {
+			return trace.BadParameter("--socket cannot be combined with --tunnel, database clients cannot be pointed at a socket file automatically")
+		}
+		listener, err = net.Listen("unix", cf.LocalProxySocket)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// Let file permissions gate access to the tunnel: only the
+		// owner may connect.
+		if err := os.Chmod(cf.LocalProxySocket, 0600); err != nil {
+			listener.Close()
+			return trace.ConvertSystemError(err)
+		}
+	} else {
+		addr := "localhost:0"
+		if cf.LocalProxyPort != "" {
+			addr = fmt.Sprintf("127.0.0.1:%s", cf.LocalProxyPort)
+		}
+		listener, err = net.Listen("tcp", addr)
+		if err != nil {
+			return trace.Wrap(err)
+		}
 	}
 	defer func() {
 		if err := listener.Close(); err != nil {
@@ -189,6 +274,17 @@ func onProxyCommandDB(cf *CLIConf) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	unregister, err := registerListener(cf, "proxy db", routeToDatabase.ServiceName, lp.GetAddr())
+	if err != nil {
+		log.WithError(err).Debug("Failed to register local listener.")
+	} else {
+		defer unregister()
+	}
+	emitStreamEvent(cf, "proxy_listening", map[string]interface{}{
+		"address":  lp.GetAddr(),
+		"database": routeToDatabase.ServiceName,
+		"protocol": routeToDatabase.Protocol,
+	})
 	go func() {
 		<-cf.Context.Done()
 		lp.Close()
@@ -297,6 +393,21 @@ func mkLocalProxyCerts(certFile, keyFile string) ([]tls.Certificate, error) {
 }
 
 func onProxyCommandApp(cf *CLIConf) error {
+	return trace.Wrap(runLocalProxyApp(cf, alpncommon.ProtocolHTTP))
+}
+
+// onProxyCommandGRPC exposes a local plaintext HTTP/2 listener for a gRPC
+// application behind app access. The local proxy forwards raw connection
+// bytes, so streaming RPCs and trailers pass through unmodified, which lets
+// grpcurl and IDE clients talk to the upstream service.
+func onProxyCommandGRPC(cf *CLIConf) error {
+	return trace.Wrap(runLocalProxyApp(cf, alpncommon.ProtocolHTTP2))
+}
+
+// runLocalProxyApp starts a local listener forwarding connections to the
+// given application with the requested ALPN protocol and blocks until the
+// parent context is canceled.
+func runLocalProxyApp(cf *CLIConf, protocol alpncommon.Protocol) error {
 	tc, err := makeClient(cf, false)
 	if err != nil {
 		return trace.Wrap(err)
@@ -325,7 +436,7 @@ func onProxyCommandApp(cf *CLIConf) error {
 	lp, err := alpnproxy.NewLocalProxy(alpnproxy.LocalProxyConfig{
 		Listener:           listener,
 		RemoteProxyAddr:    tc.WebProxyAddr,
-		Protocols:          []alpncommon.Protocol{alpncommon.ProtocolHTTP},
+		Protocols:          []alpncommon.Protocol{protocol},
 		InsecureSkipVerify: cf.InsecureSkipVerify,
 		ParentContext:      cf.Context,
 		SNI:                address.Host(),
@@ -340,6 +451,17 @@ func onProxyCommandApp(cf *CLIConf) error {
 
 	fmt.Printf("Proxying connections to %s on %v\n", cf.AppName, lp.GetAddr())
 
+	kind := "proxy app"
+	if protocol == alpncommon.ProtocolHTTP2 {
+		kind = "proxy grpc"
+	}
+	unregister, err := registerListener(cf, kind, cf.AppName, lp.GetAddr())
+	if err != nil {
+		log.WithError(err).Debug("Failed to register local listener.")
+	} else {
+		defer unregister()
+	}
+
 	go func() {
 		<-cf.Context.Done()
 		lp.Close()
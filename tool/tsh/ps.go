@@ -0,0 +1,196 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/teleport/api/profile"
+	"github.com/gravitational/teleport/lib/asciitable"
+
+	"github.com/gravitational/trace"
+)
+
+// listenerRecord describes a tsh process holding a local listener, such as a
+// local proxy started with 'tsh proxy db/app' or an ssh port forward. Records
+// are written to the profile directory so other tsh processes can enumerate
+// and clean them up with 'tsh ps' and 'tsh kill'.
+type listenerRecord struct {
+	// PID is the process ID of the tsh process holding the listener.
+	PID int `json:"pid"`
+	// Kind describes the type of listener, e.g. "proxy db" or "forward".
+	Kind string `json:"kind"`
+	// Target is what the listener forwards to, e.g. a database service name.
+	Target string `json:"target"`
+	// ListenAddr is the local address the listener is bound to.
+	ListenAddr string `json:"listen_addr"`
+	// StartedAt is when the listener was started.
+	StartedAt time.Time `json:"started_at"`
+}
+
+// listenerRegistryDir returns the directory in the profile directory where
+// listener records are stored.
+func listenerRegistryDir(homePath string) string {
+	return filepath.Join(profile.FullProfilePath(homePath), "listeners")
+}
+
+// registerListener records the current process in the listener registry and
+// returns a cleanup function removing the record on graceful shutdown.
+// Records of processes that die without cleaning up are pruned by 'tsh ps'.
+func registerListener(cf *CLIConf, kind, target, listenAddr string) (func(), error) {
+	dir := listenerRegistryDir(cf.HomePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	r := listenerRecord{
+		PID:        os.Getpid(),
+		Kind:       kind,
+		Target:     target,
+		ListenAddr: listenAddr,
+		StartedAt:  time.Now().UTC(),
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%v.json", r.PID))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return func() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).Debug("Failed to remove listener record.")
+		}
+	}, nil
+}
+
+// listListeners returns the records of live tsh listener processes, pruning
+// records left behind by processes that are no longer running.
+func listListeners(homePath string) ([]listenerRecord, error) {
+	dir := listenerRegistryDir(homePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var records []listenerRecord
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var r listenerRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			log.WithError(err).Debugf("Removing malformed listener record %v.", path)
+			os.Remove(path)
+			continue
+		}
+		if !processAlive(r.PID) {
+			log.Debugf("Pruning listener record of dead process %v.", r.PID)
+			os.Remove(path)
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// processAlive reports whether the process with the given PID is still
+// running.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	if err == nil {
+		return true
+	}
+	return !errors.Is(err, os.ErrProcessDone) && !errors.Is(err, syscall.ESRCH)
+}
+
+// onPS executes the 'tsh ps' command, listing local proxies and port
+// forwards started by tsh.
+func onPS(cf *CLIConf) error {
+	records, err := listListeners(cf.HomePath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(records) == 0 {
+		fmt.Println("No local proxies or port forwards are running.")
+		return nil
+	}
+	t := asciitable.MakeTable([]string{"PID", "Kind", "Target", "Address", "Started"})
+	for _, r := range records {
+		t.AddRow([]string{
+			fmt.Sprintf("%v", r.PID),
+			r.Kind,
+			r.Target,
+			r.ListenAddr,
+			r.StartedAt.Local().Format(time.RFC822),
+		})
+	}
+	fmt.Println(t.AsBuffer().String())
+	return nil
+}
+
+// onKill executes the 'tsh kill' command, stopping listener processes
+// recorded in the registry.
+func onKill(cf *CLIConf) error {
+	if !cf.KillAll && cf.KillPID == 0 {
+		return trace.BadParameter("specify a PID from 'tsh ps' or use --all")
+	}
+	records, err := listListeners(cf.HomePath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var targets []listenerRecord
+	for _, r := range records {
+		if cf.KillAll || r.PID == cf.KillPID {
+			targets = append(targets, r)
+		}
+	}
+	if len(targets) == 0 {
+		if cf.KillAll {
+			fmt.Println("No local proxies or port forwards are running.")
+			return nil
+		}
+		return trace.NotFound("no listener with PID %v found, check 'tsh ps'", cf.KillPID)
+	}
+	for _, r := range targets {
+		process, err := os.FindProcess(r.PID)
+		if err != nil {
+			continue
+		}
+		if err := process.Signal(syscall.SIGTERM); err != nil {
+			if err := process.Kill(); err != nil {
+				return trace.Wrap(err, "failed to stop process %v", r.PID)
+			}
+		}
+		fmt.Printf("Stopped %v %v (pid %v).\n", r.Kind, r.Target, r.PID)
+	}
+	return nil
+}
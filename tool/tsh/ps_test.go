@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenerRegistry(t *testing.T) {
+	cf := &CLIConf{HomePath: t.TempDir()}
+
+	unregister, err := registerListener(cf, "proxy db", "postgres", "127.0.0.1:12345")
+	require.NoError(t, err)
+
+	records, err := listListeners(cf.HomePath)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, os.Getpid(), records[0].PID)
+	require.Equal(t, "proxy db", records[0].Kind)
+	require.Equal(t, "postgres", records[0].Target)
+	require.Equal(t, "127.0.0.1:12345", records[0].ListenAddr)
+
+	// Records of dead processes are pruned on listing.
+	dead := listenerRecord{
+		PID:        1 << 30, // does not exist
+		Kind:       "forward",
+		Target:     "node1",
+		ListenAddr: "8080:localhost:8080",
+		StartedAt:  time.Now().UTC(),
+	}
+	data, err := json.Marshal(dead)
+	require.NoError(t, err)
+	deadPath := filepath.Join(listenerRegistryDir(cf.HomePath), "1073741824.json")
+	require.NoError(t, os.WriteFile(deadPath, data, 0600))
+
+	records, err = listListeners(cf.HomePath)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.NoFileExists(t, deadPath)
+
+	unregister()
+	records, err = listListeners(cf.HomePath)
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/auth"
+	libclient "github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/utils/prompt"
+
+	"github.com/gravitational/trace"
+)
+
+// reasonFieldPattern matches {field} placeholders in a role's request prompt,
+// turning the prompt into a reason template with required fields, e.g.
+// "Ticket: {ticket}, needed for {duration}".
+var reasonFieldPattern = regexp.MustCompile(`\{([a-zA-Z0-9_-]+)\}`)
+
+// reasonTemplateFields returns the names of the {field} placeholders in the
+// template, in order of appearance and without duplicates.
+func reasonTemplateFields(template string) []string {
+	var fields []string
+	seen := make(map[string]struct{})
+	for _, match := range reasonFieldPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := seen[match[1]]; ok {
+			continue
+		}
+		seen[match[1]] = struct{}{}
+		fields = append(fields, match[1])
+	}
+	return fields
+}
+
+// requestReasonTemplate returns the reason template advertised by the user's
+// roles via the request_prompt role option, empty if no role defines one with
+// required fields.
+func requestReasonTemplate(cf *CLIConf, tc *libclient.TeleportClient) (string, error) {
+	profile, err := libclient.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var template string
+	err = tc.WithRootClusterClient(cf.Context, func(clt auth.ClientI) error {
+		for _, roleName := range profile.Roles {
+			role, err := clt.GetRole(cf.Context, roleName)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			rolePrompt := role.GetOptions().RequestPrompt
+			if rolePrompt != "" && len(reasonTemplateFields(rolePrompt)) > 0 {
+				template = rolePrompt
+				return nil
+			}
+		}
+		return nil
+	})
+	return template, trace.Wrap(err)
+}
+
+// applyReasonTemplate enforces the server-delivered reason template on a new
+// access request. A reason passed on the command line must mention every
+// required field; with no reason given, the user is prompted for each field
+// and the rendered template becomes the request reason.
+func applyReasonTemplate(cf *CLIConf, tc *libclient.TeleportClient) error {
+	template, err := requestReasonTemplate(cf, tc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if template == "" {
+		return nil
+	}
+	fields := reasonTemplateFields(template)
+
+	if cf.RequestReason != "" {
+		var missing []string
+		for _, field := range fields {
+			if !strings.Contains(strings.ToLower(cf.RequestReason), strings.ToLower(field)) {
+				missing = append(missing, field)
+			}
+		}
+		if len(missing) > 0 {
+			return trace.BadParameter("request reason is missing required fields: %v (your cluster requires reasons in the form %q)",
+				strings.Join(missing, ", "), template)
+		}
+		return nil
+	}
+
+	fmt.Printf("Your cluster requires a request reason in the form %q.\n", template)
+	reason := template
+	for _, field := range fields {
+		var answer string
+		for answer == "" {
+			answer, err = prompt.Input(cf.Context, os.Stdout, prompt.Stdin(), field)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		reason = strings.ReplaceAll(reason, "{"+field+"}", answer)
+	}
+	cf.RequestReason = reason
+	return nil
+}
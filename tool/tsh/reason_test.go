@@ -0,0 +1,32 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReasonTemplateFields(t *testing.T) {
+	require.Nil(t, reasonTemplateFields("please provide a reason"))
+	require.Equal(t, []string{"ticket", "duration"},
+		reasonTemplateFields("Ticket: {ticket}, needed for {duration}"))
+	// Duplicates are collapsed.
+	require.Equal(t, []string{"ticket"},
+		reasonTemplateFields("{ticket} {ticket}"))
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+)
+
+// recordingChecksumSuffix is appended to a recording file name to locate
+// its detached checksum, e.g. "ab12.tar" is verified against "ab12.tar.sha256".
+const recordingChecksumSuffix = ".sha256"
+
+// verifyRecording checks the integrity of a session recording file before it
+// is played back. When a detached checksum file is present next to the
+// recording it is compared against the file contents, which detects tampering
+// with recordings distributed outside the cluster. In all cases the protobuf
+// event stream is decoded end to end to catch truncation and corruption.
+func verifyRecording(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	if err := verifyRecordingChecksum(f, path); err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	reader := events.NewProtoReader(f)
+	defer reader.Close()
+	var count int
+	for {
+		_, err := reader.Read(ctx)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return trace.BadParameter("recording %v is corrupted or has been tampered with: %v", path, err)
+		}
+		count++
+	}
+	if count == 0 {
+		return trace.BadParameter("recording %v contains no events", path)
+	}
+	fmt.Fprintf(os.Stderr, "Verified recording %v: %v events.\n", path, count)
+	return nil
+}
+
+// redactionNote is written over the start of each blanked region so viewers
+// of the shared recording can tell output was removed rather than lost.
+const redactionNote = "[output redacted]"
+
+// exportProcessedRecording writes a trimmed and/or redacted copy of a local
+// recording to cf.PlayOut, used when sharing recordings externally while
+// protecting secrets that appeared on screen. Trimming keeps only terminal
+// output between --from and --to, redaction blanks the output in the given
+// ranges while preserving its length and timing.
+func exportProcessedRecording(cf *CLIConf) error {
+	if path.Ext(cf.SessionID) != ".tar" {
+		return trace.BadParameter("--out requires a local recording file, not a session ID")
+	}
+	redactRanges, err := parseTimeRanges(cf.PlayRedact)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if cf.PlayTo != 0 && cf.PlayTo <= cf.PlayFrom {
+		return trace.BadParameter("--to must be greater than --from")
+	}
+
+	in, err := os.Open(cf.SessionID)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer in.Close()
+	reader := events.NewProtoReader(in)
+	defer reader.Close()
+	evts, err := reader.ReadAll(cf.Context)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	var kept []apievents.AuditEvent
+	var dropped, redacted int
+	for _, event := range evts {
+		p, ok := event.(*apievents.SessionPrint)
+		if !ok {
+			kept = append(kept, event)
+			continue
+		}
+		delay := time.Duration(p.DelayMilliseconds) * time.Millisecond
+		if delay < cf.PlayFrom || (cf.PlayTo != 0 && delay > cf.PlayTo) {
+			dropped++
+			continue
+		}
+		for _, r := range redactRanges {
+			if delay >= r[0] && delay <= r[1] {
+				redactPrintData(p)
+				redacted++
+				break
+			}
+		}
+		kept = append(kept, p)
+	}
+
+	sid := session.ID(sessionIDFromPath(cf.SessionID))
+	if err := writeRecording(cf.Context, cf.PlayOut, sid, kept); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %v: kept %v of %v events, dropped %v outside the trim range, redacted %v.\n",
+		cf.PlayOut, len(kept), len(evts), dropped, redacted)
+	return nil
+}
+
+// redactPrintData blanks the event's terminal output in place, keeping the
+// original length so offsets and timing stay consistent, and stamps a note
+// at the start so the redaction is visible during playback.
+func redactPrintData(p *apievents.SessionPrint) {
+	for i := range p.Data {
+		p.Data[i] = '*'
+	}
+	if len(p.Data) >= len(redactionNote) {
+		copy(p.Data, redactionNote)
+	}
+}
+
+// writeRecording encodes the events into the protobuf stream format used by
+// exported session recordings and writes it to the given file.
+func writeRecording(ctx context.Context, outPath string, sid session.ID, evts []apievents.AuditEvent) error {
+	uploader := events.NewMemoryUploader()
+	streamer, err := events.NewProtoStreamer(events.ProtoStreamerConfig{Uploader: uploader})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	upload, err := uploader.CreateUpload(ctx, sid)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	stream, err := streamer.CreateAuditStreamForUpload(ctx, sid, *upload)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, event := range evts {
+		if err := stream.EmitAuditEvent(ctx, event); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if err := stream.Complete(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+	parts, err := uploader.GetParts(upload.ID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	out, err := os.OpenFile(outPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	for _, part := range parts {
+		if _, err := out.Write(part); err != nil {
+			out.Close()
+			return trace.ConvertSystemError(err)
+		}
+	}
+	return trace.ConvertSystemError(out.Close())
+}
+
+// parseTimeRanges parses --redact values in the "from-to" duration format,
+// e.g. "1m-2m30s".
+func parseTimeRanges(ranges []string) ([][2]time.Duration, error) {
+	var out [][2]time.Duration
+	for _, r := range ranges {
+		parts := strings.Split(r, "-")
+		if len(parts) != 2 {
+			return nil, trace.BadParameter("invalid time range %q, expected format: 1m-2m30s", r)
+		}
+		from, err := time.ParseDuration(parts[0])
+		if err != nil {
+			return nil, trace.BadParameter("invalid time range %q: %v", r, err)
+		}
+		to, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return nil, trace.BadParameter("invalid time range %q: %v", r, err)
+		}
+		if to <= from {
+			return nil, trace.BadParameter("invalid time range %q: end must be after start", r)
+		}
+		out = append(out, [2]time.Duration{from, to})
+	}
+	return out, nil
+}
+
+// verifyRecordingChecksum compares the recording against the hex-encoded
+// SHA-256 hash stored in the detached checksum file, in the format produced
+// by sha256sum. A missing checksum file is not an error: recordings exported
+// from older clusters ship without one, so only the structural check applies.
+func verifyRecordingChecksum(f io.Reader, path string) error {
+	checksumPath := path + recordingChecksumSuffix
+	data, err := os.ReadFile(checksumPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "No checksum file found at %v, verifying the event stream only.\n", checksumPath)
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+	expected := strings.Fields(string(data))
+	if len(expected) == 0 {
+		return trace.BadParameter("checksum file %v is empty", checksumPath)
+	}
+	want, err := hex.DecodeString(expected[0])
+	if err != nil || len(want) != sha256.Size {
+		return trace.BadParameter("checksum file %v does not contain a hex-encoded SHA-256 hash", checksumPath)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if !strings.EqualFold(hex.EncodeToString(want), hex.EncodeToString(h.Sum(nil))) {
+		return trace.BadParameter("recording %v does not match the checksum in %v: the file has been modified", path, checksumPath)
+	}
+	return nil
+}
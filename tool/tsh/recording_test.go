@@ -0,0 +1,129 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apievents "github.com/gravitational/teleport/api/types/events"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyRecording(t *testing.T) {
+	dir := t.TempDir()
+	recording := filepath.Join(dir, "session.tar")
+	contents := []byte("not a valid recording")
+	require.NoError(t, os.WriteFile(recording, contents, 0600))
+
+	// Without a checksum file the structural check still runs and rejects
+	// the garbage contents.
+	err := verifyRecording(context.Background(), recording)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "corrupted")
+
+	// A checksum mismatch is detected before the stream is decoded.
+	checksumPath := recording + recordingChecksumSuffix
+	bogus := sha256.Sum256([]byte("different contents"))
+	require.NoError(t, os.WriteFile(checksumPath, []byte(hex.EncodeToString(bogus[:])), 0600))
+	err = verifyRecording(context.Background(), recording)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match the checksum")
+
+	// A matching checksum passes and verification proceeds to the stream.
+	sum := sha256.Sum256(contents)
+	require.NoError(t, os.WriteFile(checksumPath, []byte(hex.EncodeToString(sum[:])+"  session.tar\n"), 0600))
+	err = verifyRecording(context.Background(), recording)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "corrupted")
+
+	// A malformed checksum file is rejected outright.
+	require.NoError(t, os.WriteFile(checksumPath, []byte("not-a-hash"), 0600))
+	err = verifyRecording(context.Background(), recording)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "hex-encoded")
+}
+
+func TestExportProcessedRecording(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	sid := session.NewID()
+
+	printEvent := func(index int64, delay time.Duration, data string) apievents.AuditEvent {
+		return &apievents.SessionPrint{
+			Metadata: apievents.Metadata{
+				Type:  events.SessionPrintEvent,
+				Index: index,
+				Time:  time.Now().UTC(),
+			},
+			Data:              []byte(data),
+			Bytes:             int64(len(data)),
+			DelayMilliseconds: delay.Milliseconds(),
+		}
+	}
+	src := filepath.Join(dir, string(sid)+".tar")
+	require.NoError(t, writeRecording(ctx, src, sid, []apievents.AuditEvent{
+		printEvent(0, 0, "hello"),
+		printEvent(1, 30*time.Second, "secret"),
+		printEvent(2, 90*time.Second, "bye"),
+	}))
+
+	out := filepath.Join(dir, "out.tar")
+	cf := &CLIConf{
+		Context:    ctx,
+		SessionID:  src,
+		PlayOut:    out,
+		PlayTo:     time.Minute,
+		PlayRedact: []string{"20s-40s"},
+	}
+	require.NoError(t, exportProcessedRecording(cf))
+
+	f, err := os.Open(out)
+	require.NoError(t, err)
+	defer f.Close()
+	result, err := events.NewProtoReader(f).ReadAll(ctx)
+	require.NoError(t, err)
+
+	// The event past the trim range is dropped and the one in the redact
+	// range is blanked without changing its length.
+	require.Len(t, result, 2)
+	first, ok := result[0].(*apievents.SessionPrint)
+	require.True(t, ok)
+	require.Equal(t, []byte("hello"), first.Data)
+	second, ok := result[1].(*apievents.SessionPrint)
+	require.True(t, ok)
+	require.Equal(t, []byte("******"), second.Data)
+}
+
+func TestParseTimeRanges(t *testing.T) {
+	ranges, err := parseTimeRanges([]string{"1m-2m30s"})
+	require.NoError(t, err)
+	require.Equal(t, [][2]time.Duration{{time.Minute, 2*time.Minute + 30*time.Second}}, ranges)
+
+	_, err = parseTimeRanges([]string{"5m"})
+	require.Error(t, err)
+	_, err = parseTimeRanges([]string{"2m-1m"})
+	require.Error(t, err)
+}
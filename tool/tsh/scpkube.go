@@ -0,0 +1,127 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/kube/kubeconfig"
+
+	"github.com/gravitational/trace"
+)
+
+// scpKubeSpecPrefix marks a "tsh scp" argument as a kubernetes pod target.
+const scpKubeSpecPrefix = "kube:"
+
+// scpKubeTarget is a parsed kube:<cluster>/<pod>:<path> copy target.
+type scpKubeTarget struct {
+	// cluster is the kubernetes cluster the pod runs in.
+	cluster string
+	// pod is the pod to copy from or to.
+	pod string
+	// path is the file or directory path inside the pod.
+	path string
+}
+
+// hasSCPKubeSpec reports whether any of the copy arguments targets a
+// kubernetes pod.
+func hasSCPKubeSpec(specs []string) bool {
+	for _, spec := range specs {
+		if strings.HasPrefix(spec, scpKubeSpecPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSCPKubeSpec parses a copy argument of the form
+// kube:<cluster>/<pod>:<path>. Arguments without the kube: prefix return a
+// nil target and no error.
+func parseSCPKubeSpec(spec string) (*scpKubeTarget, error) {
+	if !strings.HasPrefix(spec, scpKubeSpecPrefix) {
+		return nil, nil
+	}
+	rest := strings.TrimPrefix(spec, scpKubeSpecPrefix)
+	idx := strings.Index(rest, ":")
+	if idx < 0 {
+		return nil, trace.BadParameter("invalid kube copy target %q, expected kube:<cluster>/<pod>:<path>", spec)
+	}
+	location, path := rest[:idx], rest[idx+1:]
+	slash := strings.LastIndex(location, "/")
+	if slash <= 0 || slash == len(location)-1 || path == "" {
+		return nil, trace.BadParameter("invalid kube copy target %q, expected kube:<cluster>/<pod>:<path>", spec)
+	}
+	return &scpKubeTarget{
+		cluster: location[:slash],
+		pod:     location[slash+1:],
+		path:    path,
+	}, nil
+}
+
+// onSCPKube handles "tsh scp" invocations where one side is a kubernetes pod
+// target. The copy is routed through the kube proxy using the kubeconfig
+// context generated by "tsh kube login" for the target cluster.
+func onSCPKube(cf *CLIConf) error {
+	if len(cf.CopySpec) != 2 {
+		return trace.BadParameter("kube copies require exactly one source and one destination")
+	}
+	src, err := parseSCPKubeSpec(cf.CopySpec[0])
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	dst, err := parseSCPKubeSpec(cf.CopySpec[1])
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if src != nil && dst != nil {
+		return trace.BadParameter("copying directly between pods is not supported")
+	}
+	target := src
+	if target == nil {
+		target = dst
+	}
+
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	contextName := kubeconfig.ContextName(profile.Cluster, target.cluster)
+	kc, err := kubeconfig.Load("")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, ok := kc.Contexts[contextName]; !ok {
+		return trace.NotFound("kubeconfig context for cluster %q not found, run 'tsh kube login %v' first", target.cluster, target.cluster)
+	}
+
+	// Rewrite the kube side into the <pod>:<path> form understood by the
+	// kube cp machinery, the local side is passed through unchanged.
+	c := &kubeCpCommand{
+		src:   cf.CopySpec[0],
+		dst:   cf.CopySpec[1],
+		quiet: cf.Quiet,
+	}
+	podSpec := fmt.Sprintf("%v:%v", target.pod, target.path)
+	if src != nil {
+		c.src = podSpec
+	} else {
+		c.dst = podSpec
+	}
+	return trace.Wrap(c.copy(cf, contextName))
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSCPKubeSpec(t *testing.T) {
+	target, err := parseSCPKubeSpec("kube:prod/nginx-0:/var/log/nginx")
+	require.NoError(t, err)
+	require.Equal(t, &scpKubeTarget{cluster: "prod", pod: "nginx-0", path: "/var/log/nginx"}, target)
+
+	// Relative paths inside the pod work too.
+	target, err = parseSCPKubeSpec("kube:prod/nginx-0:logs/access.log")
+	require.NoError(t, err)
+	require.Equal(t, &scpKubeTarget{cluster: "prod", pod: "nginx-0", path: "logs/access.log"}, target)
+
+	// Non-kube arguments are passed through.
+	target, err = parseSCPKubeSpec("/tmp/local")
+	require.NoError(t, err)
+	require.Nil(t, target)
+	target, err = parseSCPKubeSpec("host:/tmp/remote")
+	require.NoError(t, err)
+	require.Nil(t, target)
+
+	// Malformed kube targets are rejected.
+	for _, spec := range []string{
+		"kube:prod",
+		"kube:prod:/path",
+		"kube:prod/nginx-0:",
+		"kube:/nginx-0:/path",
+		"kube:prod/:/path",
+	} {
+		_, err := parseSCPKubeSpec(spec)
+		require.True(t, trace.IsBadParameter(err), "expected BadParameter for %q", spec)
+	}
+}
+
+func TestHasSCPKubeSpec(t *testing.T) {
+	require.True(t, hasSCPKubeSpec([]string{"/tmp/local", "kube:prod/nginx-0:/etc/nginx"}))
+	require.False(t, hasSCPKubeSpec([]string{"/tmp/local", "host:/tmp/remote"}))
+}
+
+func TestSCPKubeRejectsUnsupportedFlags(t *testing.T) {
+	// The scp transfer options are not implemented by the kube copy
+	// machinery and must be rejected instead of silently dropped.
+	for name, cf := range map[string]*CLIConf{
+		"recursive": {RecursiveCopy: true},
+		"port":      {NodePort: 3022},
+		"preserve":  {PreserveAttrs: true},
+		"sparse":    {SparseCopy: true},
+		"update":    {UpdateCopy: true},
+	} {
+		cf.CopySpec = []string{"/tmp/local", "kube:prod/nginx-0:/etc/nginx"}
+		err := onSCP(cf)
+		require.True(t, trace.IsBadParameter(err), "expected BadParameter for %v flag", name)
+	}
+}
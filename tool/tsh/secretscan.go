@@ -0,0 +1,140 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// maxSecretScanSize caps how much of each file the secret scanner reads.
+const maxSecretScanSize = 1024 * 1024
+
+// secretPatterns are the credential heuristics applied by the scp secret
+// scan. The descriptions are shown to the user when a file is flagged.
+var secretPatterns = []struct {
+	description string
+	re          *regexp.Regexp
+}{
+	{
+		description: "private key",
+		re:          regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`),
+	},
+	{
+		description: "AWS access key ID",
+		re:          regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		description: "AWS secret access key",
+		re:          regexp.MustCompile(`(?i)aws.{0,20}['"][0-9a-zA-Z/+]{40}['"]`),
+	},
+}
+
+// scanCopySpecForSecrets scans the local source files of an scp upload for
+// likely credentials and refuses the copy when any are found. File names
+// matching one of the allow patterns are skipped.
+func scanCopySpecForSecrets(copySpec, allowPatterns []string) error {
+	if len(copySpec) < 2 {
+		return nil
+	}
+	// Only uploads are scanned: local sources copied to a remote
+	// destination.
+	if !isRemoteDest(copySpec[len(copySpec)-1]) {
+		return nil
+	}
+
+	var findings []string
+	for _, source := range copySpec[:len(copySpec)-1] {
+		if isRemoteDest(source) {
+			continue
+		}
+		err := filepath.Walk(source, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return trace.ConvertSystemError(err)
+			}
+			if !fi.Mode().IsRegular() || matchesAllowPattern(path, allowPatterns) {
+				return nil
+			}
+			description, found, err := scanFileForSecrets(path)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if found {
+				findings = append(findings, fmt.Sprintf("%v (%v)", path, description))
+			}
+			return nil
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	return trace.BadParameter(
+		"refusing to upload files that look like they contain credentials:\n  %v\nuse --scan-allow=<pattern> to exempt files or drop --scan-secrets to skip the scan",
+		strings.Join(findings, "\n  "))
+}
+
+// scanFileForSecrets reads up to maxSecretScanSize bytes of the file and
+// reports the first matching secret pattern.
+func scanFileForSecrets(path string) (description string, found bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	data := make([]byte, maxSecretScanSize)
+	n, err := f.Read(data)
+	if err != nil && n == 0 {
+		// Unreadable (e.g. empty) files have nothing to flag.
+		return "", false, nil
+	}
+	for _, pattern := range secretPatterns {
+		if pattern.re.Match(data[:n]) {
+			return pattern.description, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// matchesAllowPattern checks the file path and its base name against the
+// allowlist globs.
+func matchesAllowPattern(path string, allowPatterns []string) bool {
+	for _, pattern := range allowPatterns {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isRemoteDest returns true if the scp argument refers to a remote path
+// (host:path). Windows drive letters are not treated as remote.
+func isRemoteDest(spec string) bool {
+	idx := strings.Index(spec, ":")
+	return idx > 1
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+	"github.com/mdp/rsc/qr"
+)
+
+// onSessionsLink implements "tsh sessions link". It prints the web UI URL
+// for joining the given session so it can be shared with reviewers who
+// prefer the browser, optionally rendered as a QR code for scanning off the
+// terminal. The URL is built from the current profile without contacting
+// the cluster; the session itself is not validated.
+func onSessionsLink(cf *CLIConf) error {
+	sid, err := session.ParseID(cf.SessionID)
+	if err != nil {
+		return trace.BadParameter("invalid session ID format: %v", cf.SessionID)
+	}
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	url := fmt.Sprintf("https://%v/web/cluster/%v/console/session/%v",
+		tc.WebProxyAddr, tc.SiteName, sid)
+	fmt.Println(url)
+	if cf.SessionLinkQR {
+		code, err := renderQRCode(url)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(code)
+	}
+	return nil
+}
+
+// renderQRCode encodes text as a QR code drawn with unicode half-block
+// characters, two modules per character cell to keep the code roughly
+// square in a terminal. Modules are rendered light-on-dark so the code
+// scans on the dark backgrounds most terminals use.
+func renderQRCode(text string) (string, error) {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	// The QR spec requires a quiet zone of light modules around the code.
+	const quiet = 2
+	size := code.Size + 2*quiet
+	light := func(x, y int) bool {
+		return !code.Black(x-quiet, y-quiet)
+	}
+	var sb strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			top := light(x, y)
+			// Rows past the bottom edge belong to the light background.
+			bottom := y+1 >= size || light(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteRune('\n')
+	}
+	return sb.String(), nil
+}
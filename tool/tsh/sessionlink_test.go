@@ -0,0 +1,42 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderQRCode(t *testing.T) {
+	code, err := renderQRCode("https://proxy.example.com:3080/web/cluster/root/console/session/00000000-0000-0000-0000-000000000000")
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	require.NotEmpty(t, lines)
+	// All lines render the same number of character cells.
+	width := utf8.RuneCountInString(lines[0])
+	for _, line := range lines {
+		require.Equal(t, width, utf8.RuneCountInString(line))
+	}
+	// The quiet zone renders as solid light modules on the first line.
+	require.True(t, strings.HasPrefix(lines[0], "██"))
+	// The code contains dark modules.
+	require.Contains(t, code, " ")
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/teleport/api/profile"
+
+	"github.com/gravitational/trace"
+)
+
+// Telemetry is strictly opt-in and local-only: nothing is ever sent over the
+// network. Aggregated counters are kept in a JSON file inside the tsh profile
+// directory so users can inspect (or delete) everything that was collected.
+const telemetryPath = "config/telemetry.json"
+
+// commandTelemetry holds aggregated usage metrics for a single tsh command.
+type commandTelemetry struct {
+	// Count is the number of times the command was invoked.
+	Count int64 `json:"count"`
+	// Errors is the number of invocations that returned an error.
+	Errors int64 `json:"errors"`
+	// ErrorClasses counts errors by coarse error class.
+	ErrorClasses map[string]int64 `json:"error_classes,omitempty"`
+	// TotalDurationMS accumulates wall clock duration across invocations.
+	TotalDurationMS int64 `json:"total_duration_ms"`
+	// MaxDurationMS is the slowest invocation observed.
+	MaxDurationMS int64 `json:"max_duration_ms"`
+}
+
+// recordTelemetry aggregates a single command invocation into the local
+// telemetry file. Only the command name, duration and error class are
+// recorded, never arguments or resource names. Failures are logged and
+// otherwise ignored, telemetry must never break the command itself.
+func recordTelemetry(cf *CLIConf, command string, duration time.Duration, cmdErr error) {
+	path := filepath.Join(profile.FullProfilePath(cf.HomePath), telemetryPath)
+
+	stats := make(map[string]*commandTelemetry)
+	if bs, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(bs, &stats); err != nil {
+			log.WithError(err).Debug("Resetting unreadable telemetry file.")
+			stats = make(map[string]*commandTelemetry)
+		}
+	}
+
+	entry := stats[command]
+	if entry == nil {
+		entry = &commandTelemetry{}
+		stats[command] = entry
+	}
+	entry.Count++
+	ms := duration.Milliseconds()
+	entry.TotalDurationMS += ms
+	if ms > entry.MaxDurationMS {
+		entry.MaxDurationMS = ms
+	}
+	if cmdErr != nil {
+		entry.Errors++
+		if entry.ErrorClasses == nil {
+			entry.ErrorClasses = make(map[string]int64)
+		}
+		entry.ErrorClasses[telemetryErrorClass(cmdErr)]++
+	}
+
+	bs, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		log.WithError(err).Debug("Failed to marshal telemetry.")
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		log.WithError(err).Debug("Failed to create telemetry directory.")
+		return
+	}
+	if err := os.WriteFile(path, bs, 0600); err != nil {
+		log.WithError(err).Debug("Failed to write telemetry file.")
+	}
+}
+
+// telemetryErrorClass maps an error to a coarse class name suitable for
+// aggregation without leaking error message contents.
+func telemetryErrorClass(err error) string {
+	switch {
+	case trace.IsAccessDenied(err):
+		return "access_denied"
+	case trace.IsNotFound(err):
+		return "not_found"
+	case trace.IsBadParameter(err):
+		return "bad_parameter"
+	case trace.IsConnectionProblem(err):
+		return "connection_problem"
+	case trace.IsLimitExceeded(err):
+		return "limit_exceeded"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+
+	"github.com/gravitational/trace"
+)
+
+// tmuxSessionNamePattern restricts --tmux-session to names that don't need
+// shell quoting on the remote side.
+var tmuxSessionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// applyTmuxAttach rewrites the ssh invocation to create-or-attach a tmux
+// session on the remote host. "tmux new-session -A" attaches when the named
+// session already exists and creates it otherwise. Attaching requires a
+// TTY, so one is requested as if -t was passed; window resizes then flow
+// through the regular SSH window-change requests.
+func applyTmuxAttach(cf *CLIConf) error {
+	if len(cf.RemoteCommand) > 0 || cf.ScriptPath != "" {
+		return trace.BadParameter("--tmux cannot be combined with a remote command or --script")
+	}
+	if cf.DisableTTY {
+		return trace.BadParameter("--tmux requires a TTY, it cannot be combined with -T")
+	}
+	if !tmuxSessionNamePattern.MatchString(cf.TmuxSession) {
+		return trace.BadParameter("invalid tmux session name %q", cf.TmuxSession)
+	}
+	cf.RemoteCommand = []string{"tmux", "new-session", "-A", "-s", cf.TmuxSession}
+	if cf.EnableTTY == 0 {
+		cf.EnableTTY = 1
+	}
+	return nil
+}
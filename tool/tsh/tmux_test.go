@@ -0,0 +1,41 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTmuxAttach(t *testing.T) {
+	cf := &CLIConf{TmuxAttach: true, TmuxSession: "work"}
+	require.NoError(t, applyTmuxAttach(cf))
+	require.Equal(t, []string{"tmux", "new-session", "-A", "-s", "work"}, cf.RemoteCommand)
+	require.Equal(t, 1, cf.EnableTTY)
+
+	// An explicit -tt is preserved.
+	cf = &CLIConf{TmuxAttach: true, TmuxSession: "work", EnableTTY: 2}
+	require.NoError(t, applyTmuxAttach(cf))
+	require.Equal(t, 2, cf.EnableTTY)
+
+	// Incompatible with a remote command, --script, -T and unsafe names.
+	require.Error(t, applyTmuxAttach(&CLIConf{TmuxAttach: true, TmuxSession: "work", RemoteCommand: []string{"ls"}}))
+	require.Error(t, applyTmuxAttach(&CLIConf{TmuxAttach: true, TmuxSession: "work", ScriptPath: "x.sh"}))
+	require.Error(t, applyTmuxAttach(&CLIConf{TmuxAttach: true, TmuxSession: "work", DisableTTY: true}))
+	require.Error(t, applyTmuxAttach(&CLIConf{TmuxAttach: true, TmuxSession: "bad name; rm"}))
+}
@@ -17,11 +17,15 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path"
@@ -30,6 +34,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -39,12 +44,14 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/api/constants"
 	apidefaults "github.com/gravitational/teleport/api/defaults"
+	"github.com/gravitational/teleport/api/profile"
 	"github.com/gravitational/teleport/api/types"
 	"github.com/gravitational/teleport/api/types/wrappers"
 	apiutils "github.com/gravitational/teleport/api/utils"
 	apisshutils "github.com/gravitational/teleport/api/utils/sshutils"
 	"github.com/gravitational/teleport/lib/asciitable"
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/auth/native"
 	"github.com/gravitational/teleport/lib/auth/touchid"
 	wancli "github.com/gravitational/teleport/lib/auth/webauthncli"
 	"github.com/gravitational/teleport/lib/benchmark"
@@ -66,6 +73,7 @@ import (
 	"github.com/gravitational/kingpin"
 	"github.com/gravitational/trace"
 
+	"github.com/dustin/go-humanize"
 	"github.com/ghodss/yaml"
 	"github.com/jonboulle/clockwork"
 	"github.com/sirupsen/logrus"
@@ -83,16 +91,46 @@ const (
 	mfaModePlatform      = "platform"
 )
 
+// commandTimeoutExitCode is returned when a remote command is terminated
+// because it exceeded --command-timeout, matching the exit code used by
+// timeout(1).
+const commandTimeoutExitCode = 124
+
 // CLIConf stores command line arguments and flags:
 type CLIConf struct {
 	// UserHost contains "[login]@hostname" argument to SSH command
 	UserHost string
+	// NodeID targets the SSH node with this unique ID, overriding the
+	// hostname given in the positional argument.
+	NodeID string
+	// PickNode presents an interactive node picker when the hostname
+	// matches multiple nodes instead of failing.
+	PickNode bool
+	// PreferNodeLabel is a key=value label that disambiguates a hostname
+	// matching multiple nodes in favor of the node carrying it.
+	PreferNodeLabel string
 	// Commands to execute on a remote host
 	RemoteCommand []string
+	// ScriptPath is a local script to execute on the remote host. The
+	// remote command arguments are passed to the script.
+	ScriptPath string
+	// CommandTimeout optionally limits how long a remote command may run
+	// before the session is torn down.
+	CommandTimeout time.Duration
 	// DesiredRoles indicates one or more roles which should be requested.
 	DesiredRoles string
 	// RequestReason indicates the reason for an access request.
 	RequestReason string
+	// RequestTTL optionally limits how long requested access should last.
+	RequestTTL time.Duration
+	// RequestAccess automatically submits an access request when a command
+	// fails with access denied and the missing roles are requestable, then
+	// resumes the command once the request is approved.
+	RequestAccess bool
+	// KillPID is the PID of the tsh listener process to stop.
+	KillPID int
+	// KillAll stops all tsh listener processes.
+	KillAll bool
 	// SuggestedReviewers is a list of suggested request reviewers.
 	SuggestedReviewers string
 	// NoWait can be used with an access request to exit without waiting for a request resolution.
@@ -132,17 +170,45 @@ type CLIConf struct {
 	NodeLogin string
 	// InsecureSkipVerify bypasses verification of HTTPS certificate when talking to web proxy
 	InsecureSkipVerify bool
+	// TOFU pins the proxy certificate fingerprint on first connection instead
+	// of verifying the certificate chain
+	TOFU bool
 	// Remote SSH session to join
 	SessionID string
+	// SessionLinkQR renders the session join URL as a terminal QR code
+	SessionLinkQR bool
+	// PlayVerify verifies the integrity of a local session recording before playback
+	PlayVerify bool
+	// PlayFrom is the start of the segment kept when exporting a trimmed recording
+	PlayFrom time.Duration
+	// PlayTo is the end of the segment kept when exporting a trimmed recording
+	PlayTo time.Duration
+	// PlayRedact are time ranges blanked in the exported recording
+	PlayRedact []string
+	// PlayOut is the file a trimmed/redacted copy of the recording is written to
+	PlayOut string
 	// Src:dest parameter for SCP
 	CopySpec []string
 	// -r flag for scp
 	RecursiveCopy bool
+	// ScanSecrets enables the pre-upload secret scan for scp.
+	ScanSecrets bool
+	// ScanAllowPatterns are file name globs exempt from the secret scan.
+	ScanAllowPatterns []string
 	// -L flag for ssh. Local port forwarding like 'ssh -L 80:remote.host:80 -L 443:remote.host:443'
 	LocalForwardPorts []string
 	// DynamicForwardedPorts is port forwarding using SOCKS5. It is similar to
 	// "ssh -D 8080 example.com".
 	DynamicForwardedPorts []string
+	// StrictHostChecking displays the node identity on first connection and
+	// requires confirmation before connecting
+	StrictHostChecking bool
+	// ForwardConnLimit caps concurrent connections per forwarded port, zero
+	// means no limit
+	ForwardConnLimit int
+	// ForwardBandwidth caps throughput per forwarded port, in bytes per
+	// second with an optional K/M/G suffix, empty means no limit
+	ForwardBandwidth string
 	// ForwardAgent agent to target node. Equivalent of -A for OpenSSH.
 	ForwardAgent bool
 	// ProxyJump is an optional -J flag pointing to the list of jumphosts,
@@ -162,12 +228,54 @@ type CLIConf struct {
 	DatabaseUser string
 	// DatabaseName specifies database name to embed in the certificate.
 	DatabaseName string
+	// DatabaseParams are session startup parameters, as key=value pairs,
+	// passed through to the database at connect time.
+	DatabaseParams []string
+	// DatabaseProbeInterval is the time between probes in "tsh db probe".
+	DatabaseProbeInterval time.Duration
+	// StatsdAddr is the address of a statsd collector that "tsh db probe"
+	// emits latency and failure metrics to.
+	StatsdAddr string
+	// DatabaseScript is a path to a SQL script executed by "tsh db run".
+	DatabaseScript string
+	// DatabaseTransaction wraps the script executed by "tsh db run" in a
+	// single transaction.
+	DatabaseTransaction bool
+	// DatabaseLogoutAll removes credentials for all databases.
+	DatabaseLogoutAll bool
+	// DatabaseOpenPrint prints the deep link generated by "tsh db open"
+	// instead of opening it.
+	DatabaseOpenPrint bool
 	// AppName specifies proxied application name.
 	AppName string
-	// Interactive, when set to true, launches remote command with the terminal attached
-	Interactive bool
+	// AppKeepAlive keeps the app certificate refreshed in the background
+	// until the process is interrupted.
+	AppKeepAlive bool
+	// AppConfigAll prints connection information for every logged-in app.
+	AppConfigAll bool
+	// AppMFA forces an MFA check when retrieving the app certificate.
+	AppMFA bool
+	// AppCheckPath is the request path probed by "tsh apps check".
+	AppCheckPath string
+	// EnableTTY counts the number of times the -t flag was supplied, two or
+	// more force TTY allocation even when stdin is not a terminal.
+	EnableTTY int
+	// TmuxAttach creates or attaches to a tmux session on the remote host
+	// instead of starting a plain shell
+	TmuxAttach bool
+	// TmuxSession is the tmux session name used by TmuxAttach
+	TmuxSession string
+	// DisableTTY disables TTY allocation for the session, even when starting
+	// an interactive shell (-T flag).
+	DisableTTY bool
 	// Quiet mode, -q command (disables progress printing)
 	Quiet bool
+	// ProbeClusters actively probes each cluster's reachability and latency
+	// in "tsh clusters".
+	ProbeClusters bool
+	// ClusterLogins shows the local certificate state per cluster in
+	// "tsh clusters".
+	ClusterLogins bool
 	// Namespace is used to select cluster namespace
 	Namespace string
 	// NoCache is used to turn off client cache for nodes discovery
@@ -182,10 +290,21 @@ type CLIConf struct {
 	BenchExport bool
 	// BenchExportPath saves the latency profile in provided path
 	BenchExportPath string
+	// BenchCompareTo is a second [user@]host to run the identical workload
+	// against, reporting the delta between the two targets
+	BenchCompareTo string
 	// BenchTicks ticks per half distance
 	BenchTicks int32
 	// BenchValueScale value at which to scale the values recorded
 	BenchValueScale float64
+	// BenchWebEndpoint is the HTTP path requested by 'tsh bench web'
+	BenchWebEndpoint string
+	// BenchChaosRate is the percentage of benchmark requests to kill
+	// mid-flight
+	BenchChaosRate int
+	// BenchChaosMaxDelay bounds how long after a request starts its chaos
+	// kill fires
+	BenchChaosMaxDelay time.Duration
 	// Context is a context to control execution
 	Context context.Context
 	// IdentityFileIn is an argument to -i flag (path to the private key+cert file)
@@ -211,6 +330,13 @@ type CLIConf struct {
 	// AuthConnector is the name of the connector to use.
 	AuthConnector string
 
+	// Passwordless requests a passwordless/usernameless login, shorthand
+	// for --auth=passwordless.
+	Passwordless bool
+
+	// KeyAlgorithm is the algorithm used for client keys generated on login.
+	KeyAlgorithm string
+
 	// MFAMode is the preferred mode for MFA/Passwordless assertions.
 	MFAMode string
 
@@ -227,6 +353,23 @@ type CLIConf struct {
 	// Format is used to change the format of output
 	Format string
 
+	// OutputMode selects the output mode for long-running commands,
+	// "json-stream" emits structured progress events on stderr.
+	OutputMode string
+
+	// Columns is a comma separated list of columns to display for listing
+	// commands with text or CSV output.
+	Columns string
+
+	// ListKind is the resource kind listed by 'tsh ls'.
+	ListKind string
+
+	// GroupBy is a label name 'tsh ls' groups the node listing by.
+	GroupBy string
+
+	// Watch keeps re-rendering the listing as resource events arrive.
+	Watch bool
+
 	// SearchKeywords is a list of search keywords to match against resource field values.
 	SearchKeywords string
 
@@ -249,6 +392,14 @@ type CLIConf struct {
 	// Debug sends debug logs to stdout.
 	Debug bool
 
+	// DebugLevel is how many times the debug flag was repeated. Higher
+	// levels progressively increase logging verbosity.
+	DebugLevel int
+
+	// DebugFilter limits debug logs to the given comma-separated list of
+	// components.
+	DebugFilter string
+
 	// Browser can be used to pass the name of a browser to override the system default
 	// (not currently implemented), or set to 'none' to suppress browser opening entirely.
 	Browser string
@@ -270,12 +421,35 @@ type CLIConf struct {
 	// PreserveAttrs preserves access/modification times from the original file.
 	PreserveAttrs bool
 
+	// SparseCopy preserves sparse regions of copied files.
+	SparseCopy bool
+
+	// UpdateCopy skips destination files that already match the source
+	// checksum.
+	UpdateCopy bool
+
+	// ProxySSHLogFile appends structured per-connection logs of "tsh proxy
+	// ssh" invocations to the given file.
+	ProxySSHLogFile string
+
 	// executablePath is the absolute path to the current executable.
 	executablePath string
 
 	// unsetEnvironment unsets Teleport related environment variables.
 	unsetEnvironment bool
 
+	// kubernetesImpersonationConfig allows to configure custom kubernetes
+	// impersonation values recorded in the generated kubeconfig.
+	kubernetesImpersonationConfig impersonationConfig
+
+	// kubeNamespace is the default namespace recorded on generated
+	// kubeconfig contexts.
+	kubeNamespace string
+
+	// kubeContextNameTemplate overrides how generated kubeconfig contexts
+	// are named.
+	kubeContextNameTemplate string
+
 	// overrideStdout allows to switch standard output source for resource command. Used in tests.
 	overrideStdout io.Writer
 	// overrideStderr allows to switch standard error source for resource command. Used in tests.
@@ -287,11 +461,17 @@ type CLIConf struct {
 	// HomePath is where tsh stores profiles
 	HomePath string
 
+	// KeysGCDryRun prints what "tsh keys gc" would remove without removing it.
+	KeysGCDryRun bool
+
 	// GlobalTshConfigPath is a path to global TSH config. Can be overridden with TELEPORT_GLOBAL_TSH_CONFIG.
 	GlobalTshConfigPath string
 
 	// LocalProxyPort is a port used by local proxy listener.
 	LocalProxyPort string
+	// LocalProxySocket is a unix socket path used by local proxy listener
+	// instead of a TCP port.
+	LocalProxySocket string
 	// LocalProxyCertFile is the client certificate used by local proxy.
 	LocalProxyCertFile string
 	// LocalProxyKeyFile is the client key used by local proxy.
@@ -302,10 +482,19 @@ type CLIConf struct {
 	// ConfigProxyTarget is the node which should be connected to in `tsh config-proxy`.
 	ConfigProxyTarget string
 
+	// ConfigBundlePath is the configuration bundle file used by
+	// `tsh config export` and `tsh config import`.
+	ConfigBundlePath string
+	// ConfigImportForce lets imported configuration override local settings.
+	ConfigImportForce bool
+
 	// AWSRole is Amazon Role ARN or role name that will be used for AWS CLI access.
 	AWSRole string
 	// AWSCommandArgs contains arguments that will be forwarded to AWS CLI binary.
 	AWSCommandArgs []string
+	// AWSAssumeRoleChain is a comma-separated list of AWS IAM role ARNs to
+	// assume in sequence before running the AWS CLI command.
+	AWSAssumeRoleChain string
 
 	// Reason is the reason for starting an ssh or kube session.
 	Reason string
@@ -321,6 +510,28 @@ type CLIConf struct {
 
 	// ExtraProxyHeaders is configuration read from the .tsh/config/config.yaml file.
 	ExtraProxyHeaders []ExtraProxyHeaders
+
+	// SessionEnv is session environment injection configuration read from
+	// the .tsh/config/config.yaml file.
+	SessionEnv *SessionEnv
+
+	// DevicePosture is device posture check configuration read from the
+	// .tsh/config/config.yaml file.
+	DevicePosture *DevicePostureConfig
+
+	// CommandGuard is dangerous command confirmation configuration read
+	// from the .tsh/config/config.yaml file.
+	CommandGuard *CommandGuardConfig
+	// NoConfirm skips confirmation prompts for dangerous remote commands
+	NoConfirm bool
+
+	// UpdateCheck makes "tsh update" only report whether an update is
+	// available instead of installing it.
+	UpdateCheck bool
+
+	// OIDCTokenFile is a file containing an OIDC workload identity token
+	// presented to the proxy during login.
+	OIDCTokenFile string
 }
 
 // Stdout returns the stdout writer.
@@ -371,12 +582,13 @@ func main() {
 }
 
 const (
-	authEnvVar        = "TELEPORT_AUTH"
-	clusterEnvVar     = "TELEPORT_CLUSTER"
-	kubeClusterEnvVar = "TELEPORT_KUBE_CLUSTER"
-	loginEnvVar       = "TELEPORT_LOGIN"
-	bindAddrEnvVar    = "TELEPORT_LOGIN_BIND_ADDR"
-	proxyEnvVar       = "TELEPORT_PROXY"
+	authEnvVar         = "TELEPORT_AUTH"
+	clusterEnvVar      = "TELEPORT_CLUSTER"
+	kubeClusterEnvVar  = "TELEPORT_KUBE_CLUSTER"
+	keyAlgorithmEnvVar = "TELEPORT_KEY_ALGORITHM"
+	loginEnvVar        = "TELEPORT_LOGIN"
+	bindAddrEnvVar     = "TELEPORT_LOGIN_BIND_ADDR"
+	proxyEnvVar        = "TELEPORT_PROXY"
 	// TELEPORT_SITE uses the older deprecated "site" terminology to refer to a
 	// cluster. All new code should use TELEPORT_CLUSTER instead.
 	siteEnvVar             = "TELEPORT_SITE"
@@ -390,6 +602,13 @@ const (
 	searchHelp  = `List of comma separated search keywords or phrases enclosed in quotations (e.g. --search=foo,bar,"some phrase")`
 	queryHelp   = `Query by predicate language enclosed in single quotes. Supports ==, !=, &&, and || (e.g. --query='labels["key1"] == "value1" && labels["key2"] != "value2"')`
 	labelHelp   = "List of comma separated labels to filter by labels (e.g. key1=value1,key2=value2)"
+	// joinModeHelp describes the --mode flag of commands that join active sessions.
+	joinModeHelp = "Mode of joining the session: peer participates in the session, observer gets read-only access, moderator can additionally terminate the session"
+	columnsHelp  = `List of comma separated columns to display with text or csv output, individual labels can be selected with a "labels." prefix (e.g. --columns=hostname,addr,labels.env)`
+	watchHelp    = "Keep running and update the listing in place as resources come and go"
+	// requestAccessHelp is shared by the commands that can automatically
+	// submit an access request on access denied.
+	requestAccessHelp = "On access denied, automatically request the requestable roles and resume once the request is approved"
 	// proxyDefaultResolutionTimeout is how long to wait for an unknown proxy
 	// port to be resolved.
 	//
@@ -406,11 +625,36 @@ type cliOption func(*CLIConf) error
 // defaultFormats is the default set of formats to use for commands that have the --format flag.
 var defaultFormats = []string{teleport.Text, teleport.JSON, teleport.YAML}
 
+// listFormats is the set of formats used by listing commands that additionally
+// support CSV output.
+var listFormats = []string{teleport.Text, teleport.JSON, teleport.YAML, teleport.CSV}
+
+// participantModes is the set of valid participant modes for joining an
+// active session.
+var participantModes = []string{
+	string(types.SessionPeerMode),
+	string(types.SessionObserverMode),
+	string(types.SessionModeratorMode),
+}
+
 // Run executes TSH client. same as main() but easier to test
 func Run(args []string, opts ...cliOption) error {
 	var cf CLIConf
 	utils.InitLogger(utils.LoggingForCLI, logrus.WarnLevel)
 
+	// Leave a crash report behind before exiting if anything below panics,
+	// so intermittent crashes come with actionable data.
+	defer func() {
+		if r := recover(); r != nil {
+			if reportPath, err := writeCrashReport(cf.HomePath, r, args); err != nil {
+				log.WithError(err).Warn("Failed to write crash report.")
+			} else {
+				fmt.Fprintf(os.Stderr, "A crash report has been written to %v.\n", reportPath)
+			}
+			panic(r)
+		}
+	}()
+
 	moduleCfg := modules.GetModules()
 
 	// configure CLI argument parser:
@@ -434,12 +678,16 @@ func Run(args []string, opts ...cliOption) error {
 		app.Flag("insecure", "Do not verify server's certificate and host name. Use only in test environments").
 			Default("false").
 			BoolVar(&cf.InsecureSkipVerify)
+		app.Flag("tofu", "Pin the proxy certificate fingerprint on first connection and verify it afterwards, instead of CA verification").
+			Default("false").
+			BoolVar(&cf.TOFU)
 	}
 
 	app.Flag("auth", "Specify the name of authentication connector to use.").Envar(authEnvVar).StringVar(&cf.AuthConnector)
 	app.Flag("namespace", "Namespace of the cluster").Default(apidefaults.Namespace).Hidden().StringVar(&cf.Namespace)
 	app.Flag("skip-version-check", "Skip version checking between server and client.").BoolVar(&cf.SkipVersionCheck)
-	app.Flag("debug", "Verbose logging to stdout").Short('d').BoolVar(&cf.Debug)
+	app.Flag("debug", "Verbose logging to stdout. Repeat for higher verbosity (-dd, -ddd).").Short('d').CounterVar(&cf.DebugLevel)
+	app.Flag("debug-filter", "Comma-separated list of components to include in debug logs (e.g. client,reversetunnel). Other components are suppressed.").StringVar(&cf.DebugFilter)
 	app.Flag("add-keys-to-agent", fmt.Sprintf("Controls how keys are handled. Valid values are %v.", client.AllAddKeysOptions)).Short('k').Envar(addKeysToAgentEnvVar).Default(client.AddKeysToAgentAuto).StringVar(&cf.AddKeysToAgent)
 	app.Flag("use-local-ssh-agent", "Deprecated in favor of the add-keys-to-agent flag.").
 		Hidden().
@@ -450,6 +698,7 @@ func Run(args []string, opts ...cliOption) error {
 		Default("true").
 		BoolVar(&cf.EnableEscapeSequences)
 	app.Flag("bind-addr", "Override host:port used when opening a browser for cluster logins").Envar(bindAddrEnvVar).StringVar(&cf.BindAddr)
+	app.Flag("output", fmt.Sprintf("Output mode for long-running commands, %q emits structured progress events on stderr", outputModeJSONStream)).StringVar(&cf.OutputMode)
 	modes := []string{mfaModeAuto, mfaModeCrossPlatform, mfaModePlatform}
 	app.Flag("mfa-mode", fmt.Sprintf("Preferred mode for MFA and Passwordless assertions (%v)", strings.Join(modes, ", "))).
 		Default(mfaModeAuto).
@@ -458,6 +707,9 @@ func Run(args []string, opts ...cliOption) error {
 
 	ver := app.Command("version", "Print the version")
 	ver.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, defaultFormats...)
+	// update
+	update := app.Command("update", "Update tsh to the version advertised by the proxy")
+	update.Flag("check", "Only report whether an update is available, do not install it").BoolVar(&cf.UpdateCheck)
 	// ssh
 	ssh := app.Command("ssh", "Run shell or execute a command on a remote SSH node")
 	ssh.Arg("[user@]host", "Remote hostname and the login to use").Required().StringVar(&cf.UserHost)
@@ -467,15 +719,29 @@ func Run(args []string, opts ...cliOption) error {
 	ssh.Flag("forward-agent", "Forward agent to target node").Short('A').BoolVar(&cf.ForwardAgent)
 	ssh.Flag("forward", "Forward localhost connections to remote server").Short('L').StringsVar(&cf.LocalForwardPorts)
 	ssh.Flag("dynamic-forward", "Forward localhost connections to remote server using SOCKS5").Short('D').StringsVar(&cf.DynamicForwardedPorts)
+	ssh.Flag("strict-host-checking", "Display the node identity on first connection and require confirmation before connecting").BoolVar(&cf.StrictHostChecking)
+	ssh.Flag("limit-conns", "Maximum concurrent connections per forwarded port (with -L or -D), 0 for unlimited").IntVar(&cf.ForwardConnLimit)
+	ssh.Flag("limit-bandwidth", "Throughput cap per forwarded port, in bytes per second with an optional K/M/G suffix, 0 for unlimited").StringVar(&cf.ForwardBandwidth)
 	ssh.Flag("local", "Execute command on localhost after connecting to SSH node").Default("false").BoolVar(&cf.LocalExec)
-	ssh.Flag("tty", "Allocate TTY").Short('t').BoolVar(&cf.Interactive)
+	ssh.Flag("script", "Execute a local script on the remote host, positional arguments after the host are passed to the script").StringVar(&cf.ScriptPath)
+	ssh.Flag("tmux", "Create or attach to a tmux session on the remote host instead of starting a plain shell").BoolVar(&cf.TmuxAttach)
+	ssh.Flag("tmux-session", "Name of the tmux session created or attached to by --tmux").Default("main").StringVar(&cf.TmuxSession)
+	ssh.Flag("pick", "When the hostname matches multiple nodes, present an interactive picker instead of failing").BoolVar(&cf.PickNode)
+	ssh.Flag("node-id", "Unique ID of the node to connect to, overriding the hostname in the positional argument").StringVar(&cf.NodeID)
+	ssh.Flag("prefer-label", "When the hostname matches multiple nodes, prefer the node carrying this key=value label").StringVar(&cf.PreferNodeLabel)
+	ssh.Flag("tty", "Allocate TTY, specify twice to force allocation even when stdin is not a terminal").Short('t').CounterVar(&cf.EnableTTY)
+	ssh.Flag("no-tty", "Disable TTY allocation, even when starting an interactive shell").Short('T').BoolVar(&cf.DisableTTY)
 	ssh.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
+	ssh.Flag("request", requestAccessHelp).BoolVar(&cf.RequestAccess)
 	ssh.Flag("option", "OpenSSH options in the format used in the configuration file").Short('o').AllowDuplicate().StringsVar(&cf.Options)
+	ssh.Flag("reason", "Reason for the session, recorded in the audit log").StringVar(&cf.Reason)
 	ssh.Flag("no-remote-exec", "Don't execute remote command, useful for port forwarding").Short('N').BoolVar(&cf.NoRemoteExec)
 	ssh.Flag("x11-untrusted", "Requests untrusted (secure) X11 forwarding for this session").Short('X').BoolVar(&cf.X11ForwardingUntrusted)
 	ssh.Flag("x11-trusted", "Requests trusted (insecure) X11 forwarding for this session. This can make your local displays vulnerable to attacks, use with caution").Short('Y').BoolVar(&cf.X11ForwardingTrusted)
 	ssh.Flag("x11-untrusted-timeout", "Sets a timeout for untrusted X11 forwarding, after which the client will reject any forwarding requests from the server").Default("10m").DurationVar((&cf.X11ForwardingTimeout))
 	ssh.Flag("participant-req", "Displays a verbose list of required participants in a moderated session.").BoolVar(&cf.displayParticipantRequirements)
+	ssh.Flag("no-confirm", "Skip the confirmation prompt when the remote command matches a dangerous pattern").BoolVar(&cf.NoConfirm)
+	ssh.Flag("command-timeout", "Terminate the remote command and exit with code 124 if it does not complete within this duration").DurationVar(&cf.CommandTimeout)
 
 	// Daemon service for teleterm client
 	daemon := app.Command("daemon", "Daemon is the tsh daemon service").Hidden()
@@ -486,6 +752,7 @@ func Run(args []string, opts ...cliOption) error {
 	aws := app.Command("aws", "Access AWS API.")
 	aws.Arg("command", "AWS command and subcommands arguments that are going to be forwarded to AWS CLI").StringsVar(&cf.AWSCommandArgs)
 	aws.Flag("app", "Optional Name of the AWS application to use if logged into multiple.").StringVar(&cf.AppName)
+	aws.Flag("assume-role-chain", "Comma-separated list of AWS IAM role ARNs to assume in sequence before running the command.").StringVar(&cf.AWSAssumeRoleChain)
 
 	// Applications.
 	apps := app.Command("apps", "View and control proxied applications.").Alias("app")
@@ -494,33 +761,48 @@ func Run(args []string, opts ...cliOption) error {
 	lsApps.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
 	lsApps.Flag("search", searchHelp).StringVar(&cf.SearchKeywords)
 	lsApps.Flag("query", queryHelp).StringVar(&cf.PredicateExpression)
-	lsApps.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, defaultFormats...)
+	lsApps.Flag("format", formatFlagDescription(listFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, listFormats...)
+	lsApps.Flag("columns", columnsHelp).StringVar(&cf.Columns)
+	lsApps.Flag("watch", watchHelp).BoolVar(&cf.Watch)
 	lsApps.Arg("labels", labelHelp).StringVar(&cf.UserHost)
 	appLogin := apps.Command("login", "Retrieve short-lived certificate for an app.")
 	appLogin.Arg("app", "App name to retrieve credentials for. Can be obtained from `tsh apps ls` output.").Required().StringVar(&cf.AppName)
 	appLogin.Flag("aws-role", "(For AWS CLI access only) Amazon IAM role ARN or role name.").StringVar(&cf.AWSRole)
+	appLogin.Flag("keep-alive", "Keep the app certificate refreshed in the background until interrupted.").BoolVar(&cf.AppKeepAlive)
+	appLogin.Flag("mfa", "Force an MFA check when retrieving the app certificate, needed for apps gated on per-session MFA by role options.").BoolVar(&cf.AppMFA)
 	appLogout := apps.Command("logout", "Remove app certificate.")
 	appLogout.Arg("app", "App to remove credentials for.").StringVar(&cf.AppName)
 	appConfig := apps.Command("config", "Print app connection information.")
 	appConfig.Arg("app", "App to print information for. Required when logged into multiple apps.").StringVar(&cf.AppName)
-	appConfig.Flag("format", fmt.Sprintf("Optional print format, one of: %q to print app address, %q to print CA cert path, %q to print cert path, %q print key path, %q to print example curl command, %q or %q to print everything as JSON or YAML.",
-		appFormatURI, appFormatCA, appFormatCert, appFormatKey, appFormatCURL, appFormatJSON, appFormatYAML),
+	appConfig.Flag("format", fmt.Sprintf("Optional print format, one of: %q to print app address, %q to print CA cert path, %q to print cert path, %q print key path, %q to print example curl command, %q or %q to print everything as JSON or YAML, %q to print shell-exportable environment variables.",
+		appFormatURI, appFormatCA, appFormatCert, appFormatKey, appFormatCURL, appFormatJSON, appFormatYAML, appFormatEnv),
 	).Short('f').StringVar(&cf.Format)
+	appConfig.Flag("all", fmt.Sprintf("Print information for every logged-in app. Requires --format=%v.", appFormatEnv)).BoolVar(&cf.AppConfigAll)
+	appInspect := apps.Command("inspect", "Decode and print the app, database and user certificates on disk.")
+	appInspect.Flag("format", formatFlagDescription(teleport.Text, teleport.JSON)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, teleport.Text, teleport.JSON)
+	appCheck := apps.Command("check", "Send an authenticated request to an app and report status, latency and certificate details.")
+	appCheck.Arg("app", "App to check. Required when logged into multiple apps.").StringVar(&cf.AppName)
+	appCheck.Flag("path", "Request path to probe, e.g. a health endpoint.").Default("/").StringVar(&cf.AppCheckPath)
 
 	// Local TLS proxy.
 	proxy := app.Command("proxy", "Run local TLS proxy allowing connecting to Teleport in single-port mode")
 	proxySSH := proxy.Command("ssh", "Start local TLS proxy for ssh connections when using Teleport in single-port mode")
 	proxySSH.Arg("[user@]host", "Remote hostname and the login to use").Required().StringVar(&cf.UserHost)
 	proxySSH.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
+	proxySSH.Flag("log-file", "Append structured per-connection logs with correlation IDs and stage timings to this file").StringVar(&cf.ProxySSHLogFile)
 	proxyDB := proxy.Command("db", "Start local TLS proxy for database connections when using Teleport in single-port mode")
 	proxyDB.Arg("db", "The name of the database to start local proxy for").Required().StringVar(&cf.DatabaseService)
 	proxyDB.Flag("port", " Specifies the source port used by proxy db listener").Short('p').StringVar(&cf.LocalProxyPort)
+	proxyDB.Flag("socket", "Listen on a unix domain socket at the given path instead of a TCP port").StringVar(&cf.LocalProxySocket)
 	proxyDB.Flag("cert-file", "Certificate file for proxy client TLS configuration").StringVar(&cf.LocalProxyCertFile)
 	proxyDB.Flag("key-file", "Key file for proxy client TLS configuration").StringVar(&cf.LocalProxyKeyFile)
 	proxyDB.Flag("tunnel", "Open authenticated tunnel using database's client certificate so clients don't need to authenticate").BoolVar(&cf.LocalProxyTunnel)
 	proxyApp := proxy.Command("app", "Start local TLS proxy for app connection when using Teleport in single-port mode")
 	proxyApp.Arg("app", "The name of the application to start local proxy for").Required().StringVar(&cf.AppName)
 	proxyApp.Flag("port", "Specifies the source port used by by the proxy app listener").Short('p').StringVar(&cf.LocalProxyPort)
+	proxyGRPC := proxy.Command("grpc", "Start local plaintext HTTP/2 proxy for a gRPC application")
+	proxyGRPC.Arg("app", "The name of the gRPC application to start local proxy for").Required().StringVar(&cf.AppName)
+	proxyGRPC.Flag("port", "Specifies the source port used by the proxy grpc listener").Short('p').StringVar(&cf.LocalProxyPort)
 
 	// Databases.
 	db := app.Command("db", "View and control proxied databases.")
@@ -529,7 +811,9 @@ func Run(args []string, opts ...cliOption) error {
 	dbList.Flag("verbose", "Show extra database fields.").Short('v').BoolVar(&cf.Verbose)
 	dbList.Flag("search", searchHelp).StringVar(&cf.SearchKeywords)
 	dbList.Flag("query", queryHelp).StringVar(&cf.PredicateExpression)
-	dbList.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, defaultFormats...)
+	dbList.Flag("format", formatFlagDescription(listFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, listFormats...)
+	dbList.Flag("columns", columnsHelp).StringVar(&cf.Columns)
+	dbList.Flag("watch", watchHelp).BoolVar(&cf.Watch)
 	dbList.Arg("labels", labelHelp).StringVar(&cf.UserHost)
 	dbLogin := db.Command("login", "Retrieve credentials for a database.")
 	dbLogin.Arg("db", "Database to retrieve credentials for. Can be obtained from 'tsh db ls' output.").Required().StringVar(&cf.DatabaseService)
@@ -537,6 +821,7 @@ func Run(args []string, opts ...cliOption) error {
 	dbLogin.Flag("db-name", "Optional database name to configure as default.").StringVar(&cf.DatabaseName)
 	dbLogout := db.Command("logout", "Remove database credentials.")
 	dbLogout.Arg("db", "Database to remove credentials for.").StringVar(&cf.DatabaseService)
+	dbLogout.Flag("all", "Remove credentials for all databases.").BoolVar(&cf.DatabaseLogoutAll)
 	dbEnv := db.Command("env", "Print environment variables for the configured database.")
 	dbEnv.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, defaultFormats...)
 	dbEnv.Arg("db", "Print environment for the specified database").StringVar(&cf.DatabaseService)
@@ -552,44 +837,98 @@ func Run(args []string, opts ...cliOption) error {
 	dbConnect.Arg("db", "Database service name to connect to.").StringVar(&cf.DatabaseService)
 	dbConnect.Flag("db-user", "Optional database user to log in as.").StringVar(&cf.DatabaseUser)
 	dbConnect.Flag("db-name", "Optional database name to log in to.").StringVar(&cf.DatabaseName)
+	dbConnect.Flag("db-param", "Session startup parameter as key=value, e.g. application_name=ci or statement_timeout=5s. Repeatable, PostgreSQL only.").StringsVar(&cf.DatabaseParams)
+	dbConnect.Flag("request", requestAccessHelp).BoolVar(&cf.RequestAccess)
+	dbPing := db.Command("ping", "Test connectivity to a database and report per-hop timing.")
+	dbPing.Arg("db", "Database service name to test.").StringVar(&cf.DatabaseService)
+	dbPing.Flag("db-user", "Optional database user to log in as.").StringVar(&cf.DatabaseUser)
+	dbProbe := db.Command("probe", "Periodically probe a database through Teleport and emit latency metrics.")
+	dbProbe.Arg("db", "Database service name to probe.").StringVar(&cf.DatabaseService)
+	dbProbe.Flag("db-user", "Optional database user to log in as.").StringVar(&cf.DatabaseUser)
+	dbProbe.Flag("interval", "Time between probes.").Default("30s").DurationVar(&cf.DatabaseProbeInterval)
+	dbProbe.Flag("statsd", "Address of a statsd collector to emit latency and failure metrics to, e.g. localhost:8125.").StringVar(&cf.StatsdAddr)
+	dbRun := db.Command("run", "Execute a SQL script against a database.")
+	dbRun.Arg("db", "Database service name to run the script against.").Required().StringVar(&cf.DatabaseService)
+	dbRun.Arg("script", "Path to the SQL script to execute.").Required().StringVar(&cf.DatabaseScript)
+	dbRun.Flag("db-user", "Optional database user to log in as.").StringVar(&cf.DatabaseUser)
+	dbRun.Flag("db-name", "Optional database name to log in to.").StringVar(&cf.DatabaseName)
+	dbRun.Flag("transaction", "Wrap the script in a single transaction so it either fully applies or fully rolls back.").BoolVar(&cf.DatabaseTransaction)
+	dbSessions := db.Command("sessions", "Manage your active database sessions.")
+	dbSessionsList := dbSessions.Command("ls", "List your active database sessions.").Default()
+	dbSessionsList.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, defaultFormats...)
+	dbSessionsKill := dbSessions.Command("kill", "Terminate an active database session.")
+	dbSessionsKill.Arg("session-id", "ID of the database session to terminate. Can be obtained from 'tsh db sessions ls'.").Required().StringVar(&cf.SessionID)
+	dbOpen := db.Command("open", "Open the database in a GUI client via a protocol deep link.")
+	dbOpen.Arg("db", "Database service name to open.").StringVar(&cf.DatabaseService)
+	dbOpen.Flag("db-user", "Optional database user to log in as.").StringVar(&cf.DatabaseUser)
+	dbOpen.Flag("db-name", "Optional database name to log in to.").StringVar(&cf.DatabaseName)
+	dbOpen.Flag("print", "Print the deep link instead of opening it.").BoolVar(&cf.DatabaseOpenPrint)
 
 	// join
 	join := app.Command("join", "Join the active SSH session")
 	join.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
-	join.Flag("mode", "Mode of joining the session, valid modes are observer and moderator").Short('m').Default("peer").StringVar(&cf.JoinMode)
+	join.Flag("mode", joinModeHelp).Short('m').Default(string(types.SessionPeerMode)).EnumVar(&cf.JoinMode, participantModes...)
 	join.Flag("reason", "The purpose of the session.").StringVar(&cf.Reason)
 	join.Flag("invite", "A comma separated list of people to mark as invited for the session.").StringsVar(&cf.Invited)
 	join.Arg("session-id", "ID of the session to join").Required().StringVar(&cf.SessionID)
+	// sessions
+	sessionsCmd := app.Command("sessions", "Share and manage active sessions")
+	sessionsLink := sessionsCmd.Command("link", "Print the web UI URL for joining an active session")
+	sessionsLink.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
+	sessionsLink.Flag("qr", "Also print the URL as a QR code for scanning off the terminal").BoolVar(&cf.SessionLinkQR)
+	sessionsLink.Arg("session-id", "ID of the session to link to").Required().StringVar(&cf.SessionID)
 	// play
 	play := app.Command("play", "Replay the recorded SSH session")
 	play.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
 	play.Flag("format", formatFlagDescription(
 		teleport.PTY, teleport.JSON, teleport.YAML,
 	)).Short('f').Default(teleport.PTY).EnumVar(&cf.Format, teleport.PTY, teleport.JSON, teleport.YAML)
+	play.Flag("verify", "Verify the integrity of a local recording file before playback, checking it against a <file>.sha256 checksum when present").BoolVar(&cf.PlayVerify)
+	play.Flag("out", "Write a trimmed/redacted copy of a local recording to this file instead of playing it").StringVar(&cf.PlayOut)
+	play.Flag("from", "With --out, the start of the segment to keep, e.g. 30s").DurationVar(&cf.PlayFrom)
+	play.Flag("to", "With --out, the end of the segment to keep, e.g. 5m").DurationVar(&cf.PlayTo)
+	play.Flag("redact", "With --out, a time range whose terminal output is blanked in the copy, e.g. 1m-2m30s. Can be repeated.").StringsVar(&cf.PlayRedact)
 	play.Arg("session-id", "ID of the session to play").Required().StringVar(&cf.SessionID)
 
 	// scp
 	scp := app.Command("scp", "Secure file copy")
 	scp.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
-	scp.Arg("from, to", "Source and destination to copy").Required().StringsVar(&cf.CopySpec)
+	scp.Arg("from, to", "Source and destination to copy, kube:<cluster>/<pod>:<path> targets a kubernetes pod").Required().StringsVar(&cf.CopySpec)
 	scp.Flag("recursive", "Recursive copy of subdirectories").Short('r').BoolVar(&cf.RecursiveCopy)
 	scp.Flag("port", "Port to connect to on the remote host").Short('P').Int32Var(&cf.NodePort)
 	scp.Flag("preserve", "Preserves access and modification times from the original file").Short('p').BoolVar(&cf.PreserveAttrs)
+	scp.Flag("sparse", "Preserve sparse regions instead of transferring them as zeros").BoolVar(&cf.SparseCopy)
+	scp.Flag("update", "Skip destination files that already match the source checksum").Short('u').BoolVar(&cf.UpdateCopy)
+	scp.Flag("reason", "Reason for the file transfer, recorded in the audit log").StringVar(&cf.Reason)
 	scp.Flag("quiet", "Quiet mode").Short('q').BoolVar(&cf.Quiet)
+	scp.Flag("scan-secrets", "Scan local files for likely credentials before uploading and refuse the copy when any are found").BoolVar(&cf.ScanSecrets)
+	scp.Flag("scan-allow", "Glob pattern of file names exempt from the secret scan, can be repeated").StringsVar(&cf.ScanAllowPatterns)
 	// ls
-	ls := app.Command("ls", "List remote SSH nodes")
+	ls := app.Command("ls", "List accessible resources, SSH nodes by default")
 	ls.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
+	ls.Flag("kind", "Resource kind to list").Default(listKindNode).EnumVar(&cf.ListKind,
+		listKindNode, listKindDatabase, listKindApp, listKindKube)
 	ls.Flag("verbose", "One-line output (for text format), including node UUIDs").Short('v').BoolVar(&cf.Verbose)
 	ls.Flag("format", formatFlagDescription(
-		teleport.Text, teleport.JSON, teleport.YAML, teleport.Names,
-	)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, teleport.Text, teleport.JSON, teleport.YAML, teleport.Names)
+		teleport.Text, teleport.JSON, teleport.YAML, teleport.Names, teleport.CSV,
+	)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, teleport.Text, teleport.JSON, teleport.YAML, teleport.Names, teleport.CSV)
+	ls.Flag("columns", columnsHelp).StringVar(&cf.Columns)
+	ls.Flag("group-by", "Group the node listing by the given label and show a per-group count (text format only)").StringVar(&cf.GroupBy)
+	ls.Flag("watch", watchHelp).BoolVar(&cf.Watch)
 	ls.Arg("labels", labelHelp).StringVar(&cf.UserHost)
 	ls.Flag("search", searchHelp).StringVar(&cf.SearchKeywords)
 	ls.Flag("query", queryHelp).StringVar(&cf.PredicateExpression)
+	// can-access
+	canAccess := app.Command("can-access", "Check whether your roles allow access to a resource without connecting")
+	canAccess.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
+	canAccess.Flag("db-user", "Database user to check access as.").StringVar(&cf.DatabaseUser)
+	canAccess.Arg("target", canAccessHelp).Required().StringVar(&cf.UserHost)
 	// clusters
 	clusters := app.Command("clusters", "List available Teleport clusters")
 	clusters.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, defaultFormats...)
 	clusters.Flag("quiet", "Quiet mode").Short('q').BoolVar(&cf.Quiet)
+	clusters.Flag("probe", "Probe each cluster's reachability through the proxy and show round-trip latency").BoolVar(&cf.ProbeClusters)
+	clusters.Flag("logins", "Show the local certificate state for each cluster: validity, roles and expiry").BoolVar(&cf.ClusterLogins)
 
 	// login logs in with remote proxy and obtains a "session certificate" which gets
 	// stored in ~/.tsh directory
@@ -606,27 +945,39 @@ func Run(args []string, opts ...cliOption) error {
 	login.Flag("request-reviewers", "Suggested reviewers for role request").StringVar(&cf.SuggestedReviewers)
 	login.Flag("request-nowait", "Finish without waiting for request resolution").BoolVar(&cf.NoWait)
 	login.Flag("request-id", "Login with the roles requested in the given request").StringVar(&cf.RequestID)
+	login.Flag("passwordless", "Login without a username or password, using the security key's resident credential").BoolVar(&cf.Passwordless)
 	login.Arg("cluster", clusterHelp).StringVar(&cf.SiteName)
 	login.Flag("browser", browserHelp).StringVar(&cf.Browser)
 	login.Flag("kube-cluster", "Name of the Kubernetes cluster to login to").StringVar(&cf.KubernetesCluster)
+	login.Flag("oidc-token-file", "File containing an OIDC workload identity token (e.g. from GitHub Actions or GitLab CI) to present to the proxy during login").StringVar(&cf.OIDCTokenFile)
+	login.Flag("key-algorithm", fmt.Sprintf("Algorithm used to generate the client key. Valid values are %v.", native.SupportedKeyAlgorithms)).
+		Envar(keyAlgorithmEnvVar).
+		EnumVar(&cf.KeyAlgorithm, native.SupportedKeyAlgorithms...)
 	login.Alias(loginUsageFooter)
 
 	// logout deletes obtained session certificates in ~/.tsh
 	logout := app.Command("logout", "Delete a cluster certificate")
 
 	// bench
-	bench := app.Command("bench", "Run shell or execute a command on a remote SSH node").Hidden()
+	bench := app.Command("bench", "Run Teleport benchmark tests").Hidden()
 	bench.Flag("cluster", clusterHelp).StringVar(&cf.SiteName)
-	bench.Arg("[user@]host", "Remote hostname and the login to use").Required().StringVar(&cf.UserHost)
-	bench.Arg("command", "Command to execute on a remote host").Required().StringsVar(&cf.RemoteCommand)
-	bench.Flag("port", "SSH port on a remote host").Short('p').Int32Var(&cf.NodePort)
 	bench.Flag("duration", "Test duration").Default("1s").DurationVar(&cf.BenchDuration)
 	bench.Flag("rate", "Requests per second rate").Default("10").IntVar(&cf.BenchRate)
-	bench.Flag("interactive", "Create interactive SSH session").BoolVar(&cf.BenchInteractive)
 	bench.Flag("export", "Export the latency profile").BoolVar(&cf.BenchExport)
 	bench.Flag("path", "Directory to save the latency profile to, default path is the current directory").Default(".").StringVar(&cf.BenchExportPath)
 	bench.Flag("ticks", "Ticks per half distance").Default("100").Int32Var(&cf.BenchTicks)
 	bench.Flag("scale", "Value scale in which to scale the recorded values").Default("1.0").Float64Var(&cf.BenchValueScale)
+	bench.Flag("chaos-rate", "Percentage of requests to randomly kill mid-flight, to test resilience").Default("0").IntVar(&cf.BenchChaosRate)
+	bench.Flag("chaos-max-delay", "Maximum delay after which a chaos kill fires").Default("1s").DurationVar(&cf.BenchChaosMaxDelay)
+	benchSSH := bench.Command("ssh", "Run a benchmark test against a remote SSH node").Default()
+	benchSSH.Arg("[user@]host", "Remote hostname and the login to use").Required().StringVar(&cf.UserHost)
+	benchSSH.Arg("command", "Command to execute on a remote host").Required().StringsVar(&cf.RemoteCommand)
+	benchSSH.Flag("port", "SSH port on a remote host").Short('p').Int32Var(&cf.NodePort)
+	benchSSH.Flag("interactive", "Create interactive SSH session").BoolVar(&cf.BenchInteractive)
+	benchSSH.Flag("compare-to", "Second [user@]host to run the identical workload against, e.g. a node without session recording, reporting the latency and throughput delta").StringVar(&cf.BenchCompareTo)
+	benchWeb := bench.Command("web", "Run an HTTP benchmark against an application access endpoint")
+	benchWeb.Arg("app", "Name of the app to benchmark. Requires an active app session, see 'tsh apps login'").Required().StringVar(&cf.AppName)
+	benchWeb.Flag("endpoint", "HTTP path to request on the app").Default("/").StringVar(&cf.BenchWebEndpoint)
 
 	// show key
 	show := app.Command("show", "Read an identity from file and print to stdout").Hidden()
@@ -644,6 +995,27 @@ func Run(args []string, opts ...cliOption) error {
 	environment.Flag("format", formatFlagDescription(defaultFormats...)).Short('f').Default(teleport.Text).EnumVar(&cf.Format, defaultFormats...)
 	environment.Flag("unset", "Print commands to clear Teleport session environment variables").BoolVar(&cf.unsetEnvironment)
 
+	// The migrate-home command moves the legacy ~/.tsh directory to the XDG
+	// data directory ($XDG_DATA_HOME/tsh or ~/.local/share/tsh).
+	migrateHome := app.Command("migrate-home", "Move the profile directory from ~/.tsh to the XDG data directory")
+
+	// The agent command inspects and refreshes Teleport keys held by the
+	// local SSH agent for third-party tools consuming the agent.
+	agentCmd := app.Command("agent", "Manage Teleport keys in the local SSH agent")
+	agentList := agentCmd.Command("ls", "List Teleport keys loaded into the local SSH agent")
+	agentRefresh := agentCmd.Command("refresh", "Reload Teleport certificates into the local SSH agent")
+
+	// The keys command manages the session keys stored on disk.
+	keysCmd := app.Command("keys", "Manage session keys stored on disk")
+	keysGC := keysCmd.Command("gc", "Remove expired certificates from the local key store")
+	keysGC.Flag("dry-run", "Print the certificates that would be removed without removing them").BoolVar(&cf.KeysGCDryRun)
+
+	// ps and kill manage local proxies and port forwards started by tsh.
+	psCmd := app.Command("ps", "List local proxies and port forwards started by tsh")
+	killCmd := app.Command("kill", "Stop a local proxy or port forward started by tsh")
+	killCmd.Arg("pid", "PID of the tsh process to stop, as shown by 'tsh ps'").IntVar(&cf.KillPID)
+	killCmd.Flag("all", "Stop all local proxies and port forwards").BoolVar(&cf.KillAll)
+
 	req := app.Command("request", "Manage access requests").Alias("requests")
 
 	reqList := req.Command("ls", "List access requests").Alias("list")
@@ -661,6 +1033,7 @@ func Run(args []string, opts ...cliOption) error {
 	reqCreate.Flag("reason", "Reason for requesting").StringVar(&cf.RequestReason)
 	reqCreate.Flag("reviewers", "Suggested reviewers").StringVar(&cf.SuggestedReviewers)
 	reqCreate.Flag("nowait", "Finish without waiting for request resolution").BoolVar(&cf.NoWait)
+	reqCreate.Flag("request-ttl", "How long the elevated access should last, e.g. 2h").DurationVar(&cf.RequestTTL)
 	// TODO(nic): unhide this command when the rest of search-based access
 	// requests is implemented (#10887)
 	reqCreate.Flag("resources", "List of resources to request access to separated by commas").Hidden().StringVar(&cf.RequestedResourceIDs)
@@ -688,6 +1061,12 @@ func Run(args []string, opts ...cliOption) error {
 	mfa := newMFACommand(app)
 
 	config := app.Command("config", "Print OpenSSH configuration details")
+	configSSH := config.Command("ssh", "Print OpenSSH configuration details").Default().Hidden()
+	configExport := config.Command("export", "Export non-secret tsh configuration into a shareable bundle")
+	configExport.Flag("out", "Write the bundle to a file instead of stdout").Short('o').AllowDuplicate().StringVar(&cf.ConfigBundlePath)
+	configImport := config.Command("import", "Import tsh configuration from an exported bundle")
+	configImport.Arg("file", "Path to the configuration bundle created by 'tsh config export'").Required().StringVar(&cf.ConfigBundlePath)
+	configImport.Flag("force", "Overwrite local settings with imported values on conflict").BoolVar(&cf.ConfigImportForce)
 
 	// config-proxy is a wrapper to ensure Windows clients can properly use
 	// `tsh config`. As it's not intended to run by users directly and may
@@ -699,6 +1078,7 @@ func Run(args []string, opts ...cliOption) error {
 
 	f2 := app.Command("fido2", "FIDO2 commands").Hidden()
 	f2Diag := f2.Command("diag", "Run FIDO2 diagnostics").Hidden()
+	f2Devs := f2.Command("devices", "List connected FIDO2 devices").Hidden()
 
 	// touchid subcommands.
 	var tid *touchIDCommand
@@ -722,6 +1102,10 @@ func Run(args []string, opts ...cliOption) error {
 	// Did we initially get the Username from flags/env?
 	cf.ExplicitUsername = cf.Username != ""
 
+	if cf.OutputMode != "" && cf.OutputMode != outputModeJSONStream {
+		return trace.BadParameter("invalid output mode %q, only %q is supported", cf.OutputMode, outputModeJSONStream)
+	}
+
 	// apply any options after parsing of arguments to ensure
 	// that defaults don't overwrite options.
 	for _, opt := range opts {
@@ -730,9 +1114,22 @@ func Run(args []string, opts ...cliOption) error {
 		}
 	}
 
-	// While in debug mode, send logs to stdout.
+	// While in debug mode, send logs to stdout. Repeating the debug flag
+	// raises the verbosity further: -dd enables trace logging and -ddd
+	// additionally reports call sites.
+	cf.Debug = cf.DebugLevel > 0
 	if cf.Debug {
-		utils.InitLogger(utils.LoggingForCLI, logrus.DebugLevel)
+		level := logrus.DebugLevel
+		if cf.DebugLevel >= 2 {
+			level = logrus.TraceLevel
+		}
+		utils.InitLogger(utils.LoggingForCLI, level)
+		if cf.DebugLevel >= 3 {
+			logrus.SetReportCaller(true)
+		}
+		if cf.DebugFilter != "" {
+			utils.FilterLogComponents(strings.Split(cf.DebugFilter, ","))
+		}
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -763,22 +1160,34 @@ func Run(args []string, opts ...cliOption) error {
 	}
 
 	cf.ExtraProxyHeaders = confOptions.ExtraHeaders
+	cf.SessionEnv = confOptions.SessionEnv
+	cf.DevicePosture = confOptions.DevicePosture
+	cf.CommandGuard = confOptions.CommandGuard
 
+	commandStart := time.Now()
 	switch command {
 	case ver.FullCommand():
 		err = onVersion(&cf)
+	case update.FullCommand():
+		err = onUpdate(&cf)
 	case ssh.FullCommand():
 		err = onSSH(&cf)
-	case bench.FullCommand():
+	case benchSSH.FullCommand():
 		err = onBenchmark(&cf)
+	case benchWeb.FullCommand():
+		err = onBenchmarkWeb(&cf)
 	case join.FullCommand():
 		err = onJoin(&cf)
+	case sessionsLink.FullCommand():
+		err = onSessionsLink(&cf)
 	case scp.FullCommand():
 		err = onSCP(&cf)
 	case play.FullCommand():
 		err = onPlay(&cf)
 	case ls.FullCommand():
-		err = onListNodes(&cf)
+		err = onListResources(&cf)
+	case canAccess.FullCommand():
+		err = onCanAccess(&cf)
 	case clusters.FullCommand():
 		err = onListClusters(&cf)
 	case login.FullCommand():
@@ -800,6 +1209,10 @@ func Run(args []string, opts ...cliOption) error {
 		err = onAppLogout(&cf)
 	case appConfig.FullCommand():
 		err = onAppConfig(&cf)
+	case appInspect.FullCommand():
+		err = onAppInspect(&cf)
+	case appCheck.FullCommand():
+		err = onAppCheck(&cf)
 	case kube.credentials.FullCommand():
 		err = kube.credentials.run(&cf)
 	case kube.ls.FullCommand():
@@ -808,15 +1221,25 @@ func Run(args []string, opts ...cliOption) error {
 		err = kube.login.run(&cf)
 	case kube.sessions.FullCommand():
 		err = kube.sessions.run(&cf)
+	case kube.sessions.approve.FullCommand():
+		err = kube.sessions.runApprove(&cf)
 	case kube.exec.FullCommand():
 		err = kube.exec.run(&cf)
+	case kube.cp.FullCommand():
+		err = kube.cp.run(&cf)
 	case kube.join.FullCommand():
 		err = kube.join.run(&cf)
+	case kube.config.FullCommand():
+		err = kube.config.run(&cf)
+	case kube.prune.FullCommand():
+		err = kube.prune.run(&cf)
 
 	case proxySSH.FullCommand():
 		err = onProxyCommandSSH(&cf)
 	case proxyDB.FullCommand():
 		err = onProxyCommandDB(&cf)
+	case proxyGRPC.FullCommand():
+		err = onProxyCommandGRPC(&cf)
 	case proxyApp.FullCommand():
 		err = onProxyCommandApp(&cf)
 
@@ -832,6 +1255,18 @@ func Run(args []string, opts ...cliOption) error {
 		err = onDatabaseConfig(&cf)
 	case dbConnect.FullCommand():
 		err = onDatabaseConnect(&cf)
+	case dbPing.FullCommand():
+		err = onDatabasePing(&cf)
+	case dbProbe.FullCommand():
+		err = onDatabaseProbe(&cf)
+	case dbRun.FullCommand():
+		err = onDatabaseRun(&cf)
+	case dbSessionsList.FullCommand():
+		err = onListDatabaseSessions(&cf)
+	case dbSessionsKill.FullCommand():
+		err = onKillDatabaseSession(&cf)
+	case dbOpen.FullCommand():
+		err = onDatabaseOpen(&cf)
 	case environment.FullCommand():
 		err = onEnvironment(&cf)
 	case mfa.ls.FullCommand():
@@ -840,6 +1275,8 @@ func Run(args []string, opts ...cliOption) error {
 		err = mfa.add.run(&cf)
 	case mfa.rm.FullCommand():
 		err = mfa.rm.run(&cf)
+	case mfa.rename.FullCommand():
+		err = mfa.rename.run(&cf)
 	case reqList.FullCommand():
 		err = onRequestList(&cf)
 	case reqShow.FullCommand():
@@ -850,8 +1287,24 @@ func Run(args []string, opts ...cliOption) error {
 		err = onRequestReview(&cf)
 	case reqSearch.FullCommand():
 		err = onRequestSearch(&cf)
-	case config.FullCommand():
+	case configSSH.FullCommand():
 		err = onConfig(&cf)
+	case configExport.FullCommand():
+		err = onConfigExport(&cf)
+	case configImport.FullCommand():
+		err = onConfigImport(&cf)
+	case migrateHome.FullCommand():
+		err = onMigrateHome(&cf)
+	case agentList.FullCommand():
+		err = onAgentList(&cf)
+	case agentRefresh.FullCommand():
+		err = onAgentRefresh(&cf)
+	case keysGC.FullCommand():
+		err = onKeysGC(&cf)
+	case psCmd.FullCommand():
+		err = onPS(&cf)
+	case killCmd.FullCommand():
+		err = onKill(&cf)
 	case configProxy.FullCommand():
 		err = onConfigProxy(&cf)
 	case aws.FullCommand():
@@ -860,6 +1313,8 @@ func Run(args []string, opts ...cliOption) error {
 		err = onDaemonStart(&cf)
 	case f2Diag.FullCommand():
 		err = onFIDO2Diag(&cf)
+	case f2Devs.FullCommand():
+		err = onFIDO2Devices(&cf)
 	default:
 		// Handle commands that might not be available.
 		switch {
@@ -873,6 +1328,11 @@ func Run(args []string, opts ...cliOption) error {
 		}
 	}
 
+	// Aggregate usage metrics locally when the user opted in.
+	if confOptions.Telemetry != nil && confOptions.Telemetry.Enabled {
+		recordTelemetry(&cf, command, time.Since(commandStart), err)
+	}
+
 	if trace.IsNotImplemented(err) {
 		return handleUnimplementedError(ctx, err, cf)
 	}
@@ -960,6 +1420,17 @@ func serializeVersion(format string, proxyVersion string) (string, error) {
 
 // onPlay replays a session with a given ID
 func onPlay(cf *CLIConf) error {
+	if cf.PlayVerify {
+		if path.Ext(cf.SessionID) != ".tar" {
+			return trace.BadParameter("--verify requires a local recording file, not a session ID")
+		}
+		if err := verifyRecording(cf.Context, cf.SessionID); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if cf.PlayOut != "" {
+		return trace.Wrap(exportProcessedRecording(cf))
+	}
 	format := strings.ToLower(cf.Format)
 	switch format {
 	case teleport.PTY:
@@ -1051,6 +1522,15 @@ func onLogin(cf *CLIConf) error {
 		return trace.BadParameter("-i flag cannot be used here")
 	}
 
+	// --passwordless is shorthand for the passwordless connector; the
+	// security key's resident credential identifies the user.
+	if cf.Passwordless {
+		if cf.AuthConnector != "" && cf.AuthConnector != constants.PasswordlessConnector {
+			return trace.BadParameter("--passwordless flag cannot be used with --auth=%v", cf.AuthConnector)
+		}
+		cf.AuthConnector = constants.PasswordlessConnector
+	}
+
 	switch cf.IdentityFormat {
 	case identityfile.FormatFile, identityfile.FormatOpenSSH, identityfile.FormatKubernetes:
 	default:
@@ -1152,6 +1632,19 @@ func onLogin(cf *CLIConf) error {
 	// -i flag specified? save the retrieved cert into an identity file
 	makeIdentityFile := (cf.IdentityFileOut != "")
 
+	// Run the optional device posture check and attach its attestation to
+	// the login request so the proxy can gate certificate issuance on it.
+	if err := addDevicePostureAttestation(cf, tc); err != nil {
+		return trace.Wrap(err)
+	}
+
+	// Attach the OIDC workload identity token (CI federation) to the login
+	// request so the proxy can exchange it for certificates without the job
+	// storing long-lived bot secrets.
+	if err := addOIDCWorkloadToken(cf, tc); err != nil {
+		return trace.Wrap(err)
+	}
+
 	key, err := tc.Login(cf.Context)
 	if err != nil {
 		return trace.Wrap(err)
@@ -1208,6 +1701,12 @@ func onLogin(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
+	// Offer a cluster picker when leaf clusters are reachable and the user
+	// didn't pick one with --cluster.
+	if err := maybeSelectCluster(cf, tc); err != nil {
+		return trace.Wrap(err)
+	}
+
 	if autoRequest && cf.DesiredRoles == "" && cf.RequestID == "" {
 		var requireReason, auto bool
 		var prompt string
@@ -1473,33 +1972,169 @@ func onLogout(cf *CLIConf) error {
 }
 
 // onListNodes executes 'tsh ls' command.
+// Resource kinds accepted by the 'tsh ls --kind' flag.
+const (
+	listKindNode     = "node"
+	listKindDatabase = "db"
+	listKindApp      = "app"
+	listKindKube     = "kube"
+
+	// watchPollInterval is how often watched listings are refreshed when
+	// falling back to polling.
+	watchPollInterval = 5 * time.Second
+)
+
+// onListResources executes 'tsh ls' and routes to the listing for the
+// requested resource kind.
+func onListResources(cf *CLIConf) error {
+	switch cf.ListKind {
+	case listKindNode, "":
+		return trace.Wrap(onListNodes(cf))
+	case listKindDatabase:
+		return trace.Wrap(onListDatabases(cf))
+	case listKindApp:
+		return trace.Wrap(onApps(cf))
+	case listKindKube:
+		return trace.Wrap(listKubeClusters(cf, cf.Format, cf.Verbose))
+	}
+	return trace.BadParameter("unsupported resource kind %q", cf.ListKind)
+}
+
 func onListNodes(cf *CLIConf) error {
 	tc, err := makeClient(cf, true)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	// Get list of all nodes in backend and sort by "Node Name".
-	var nodes []types.Server
-	err = client.RetryWithRelogin(cf.Context, tc, func() error {
-		nodes, err = tc.ListNodesWithFilters(cf.Context)
-		return err
+	list := func() error {
+		// Get list of all nodes in backend and sort by "Node Name".
+		var nodes []types.Server
+		err = client.RetryWithRelogin(cf.Context, tc, func() error {
+			nodes, err = tc.ListNodesWithFilters(cf.Context)
+			return err
+		})
+		if err != nil {
+			if utils.IsPredicateError(err) {
+				return trace.Wrap(utils.PredicateError{Err: err})
+			}
+			return trace.Wrap(err)
+		}
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].GetHostname() < nodes[j].GetHostname()
+		})
+
+		return trace.Wrap(printNodes(nodes, cf.Format, cf.Columns, cf.GroupBy, cf.Verbose))
+	}
+
+	if cf.Watch {
+		return trace.Wrap(watchResourceListing(cf, tc, types.KindNode, list))
+	}
+	return trace.Wrap(list())
+}
+
+// watchResourceListing keeps re-rendering the output of relist as resources
+// of the given kind come and go. The listing is refreshed whenever a matching
+// event arrives; if the event watcher cannot be established (e.g. due to
+// missing permissions) the listing falls back to periodic polling.
+func watchResourceListing(cf *CLIConf, tc *client.TeleportClient, kind string, relist func() error) error {
+	render := func() error {
+		// Clear the terminal and home the cursor before redrawing.
+		fmt.Print("\033[H\033[2J")
+		return trace.Wrap(relist())
+	}
+	if err := render(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	watcher, err := tc.NewWatcher(cf.Context, types.Watch{
+		Kinds: []types.WatchKind{{Kind: kind}},
 	})
 	if err != nil {
-		if utils.IsPredicateError(err) {
-			return trace.Wrap(utils.PredicateError{Err: err})
+		log.WithError(err).Debug("Failed to create event watcher, falling back to polling.")
+		return trace.Wrap(pollResourceListing(cf, render))
+	}
+	defer watcher.Close()
+
+	for {
+		select {
+		case event := <-watcher.Events():
+			if event.Resource == nil || event.Resource.GetKind() != kind {
+				continue
+			}
+			if err := render(); err != nil {
+				return trace.Wrap(err)
+			}
+		case <-watcher.Done():
+			log.WithError(watcher.Error()).Debug("Event watcher closed, falling back to polling.")
+			return trace.Wrap(pollResourceListing(cf, render))
+		case <-cf.Context.Done():
+			return nil
 		}
-		return trace.Wrap(err)
 	}
-	sort.Slice(nodes, func(i, j int) bool {
-		return nodes[i].GetHostname() < nodes[j].GetHostname()
+}
+
+// pollResourceListing re-renders the listing on a fixed interval until the
+// command is interrupted.
+func pollResourceListing(cf *CLIConf, render func() error) error {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := render(); err != nil {
+				return trace.Wrap(err)
+			}
+		case <-cf.Context.Done():
+			return nil
+		}
+	}
+}
+
+// retryWithAccessRequest runs fn and, if it fails with access denied and the
+// user has requestable roles, offers to create an access request. With
+// --request set, the request is submitted automatically and fn is retried
+// once the request is approved.
+func retryWithAccessRequest(cf *CLIConf, tc *client.TeleportClient, fn func() error) error {
+	origErr := fn()
+	if origErr == nil || !trace.IsAccessDenied(origErr) {
+		return trace.Wrap(origErr)
+	}
+
+	// Find out which roles can be requested before bothering the user.
+	var requestableRoles []string
+	err := tc.WithRootClusterClient(cf.Context, func(clt auth.ClientI) error {
+		caps, err := clt.GetAccessCapabilities(cf.Context, types.AccessCapabilitiesRequest{
+			RequestableRoles: true,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		requestableRoles = caps.RequestableRoles
+		return nil
 	})
+	if err != nil {
+		log.WithError(err).Debug("Failed to fetch access capabilities.")
+		return trace.Wrap(origErr)
+	}
+	if len(requestableRoles) == 0 {
+		return trace.Wrap(origErr)
+	}
+
+	if !cf.RequestAccess {
+		fmt.Fprintf(os.Stderr, "Access denied. The following roles can be requested: %v\n", strings.Join(requestableRoles, ", "))
+		fmt.Fprintf(os.Stderr, "Rerun the command with --request to submit an access request and resume once it is approved.\n")
+		return trace.Wrap(origErr)
+	}
 
-	if err := printNodes(nodes, cf.Format, cf.Verbose); err != nil {
+	fmt.Fprintf(os.Stdout, "Access denied, requesting roles: %v\n", strings.Join(requestableRoles, ", "))
+	cf.DesiredRoles = strings.Join(requestableRoles, ",")
+	if err := executeAccessRequest(cf, tc); err != nil {
 		return trace.Wrap(err)
 	}
 
-	return nil
+	// The request was approved and updated certificates were issued, run the
+	// original command again.
+	return trace.Wrap(fn())
 }
 
 func executeAccessRequest(cf *CLIConf, tc *client.TeleportClient) error {
@@ -1554,6 +2189,9 @@ func executeAccessRequest(cf *CLIConf, tc *client.TeleportClient) error {
 		}
 		req.SetRequestReason(cf.RequestReason)
 		req.SetSuggestedReviewers(reviewers)
+		if cf.RequestTTL > 0 {
+			req.SetAccessExpiry(time.Now().UTC().Add(cf.RequestTTL))
+		}
 	}
 
 	// Watch for resolution events on the given request. Start watcher and wait
@@ -1614,11 +2252,45 @@ func executeAccessRequest(cf *CLIConf, tc *client.TeleportClient) error {
 	return trace.Wrap(<-errChan)
 }
 
-func printNodes(nodes []types.Server, format string, verbose bool) error {
+func printNodes(nodes []types.Server, format, columns, groupBy string, verbose bool) error {
+	if err := checkColumnsFormat(format, columns); err != nil {
+		return trace.Wrap(err)
+	}
 	format = strings.ToLower(format)
+	if groupBy != "" {
+		if format != teleport.Text && format != "" {
+			return trace.BadParameter("--group-by is only supported with text format")
+		}
+		if columns != "" {
+			return trace.BadParameter("--group-by cannot be combined with --columns")
+		}
+		printNodesGrouped(nodes, groupBy, verbose)
+		return nil
+	}
 	switch format {
 	case teleport.Text, "":
+		if columns != "" {
+			selected := parseColumns(columns)
+			rows, err := nodeRows(nodes, selected)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			printRowsAsText(selected, rows)
+			break
+		}
 		printNodesAsText(nodes, verbose)
+	case teleport.CSV:
+		selected := parseColumns(columns)
+		if len(selected) == 0 {
+			selected = defaultNodeColumns
+		}
+		rows, err := nodeRows(nodes, selected)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := writeCSV(os.Stdout, selected, rows); err != nil {
+			return trace.Wrap(err)
+		}
 	case teleport.JSON, teleport.YAML:
 		out, err := serializeNodes(nodes, format)
 		if err != nil {
@@ -1650,6 +2322,46 @@ func serializeNodes(nodes []types.Server, format string) (string, error) {
 	return string(out), trace.Wrap(err)
 }
 
+// printNodesGrouped renders the node listing as a tree with one section per
+// value of the grouping label, letting fleet owners see inventory counts per
+// env, team and so on straight from the CLI. Nodes missing the label are
+// collected under "(none)".
+func printNodesGrouped(nodes []types.Server, groupBy string, verbose bool) {
+	groups := make(map[string][]types.Server)
+	for _, n := range nodes {
+		value, ok := n.GetAllLabels()[groupBy]
+		if !ok {
+			value = "(none)"
+		}
+		groups[value] = append(groups[value], n)
+	}
+	values := make([]string, 0, len(groups))
+	for value := range groups {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		members := groups[value]
+		noun := "nodes"
+		if len(members) == 1 {
+			noun = "node"
+		}
+		fmt.Printf("%v: %v (%v %v)\n", groupBy, value, len(members), noun)
+		for _, n := range members {
+			addr := n.GetAddr()
+			if n.GetUseTunnel() {
+				addr = "⟵ Tunnel"
+			}
+			if verbose {
+				fmt.Printf("  %v [%v] %v\n", n.GetHostname(), n.GetName(), addr)
+			} else {
+				fmt.Printf("  %v %v\n", n.GetHostname(), addr)
+			}
+		}
+		fmt.Println()
+	}
+}
+
 func printNodesAsText(nodes []types.Server, verbose bool) {
 	// Reusable function to get addr or tunnel for each node
 	getAddr := func(n types.Server) string {
@@ -1705,11 +2417,35 @@ func sortedLabels(labels map[string]string) string {
 	return strings.Join(append(result, namespaced...), ",")
 }
 
-func showApps(apps []types.Application, active []tlsca.RouteToApp, format string, verbose bool) error {
+func showApps(apps []types.Application, active []tlsca.RouteToApp, format, columns string, verbose bool) error {
+	if err := checkColumnsFormat(format, columns); err != nil {
+		return trace.Wrap(err)
+	}
 	format = strings.ToLower(format)
 	switch format {
 	case teleport.Text, "":
+		if columns != "" {
+			selected := parseColumns(columns)
+			rows, err := appRows(apps, selected)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			printRowsAsText(selected, rows)
+			break
+		}
 		showAppsAsText(apps, active, verbose)
+	case teleport.CSV:
+		selected := parseColumns(columns)
+		if len(selected) == 0 {
+			selected = defaultAppColumns
+		}
+		rows, err := appRows(apps, selected)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := writeCSV(os.Stdout, selected, rows); err != nil {
+			return trace.Wrap(err)
+		}
 	case teleport.JSON, teleport.YAML:
 		out, err := serializeApps(apps, format)
 		if err != nil {
@@ -1778,11 +2514,35 @@ func showAppsAsText(apps []types.Application, active []tlsca.RouteToApp, verbose
 	}
 }
 
-func showDatabases(clusterFlag string, databases []types.Database, active []tlsca.RouteToDatabase, roleSet services.RoleSet, format string, verbose bool) error {
+func showDatabases(clusterFlag string, databases []types.Database, active []tlsca.RouteToDatabase, roleSet services.RoleSet, format, columns string, verbose bool) error {
+	if err := checkColumnsFormat(format, columns); err != nil {
+		return trace.Wrap(err)
+	}
 	format = strings.ToLower(format)
 	switch format {
 	case teleport.Text, "":
+		if columns != "" {
+			selected := parseColumns(columns)
+			rows, err := databaseRows(databases, selected)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			printRowsAsText(selected, rows)
+			break
+		}
 		showDatabasesAsText(clusterFlag, databases, active, roleSet, verbose)
+	case teleport.CSV:
+		selected := parseColumns(columns)
+		if len(selected) == 0 {
+			selected = defaultDatabaseColumns
+		}
+		rows, err := databaseRows(databases, selected)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := writeCSV(os.Stdout, selected, rows); err != nil {
+			return trace.Wrap(err)
+		}
 	case teleport.JSON, teleport.YAML:
 		out, err := serializeDatabases(databases, format)
 		if err != nil {
@@ -1947,6 +2707,28 @@ func onListClusters(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
+	// With --probe, measure an authenticated round trip through the proxy
+	// to every cluster so users can pick the closest healthy one.
+	var latencies map[string]string
+	if cf.ProbeClusters {
+		clusterNames := []string{rootClusterName}
+		for _, cluster := range leafClusters {
+			clusterNames = append(clusterNames, cluster.GetName())
+		}
+		latencies = probeClusterLatencies(cf, tc, clusterNames)
+	}
+
+	// With --logins, inspect the locally stored certificate for each cluster
+	// so one view shows where the user is effectively logged in.
+	var loginStates map[string]clusterLoginStatus
+	if cf.ClusterLogins {
+		loginStates = make(map[string]clusterLoginStatus, len(leafClusters)+1)
+		loginStates[rootClusterName] = clusterLoginState(tc, rootClusterName)
+		for _, cluster := range leafClusters {
+			loginStates[cluster.GetName()] = clusterLoginState(tc, cluster.GetName())
+		}
+	}
+
 	profile, _, err := client.Status(cf.HomePath, cf.Proxy)
 	if err != nil {
 		return trace.Wrap(err)
@@ -1965,20 +2747,37 @@ func onListClusters(cf *CLIConf) error {
 	switch format {
 	case teleport.Text, "":
 		var t asciitable.Table
+		headers := []string{"Cluster Name", "Status", "Cluster Type", "Labels", "Selected"}
+		if cf.ProbeClusters {
+			headers = append(headers, "Latency")
+		}
+		if cf.ClusterLogins {
+			headers = append(headers, "Cert", "Roles", "Cert Expires")
+		}
 		if cf.Quiet {
-			t = asciitable.MakeHeadlessTable(4)
+			t = asciitable.MakeHeadlessTable(len(headers))
 		} else {
-			t = asciitable.MakeTable([]string{"Cluster Name", "Status", "Cluster Type", "Labels", "Selected"})
+			t = asciitable.MakeTable(headers)
 		}
 
-		t.AddRow([]string{
+		addRow := func(row []string, clusterName string) {
+			if cf.ProbeClusters {
+				row = append(row, latencies[clusterName])
+			}
+			if cf.ClusterLogins {
+				state := loginStates[clusterName]
+				row = append(row, state.status, strings.Join(state.roles, ", "), state.expires)
+			}
+			t.AddRow(row)
+		}
+		addRow([]string{
 			rootClusterName, teleport.RemoteClusterStatusOnline, "root", "", showSelected(rootClusterName),
-		})
+		}, rootClusterName)
 		for _, cluster := range leafClusters {
 			labels := sortedLabels(cluster.GetMetadata().Labels)
-			t.AddRow([]string{
+			addRow([]string{
 				cluster.GetName(), cluster.GetConnectionStatus(), "leaf", labels, showSelected(cluster.GetName()),
-			})
+			}, cluster.GetName())
 		}
 		fmt.Println(t.AsBuffer().String())
 	case teleport.JSON, teleport.YAML:
@@ -1986,6 +2785,10 @@ func onListClusters(cf *CLIConf) error {
 			ClusterName: rootClusterName,
 			Status:      teleport.RemoteClusterStatusOnline,
 			ClusterType: "root",
+			Latency:     latencies[rootClusterName],
+			CertStatus:  loginStates[rootClusterName].status,
+			Roles:       loginStates[rootClusterName].roles,
+			CertExpires: loginStates[rootClusterName].expires,
 			Selected:    isSelected(rootClusterName)}
 		leafClusterInfo := make([]clusterInfo, 0, len(leafClusters))
 		for _, leaf := range leafClusters {
@@ -1994,6 +2797,10 @@ func onListClusters(cf *CLIConf) error {
 				Status:      leaf.GetConnectionStatus(),
 				ClusterType: "leaf",
 				Labels:      leaf.GetMetadata().Labels,
+				Latency:     latencies[leaf.GetName()],
+				CertStatus:  loginStates[leaf.GetName()].status,
+				Roles:       loginStates[leaf.GetName()].roles,
+				CertExpires: loginStates[leaf.GetName()].expires,
 				Selected:    isSelected(leaf.GetName())})
 		}
 		out, err := serializeClusters(rootClusterInfo, leafClusterInfo, format)
@@ -2012,9 +2819,101 @@ type clusterInfo struct {
 	Status      string            `json:"status"`
 	ClusterType string            `json:"cluster_type"`
 	Labels      map[string]string `json:"labels"`
+	Latency     string            `json:"latency,omitempty"`
+	CertStatus  string            `json:"cert_status,omitempty"`
+	Roles       []string          `json:"roles,omitempty"`
+	CertExpires string            `json:"cert_expires,omitempty"`
 	Selected    bool              `json:"selected"`
 }
 
+// clusterLoginStatus describes the locally stored certificate for a cluster.
+type clusterLoginStatus struct {
+	// status is "valid", "expired" or "none".
+	status  string
+	roles   []string
+	expires string
+}
+
+// clusterLoginState inspects the certificate stored locally for the given
+// cluster and reports whether it is still valid, the roles it grants and
+// when it expires.
+func clusterLoginState(tc *client.TeleportClient, clusterName string) clusterLoginStatus {
+	if tc.LocalAgent() == nil {
+		return clusterLoginStatus{status: "none"}
+	}
+	key, err := tc.LocalAgent().GetKey(clusterName, client.WithSSHCerts{})
+	if err != nil {
+		return clusterLoginStatus{status: "none"}
+	}
+	cert, err := key.TeleportTLSCertificate()
+	if err != nil {
+		return clusterLoginStatus{status: "none"}
+	}
+	identity, err := tlsca.FromSubject(cert.Subject, cert.NotAfter)
+	if err != nil {
+		log.WithError(err).Debugf("Failed to parse certificate identity for cluster %v.", clusterName)
+		return clusterLoginStatus{status: "none"}
+	}
+	status := "valid"
+	if time.Now().After(cert.NotAfter) {
+		status = "expired"
+	}
+	return clusterLoginStatus{
+		status:  status,
+		roles:   identity.Groups,
+		expires: cert.NotAfter.Local().Format(constants.HumanDateFormatSeconds),
+	}
+}
+
+// probeClusterLatencies measures an authenticated round trip through the
+// proxy to each of the given clusters in parallel. Unreachable clusters are
+// reported with the error message instead of a duration.
+func probeClusterLatencies(cf *CLIConf, tc *client.TeleportClient, clusterNames []string) map[string]string {
+	results := make([]string, len(clusterNames))
+	var wg sync.WaitGroup
+	for i, clusterName := range clusterNames {
+		wg.Add(1)
+		go func(i int, clusterName string) {
+			defer wg.Done()
+			latency, err := probeClusterLatency(cf.Context, tc, clusterName)
+			if err != nil {
+				log.WithError(err).Debugf("Failed to probe cluster %v.", clusterName)
+				results[i] = "unreachable"
+				return
+			}
+			results[i] = latency.Round(time.Millisecond).String()
+		}(i, clusterName)
+	}
+	wg.Wait()
+
+	latencies := make(map[string]string, len(clusterNames))
+	for i, clusterName := range clusterNames {
+		latencies[clusterName] = results[i]
+	}
+	return latencies
+}
+
+// probeClusterLatency times a trivial authenticated call routed through the
+// proxy to the auth server of the given cluster.
+func probeClusterLatency(ctx context.Context, tc *client.TeleportClient, clusterName string) (time.Duration, error) {
+	proxyClient, err := tc.ConnectToProxy(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	defer proxyClient.Close()
+
+	start := time.Now()
+	clt, err := proxyClient.ConnectToCluster(ctx, clusterName, true)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	defer clt.Close()
+	if _, err := clt.GetDomainName(); err != nil {
+		return 0, trace.Wrap(err)
+	}
+	return time.Since(start), nil
+}
+
 func serializeClusters(rootCluster clusterInfo, leafClusters []clusterInfo, format string) (string, error) {
 	clusters := []clusterInfo{rootCluster}
 	clusters = append(clusters, leafClusters...)
@@ -2028,29 +2927,199 @@ func serializeClusters(rootCluster clusterInfo, leafClusters []clusterInfo, form
 	return string(out), trace.Wrap(err)
 }
 
+// validateClusterFlag checks the --cluster value against the clusters known
+// to the proxy and suggests a close match on a likely typo, instead of
+// letting the connection fail further down with a cryptic error. The cluster
+// of the active profile is accepted without a round trip to the proxy.
+func validateClusterFlag(cf *CLIConf, tc *client.TeleportClient) error {
+	if cf.SiteName == "" {
+		return nil
+	}
+	// Fast path: the cached profile already vouches for its own cluster.
+	profile, _, err := client.Status(cf.HomePath, cf.Proxy)
+	if err == nil && profile != nil && profile.Cluster == cf.SiteName {
+		return nil
+	}
+	var clusterNames []string
+	err = client.RetryWithRelogin(cf.Context, tc, func() error {
+		proxyClient, err := tc.ConnectToProxy(cf.Context)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer proxyClient.Close()
+
+		rootClusterName, err := proxyClient.RootClusterName()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		leafClusters, err := proxyClient.GetLeafClusters(cf.Context)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		clusterNames = []string{rootClusterName}
+		for _, leaf := range leafClusters {
+			clusterNames = append(clusterNames, leaf.GetName())
+		}
+		return nil
+	})
+	if err != nil {
+		// Listing clusters requires connectivity, let the command itself
+		// surface any connection problems.
+		log.WithError(err).Debug("Unable to validate --cluster against the proxy.")
+		return nil
+	}
+	for _, name := range clusterNames {
+		if name == cf.SiteName {
+			return nil
+		}
+	}
+	if suggestion := closestClusterName(cf.SiteName, clusterNames); suggestion != "" {
+		return trace.BadParameter("unknown cluster %q, did you mean %q? (run \"tsh clusters\" for the full list)", cf.SiteName, suggestion)
+	}
+	return trace.BadParameter("unknown cluster %q, known clusters: %v", cf.SiteName, strings.Join(clusterNames, ", "))
+}
+
+// closestClusterName returns the candidate with the smallest edit distance
+// from name, if it is close enough to look like a typo.
+func closestClusterName(name string, candidates []string) string {
+	best, bestDistance := "", 0
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		if best == "" || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	// Only suggest names a few edits away, anything further away is
+	// unlikely to be a typo.
+	if best == "" || bestDistance > len(name)/3+1 {
+		return ""
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
 // onSSH executes 'tsh ssh' command
 func onSSH(cf *CLIConf) error {
+	// The tmux rewrite has to happen before makeClient so the forced TTY
+	// request is picked up.
+	if cf.TmuxAttach {
+		if err := applyTmuxAttach(cf); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	tc, err := makeClient(cf, false)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if err := validateClusterFlag(cf, tc); err != nil {
+		return trace.Wrap(err)
+	}
+	if cf.NodeID != "" {
+		// Nodes are addressable by their unique ID, which bypasses
+		// hostname ambiguity entirely.
+		tc.Host = cf.NodeID
+	}
 
 	tc.Stdin = os.Stdin
-	err = client.RetryWithRelogin(cf.Context, tc, func() error {
-		return tc.SSH(cf.Context, cf.RemoteCommand, cf.LocalExec)
-	})
-	if err != nil {
-		if strings.Contains(utils.UserMessageFromError(err), teleport.NodeIsAmbiguous) {
-			allNodes, err := tc.ListAllNodes(cf.Context)
-			if err != nil {
-				return trace.Wrap(err)
-			}
-			var nodes []types.Server
-			for _, node := range allNodes {
-				if node.GetHostname() == tc.Host {
-					nodes = append(nodes, node)
+	if err := confirmDangerousCommand(cf, cf.RemoteCommand); err != nil {
+		return trace.Wrap(err)
+	}
+	remoteCommand := cf.RemoteCommand
+	if cf.ScriptPath != "" {
+		script, err := os.ReadFile(cf.ScriptPath)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		// The script is piped to the interpreter's stdin so it never
+		// touches the remote disk. The shebang line selects the
+		// interpreter and the remote command arguments are passed to
+		// the script.
+		remoteCommand = append(scriptInterpreter(script), "/dev/stdin")
+		remoteCommand = append(remoteCommand, cf.RemoteCommand...)
+		tc.Stdin = bytes.NewReader(script)
+	}
+	// Record port forwards in the listener registry so they show up in
+	// 'tsh ps' and can be cleaned up with 'tsh kill'.
+	if len(cf.LocalForwardPorts) > 0 || len(cf.DynamicForwardedPorts) > 0 {
+		spec := strings.Join(append(append([]string{}, cf.LocalForwardPorts...), cf.DynamicForwardedPorts...), ", ")
+		unregister, err := registerListener(cf, "forward", tc.Host, spec)
+		if err != nil {
+			log.WithError(err).Debug("Failed to register local listener.")
+		} else {
+			defer unregister()
+		}
+		emitStreamEvent(cf, "port_forward_open", map[string]interface{}{
+			"host": tc.Host,
+			"spec": spec,
+		})
+	}
+	connect := func() error {
+		return retryWithAccessRequest(cf, tc, func() error {
+			return client.RetryWithRelogin(cf.Context, tc, func() error {
+				ctx := cf.Context
+				// The timeout governs the remote command only; interactive
+				// shells and sessions without a command are unaffected.
+				if cf.CommandTimeout > 0 && len(remoteCommand) > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(cf.Context, cf.CommandTimeout)
+					defer cancel()
+				}
+				err := tc.SSH(ctx, remoteCommand, cf.LocalExec)
+				// Closing the SSH channel on expiry terminates the remote
+				// command; report the timeout with a distinct exit code so
+				// health-check scripts can tell it apart from a command
+				// failure.
+				if ctx.Err() == context.DeadlineExceeded && cf.Context.Err() == nil {
+					fmt.Fprintf(os.Stderr, "error: remote command did not complete within %v\n", cf.CommandTimeout)
+					return trace.Wrap(&exitCodeError{code: commandTimeoutExitCode})
 				}
+				return trace.Wrap(err)
+			})
+		})
+	}
+	err = connect()
+	if err != nil && strings.Contains(utils.UserMessageFromError(err), teleport.NodeIsAmbiguous) {
+		allNodes, listErr := tc.ListAllNodes(cf.Context)
+		if listErr != nil {
+			return trace.Wrap(listErr)
+		}
+		var nodes []types.Server
+		for _, node := range allNodes {
+			if node.GetHostname() == tc.Host {
+				nodes = append(nodes, node)
 			}
+		}
+		nodeID, pickErr := resolveAmbiguousNode(cf, tc.Host, nodes)
+		if pickErr != nil {
+			return trace.Wrap(pickErr)
+		}
+		if nodeID == "" {
 			fmt.Fprintf(os.Stderr, "error: ambiguous host could match multiple nodes\n\n")
 			printNodesAsText(nodes, true)
 			fmt.Fprintf(os.Stderr, "Hint: try addressing the node by unique id (ex: tsh ssh user@node-id)\n")
@@ -2058,6 +3127,10 @@ func onSSH(cf *CLIConf) error {
 			fmt.Fprintf(os.Stderr, "\n")
 			return trace.Wrap(&exitCodeError{code: 1})
 		}
+		tc.Host = nodeID
+		err = connect()
+	}
+	if err != nil {
 		// exit with the same exit status as the failed command:
 		if tc.ExitStatus != 0 {
 			fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
@@ -2065,9 +3138,51 @@ func onSSH(cf *CLIConf) error {
 		}
 		return trace.Wrap(err)
 	}
+	// Remember the login that worked for this node so it becomes the default
+	// on the next connection.
+	if err := rememberNodeLogin(cf, tc); err != nil {
+		log.WithError(err).Debug("Failed to save node login preference.")
+	}
 	return nil
 }
 
+// scriptInterpreter returns the interpreter command from a script's shebang
+// line, or /bin/sh when the script has none.
+func scriptInterpreter(script []byte) []string {
+	line := script
+	if idx := bytes.IndexByte(script, '\n'); idx >= 0 {
+		line = script[:idx]
+	}
+	if bytes.HasPrefix(line, []byte("#!")) {
+		if interpreter := strings.Fields(string(line[2:])); len(interpreter) > 0 {
+			return interpreter
+		}
+	}
+	return []string{"/bin/sh"}
+}
+
+// rememberNodeLogin records the login used for a successful connection in the
+// user profile, keyed by the node name or label set given on the command
+// line. Only the node login map is updated, the rest of the profile is left
+// untouched.
+func rememberNodeLogin(cf *CLIConf, tc *client.TeleportClient) error {
+	if tc.Host == "" || tc.HostLogin == "" {
+		return nil
+	}
+	cp, err := profile.FromDir(cf.HomePath, client.ProxyHost(cf.Proxy))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if cp.NodeLogins[tc.Host] == tc.HostLogin {
+		return nil
+	}
+	if cp.NodeLogins == nil {
+		cp.NodeLogins = make(map[string]string)
+	}
+	cp.NodeLogins[tc.Host] = tc.HostLogin
+	return trace.Wrap(cp.SaveToDir(cp.Dir, false))
+}
+
 // onBenchmark executes benchmark
 func onBenchmark(cf *CLIConf) error {
 	tc, err := makeClient(cf, false)
@@ -2078,18 +3193,54 @@ func onBenchmark(cf *CLIConf) error {
 		Command:       cf.RemoteCommand,
 		MinimumWindow: cf.BenchDuration,
 		Rate:          cf.BenchRate,
+		ChaosRate:     cf.BenchChaosRate,
+		ChaosMaxDelay: cf.BenchChaosMaxDelay,
+	}
+	if cf.BenchCompareTo != "" {
+		return trace.Wrap(onBenchmarkCompare(cf, tc, cnf))
 	}
 	result, err := cnf.Benchmark(cf.Context, tc)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
 		return trace.Wrap(&exitCodeError{code: 255})
 	}
+	return trace.Wrap(printBenchmarkResult(cf, result))
+}
+
+// printBenchmarkResult prints the benchmark summary, latency histogram and,
+// when requested, exports the latency profile.
+func printBenchmarkResult(cf *CLIConf, result benchmark.Result) error {
 	fmt.Printf("\n")
 	fmt.Printf("* Requests originated: %v\n", result.RequestsOriginated)
 	fmt.Printf("* Requests failed: %v\n", result.RequestsFailed)
+	if result.RequestsKilled > 0 {
+		fmt.Printf("* Requests killed mid-flight (chaos): %v\n", result.RequestsKilled)
+	}
+	if len(result.FailuresByClass) > 0 {
+		classes := make([]string, 0, len(result.FailuresByClass))
+		for class := range result.FailuresByClass {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		fmt.Printf("* Failures by class:\n")
+		for _, class := range classes {
+			fmt.Printf("    %v: %v\n", class, result.FailuresByClass[class])
+		}
+	}
 	if result.LastError != nil {
 		fmt.Printf("* Last error: %v\n", result.LastError)
 	}
+	if len(result.StatusCodes) > 0 {
+		codes := make([]int, 0, len(result.StatusCodes))
+		for code := range result.StatusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		fmt.Printf("* Response codes:\n")
+		for _, code := range codes {
+			fmt.Printf("    %v: %v\n", code, result.StatusCodes[code])
+		}
+	}
 	fmt.Printf("\nHistogram\n\n")
 	t := asciitable.MakeTable([]string{"Percentile", "Response Duration"})
 	for _, quantile := range []float64{25, 50, 75, 90, 95, 99, 100} {
@@ -2109,10 +3260,121 @@ func onBenchmark(cf *CLIConf) error {
 		} else {
 			fmt.Printf("latency profile saved: %v\n", path)
 		}
+		paths, err := benchmark.ExportClassLatencyProfiles(cf.BenchExportPath, result.HistogramsByClass, cf.BenchTicks, cf.BenchValueScale)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed exporting per-class latency profiles: %s\n", utils.UserMessageFromError(err))
+		} else {
+			for _, path := range paths {
+				fmt.Printf("latency profile saved: %v\n", path)
+			}
+		}
 	}
 	return nil
 }
 
+// onBenchmarkWeb executes an HTTP benchmark against an application access
+// endpoint using the app certificate on disk. An active app session is
+// required, see "tsh apps login".
+func onBenchmarkWeb(cf *CLIConf) error {
+	tc, err := makeClient(cf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	app, err := pickActiveApp(cf)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(profile.AppCertPath(app.Name), profile.KeyPath())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	caPool := x509.NewCertPool()
+	caPEM, err := os.ReadFile(profile.CACertPathForCluster(app.ClusterName))
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		return trace.BadParameter("failed to parse cluster CA certificate")
+	}
+
+	url := fmt.Sprintf("https://%v", app.PublicAddr)
+	if port := tc.WebProxyPort(); port != teleport.StandardHTTPSPort {
+		url = fmt.Sprintf("https://%v:%v", app.PublicAddr, port)
+	}
+	url += cf.BenchWebEndpoint
+
+	cnf := benchmark.Config{
+		MinimumWindow: cf.BenchDuration,
+		Rate:          cf.BenchRate,
+		ChaosRate:     cf.BenchChaosRate,
+		ChaosMaxDelay: cf.BenchChaosMaxDelay,
+		URL:           url,
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					Certificates:       []tls.Certificate{cert},
+					RootCAs:            caPool,
+					InsecureSkipVerify: tc.InsecureSkipVerify,
+				},
+			},
+		},
+	}
+	fmt.Printf("Benchmarking %v\n", url)
+	result, err := cnf.Benchmark(cf.Context, tc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
+		return trace.Wrap(&exitCodeError{code: 255})
+	}
+	return trace.Wrap(printBenchmarkResult(cf, result))
+}
+
+// onBenchmarkCompare runs the identical workload against the primary target
+// and the --compare-to target and reports the latency/throughput delta. It is
+// typically used to quantify session recording overhead by benchmarking a
+// recorded and an unrecorded node.
+func onBenchmarkCompare(cf *CLIConf, tc *client.TeleportClient, cnf benchmark.Config) error {
+	cmpConf := *cf
+	cmpConf.UserHost = cf.BenchCompareTo
+	comparedTC, err := makeClient(&cmpConf, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Benchmarking %v against %v\n", cf.UserHost, cf.BenchCompareTo)
+	cmp, err := cnf.Compare(cf.Context, tc, comparedTC)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, utils.UserMessageFromError(err))
+		return trace.Wrap(&exitCodeError{code: 255})
+	}
+	fmt.Printf("\n")
+	fmt.Printf("* Requests originated: %v (%v), %v (%v)\n",
+		cmp.Baseline.RequestsOriginated, cf.UserHost,
+		cmp.Compared.RequestsOriginated, cf.BenchCompareTo)
+	fmt.Printf("* Requests failed: %v (%v), %v (%v)\n",
+		cmp.Baseline.RequestsFailed, cf.UserHost,
+		cmp.Compared.RequestsFailed, cf.BenchCompareTo)
+	fmt.Printf("* Throughput delta: %+.2f requests/s\n", cmp.ThroughputDelta())
+	fmt.Printf("\nHistogram\n\n")
+	t := asciitable.MakeTable([]string{"Percentile", cf.UserHost, cf.BenchCompareTo, "Delta"})
+	for _, quantile := range []float64{25, 50, 75, 90, 95, 99, 100} {
+		t.AddRow([]string{
+			fmt.Sprintf("%v", quantile),
+			fmt.Sprintf("%v ms", cmp.Baseline.Histogram.ValueAtQuantile(quantile)),
+			fmt.Sprintf("%v ms", cmp.Compared.Histogram.ValueAtQuantile(quantile)),
+			fmt.Sprintf("%+v ms", cmp.LatencyDeltaAtQuantile(quantile)),
+		})
+	}
+	if _, err := io.Copy(os.Stdout, t.AsBuffer()); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("\n")
+	return nil
+}
+
 // onJoin executes 'ssh join' command
 func onJoin(cf *CLIConf) error {
 	if err := validateParticipantMode(types.SessionParticipantMode(cf.JoinMode)); err != nil {
@@ -2124,10 +3386,19 @@ func onJoin(cf *CLIConf) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if err := validateClusterFlag(cf, tc); err != nil {
+		return trace.Wrap(err)
+	}
 	sid, err := session.ParseID(cf.SessionID)
 	if err != nil {
 		return trace.BadParameter("'%v' is not a valid session ID (must be GUID)", cf.SessionID)
 	}
+	switch types.SessionParticipantMode(cf.JoinMode) {
+	case types.SessionObserverMode:
+		fmt.Println("Joining session as an observer. The session is read-only, press <ctrl-c> to leave.")
+	case types.SessionModeratorMode:
+		fmt.Println("Joining session as a moderator. Press 't' to forcefully terminate the session, <ctrl-c> to leave.")
+	}
 	err = client.RetryWithRelogin(cf.Context, tc, func() error {
 		return tc.Join(context.TODO(), types.SessionParticipantMode(cf.JoinMode), cf.Namespace, *sid, nil)
 	})
@@ -2139,13 +3410,35 @@ func onJoin(cf *CLIConf) error {
 
 // onSCP executes 'tsh scp' command
 func onSCP(cf *CLIConf) error {
+	// The secret scan covers local sources regardless of where they are
+	// copied to, so it runs before the kube routing below.
+	if cf.ScanSecrets {
+		if err := scanCopySpecForSecrets(cf.CopySpec, cf.ScanAllowPatterns); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	// Kubernetes pod targets are routed through the kube proxy instead of
+	// the SSH subsystem.
+	if hasSCPKubeSpec(cf.CopySpec) {
+		// The kube copy machinery does not implement the scp transfer
+		// options, reject them instead of silently dropping them.
+		if cf.RecursiveCopy || cf.NodePort != 0 || cf.PreserveAttrs || cf.SparseCopy || cf.UpdateCopy {
+			return trace.BadParameter("the -r, -P, -p, --sparse and --update flags are not supported for kube targets")
+		}
+		return trace.Wrap(onSCPKube(cf))
+	}
 	tc, err := makeClient(cf, false)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if err := validateClusterFlag(cf, tc); err != nil {
+		return trace.Wrap(err)
+	}
 	flags := scp.Flags{
 		Recursive:     cf.RecursiveCopy,
 		PreserveAttrs: cf.PreserveAttrs,
+		Sparse:        cf.SparseCopy,
+		Update:        cf.UpdateCopy,
 	}
 	err = client.RetryWithRelogin(cf.Context, tc, func() error {
 		return tc.SCP(cf.Context, cf.CopySpec, int(cf.NodePort), flags, cf.Quiet)
@@ -2227,8 +3520,10 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 		c.JumpHosts = hosts
 	}
 
-	// Look if a user identity was given via -i flag
-	if cf.IdentityFileIn != "" {
+	// Look if a user identity was given via -i flag or the environment, for
+	// containers that run without a home directory.
+	envIdentity := os.Getenv(identityEnvVar)
+	if cf.IdentityFileIn != "" || envIdentity != "" {
 		// Ignore local authentication methods when identity file is provided
 		c.SkipLocalAuth = true
 		// Force the use of the certificate principals so Unix
@@ -2242,7 +3537,13 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 			hostAuthFunc ssh.HostKeyCallback
 		)
 		// read the ID file and create an "auth method" from it:
-		key, err = client.KeyFromIdentityFile(cf.IdentityFileIn)
+		identitySource := cf.IdentityFileIn
+		if cf.IdentityFileIn != "" {
+			key, err = client.KeyFromIdentityFile(cf.IdentityFileIn)
+		} else {
+			identitySource = "$" + identityEnvVar
+			key, err = keyFromIdentityEnv(envIdentity)
+		}
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -2269,7 +3570,7 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		log.Debugf("Extracted username %q from the identity file %v.", certUsername, cf.IdentityFileIn)
+		log.Debugf("Extracted username %q from the identity file %v.", certUsername, identitySource)
 		c.Username = certUsername
 
 		identityAuth, err = authFromIdentity(key)
@@ -2347,6 +3648,18 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 	if len(dPorts) > 0 {
 		c.DynamicForwardedPorts = dPorts
 	}
+	c.StrictHostChecking = cf.StrictHostChecking
+	if cf.ForwardConnLimit < 0 {
+		return nil, trace.BadParameter("--limit-conns must not be negative")
+	}
+	c.ForwardConnLimit = cf.ForwardConnLimit
+	if cf.ForwardBandwidth != "" && cf.ForwardBandwidth != "0" {
+		bps, err := humanize.ParseBytes(cf.ForwardBandwidth)
+		if err != nil {
+			return nil, trace.BadParameter("invalid --limit-bandwidth value %q: %v", cf.ForwardBandwidth, err)
+		}
+		c.ForwardBytesPerSecond = int64(bps)
+	}
 	profileSiteName := c.SiteName
 	if cf.SiteName != "" {
 		c.SiteName = cf.SiteName
@@ -2363,21 +3676,40 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 	}
 	if hostLogin != "" {
 		c.HostLogin = hostLogin
+	} else if login, ok := c.NodeLogins[cf.UserHost]; ok && login != "" {
+		// No login given on the command line, prefer the login that last
+		// succeeded against this node over the global default.
+		c.HostLogin = login
 	}
 	c.Host = cf.UserHost
 	c.HostPort = int(cf.NodePort)
 	c.Labels = labels
 	c.KeyTTL = time.Minute * time.Duration(cf.MinsToLive)
 	c.InsecureSkipVerify = cf.InsecureSkipVerify
+	c.TOFU = cf.TOFU
 	c.PredicateExpression = cf.PredicateExpression
 
 	if cf.SearchKeywords != "" {
 		c.SearchKeywords = client.ParseSearchKeywords(cf.SearchKeywords, ',')
 	}
 
+	// Map the -t/-T flags and the RequestTTY option onto the client TTY
+	// request mode. Command line flags take precedence over parsed options.
+	c.RequestTTY = options.RequestTTY
+	switch {
+	case cf.DisableTTY && cf.EnableTTY > 0:
+		return nil, trace.BadParameter("cannot use -t and -T together")
+	case cf.DisableTTY:
+		c.RequestTTY = client.TTYRequestNo
+	case cf.EnableTTY > 1:
+		c.RequestTTY = client.TTYRequestForce
+	case cf.EnableTTY == 1:
+		c.RequestTTY = client.TTYRequestYes
+	}
+
 	// If a TTY was requested, make sure to allocate it. Note this applies to
 	// "exec" command because a shell always has a TTY allocated.
-	if cf.Interactive || options.RequestTTY {
+	if c.RequestTTY == client.TTYRequestYes || c.RequestTTY == client.TTYRequestForce {
 		c.Interactive = true
 	}
 
@@ -2399,6 +3731,7 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 	if cf.AuthConnector != "" {
 		c.AuthConnector = cf.AuthConnector
 	}
+	c.KeyAlgorithm = cf.KeyAlgorithm
 	c.AuthenticatorAttachment, err = mfaModeToAttachment(cf.MFAMode)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -2433,6 +3766,12 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 		c.AddKeysToAgent = client.AddKeysToAgentNo
 	}
 
+	// Pass along session environment injection configuration.
+	if cf.SessionEnv != nil {
+		c.InjectSessionEnv = cf.SessionEnv.Enabled
+		c.TraitsToEnv = cf.SessionEnv.TraitMap
+	}
+
 	c.EnableEscapeSequences = cf.EnableEscapeSequences
 
 	// pass along mock sso login if provided (only used in tests)
@@ -2465,7 +3804,7 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 	// addresses.
 	// To compensate, when using an identity file, explicitly fetch these
 	// addresses from the proxy (this is what Ping does).
-	if cf.IdentityFileIn != "" {
+	if cf.IdentityFileIn != "" || envIdentity != "" {
 		log.Debug("Pinging the proxy to fetch listening addresses for non-web ports.")
 		if _, err := tc.Ping(cf.Context); err != nil {
 			return nil, trace.Wrap(err)
@@ -2840,6 +4179,9 @@ Loop:
 			switch event.Type {
 			case types.OpInit:
 				log.Infof("Access-request watcher initialized...")
+				emitStreamEvent(cf, "access_request_pending", map[string]interface{}{
+					"id": req.GetName(),
+				})
 				close(ready)
 				continue Loop
 			case types.OpPut:
@@ -2851,6 +4193,10 @@ Loop:
 					log.Debugf("Skipping put event id=%s,state=%s.", r.GetName(), r.GetState())
 					continue Loop
 				}
+				emitStreamEvent(cf, "access_request_resolved", map[string]interface{}{
+					"id":    r.GetName(),
+					"state": r.GetState().String(),
+				})
 				return r, nil
 			case types.OpDelete:
 				if event.Resource.GetName() != req.GetName() {
@@ -2922,31 +4268,38 @@ func onApps(cf *CLIConf) error {
 		return trace.Wrap(err)
 	}
 
-	// Get a list of all applications.
-	var apps []types.Application
-	err = client.RetryWithRelogin(cf.Context, tc, func() error {
-		apps, err = tc.ListApps(cf.Context, nil /* custom filter */)
-		return err
-	})
-	if err != nil {
-		if utils.IsPredicateError(err) {
-			return trace.Wrap(utils.PredicateError{Err: err})
+	list := func() error {
+		// Get a list of all applications.
+		var apps []types.Application
+		err = client.RetryWithRelogin(cf.Context, tc, func() error {
+			apps, err = tc.ListApps(cf.Context, nil /* custom filter */)
+			return err
+		})
+		if err != nil {
+			if utils.IsPredicateError(err) {
+				return trace.Wrap(utils.PredicateError{Err: err})
+			}
+			return trace.Wrap(err)
 		}
-		return trace.Wrap(err)
-	}
 
-	// Retrieve profile to be able to show which apps user is logged into.
-	profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
-	if err != nil {
-		return trace.Wrap(err)
-	}
+		// Retrieve profile to be able to show which apps user is logged into.
+		profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+		if err != nil {
+			return trace.Wrap(err)
+		}
 
-	// Sort by app name.
-	sort.Slice(apps, func(i, j int) bool {
-		return apps[i].GetName() < apps[j].GetName()
-	})
+		// Sort by app name.
+		sort.Slice(apps, func(i, j int) bool {
+			return apps[i].GetName() < apps[j].GetName()
+		})
 
-	return trace.Wrap(showApps(apps, profile.Apps, cf.Format, cf.Verbose))
+		return trace.Wrap(showApps(apps, profile.Apps, cf.Format, cf.Columns, cf.Verbose))
+	}
+
+	if cf.Watch {
+		return trace.Wrap(watchResourceListing(cf, tc, types.KindAppServer, list))
+	}
+	return trace.Wrap(list())
 }
 
 // onEnvironment handles "tsh env" command.
@@ -3039,9 +4392,18 @@ func setSiteNameFromEnv(cf *CLIConf, fn envGetter) {
 }
 
 // setTeleportHomeFromEnv sets home directory from environment if configured.
+// When neither TELEPORT_HOME nor the --home flag is set and an XDG data
+// directory exists (created by 'tsh migrate-home' or by hand), it is used
+// instead of the legacy ~/.tsh.
 func setTeleportHomeFromEnv(cf *CLIConf, fn envGetter) {
 	if homeDir := fn(types.HomeEnvVar); homeDir != "" {
 		cf.HomePath = path.Clean(homeDir)
+		return
+	}
+	if cf.HomePath == "" {
+		if xdgPath := xdgProfilePath(fn); xdgPath != "" && utils.IsDir(xdgPath) {
+			cf.HomePath = xdgPath
+		}
 	}
 }
 
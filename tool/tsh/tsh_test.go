@@ -2079,20 +2079,36 @@ func TestSerializeKubeSessions(t *testing.T) {
 }
 
 func TestSerializeKubeClusters(t *testing.T) {
+	aTime := time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
 	expected := `
 	[
 		{
 			"kube_cluster_name": "cluster1",
-			"selected": true
+			"labels": {"env": "prod"},
+			"selected": true,
+			"logged_in": true,
+			"last_heartbeat": "1970-01-01T00:00:00Z"
 		},
 		{
 			"kube_cluster_name": "cluster2",
-			"selected": false
+			"selected": false,
+			"logged_in": false,
+			"last_heartbeat": "0001-01-01T00:00:00Z"
 		}
 	]
 	`
+	clusters := []kubeClusterInfo{
+		{
+			KubeClusterName: "cluster1",
+			Labels:          map[string]string{"env": "prod"},
+			Selected:        true,
+			LoggedIn:        true,
+			LastHeartbeat:   aTime,
+		},
+		{KubeClusterName: "cluster2"},
+	}
 	testSerialization(t, expected, func(f string) (string, error) {
-		return serializeKubeClusters([]string{"cluster1", "cluster2"}, "cluster1", f)
+		return serializeKubeClusters(clusters, f)
 	})
 }
 
@@ -2210,3 +2226,13 @@ func Test_getUsersForDb(t *testing.T) {
 		})
 	}
 }
+
+func TestClosestClusterName(t *testing.T) {
+	candidates := []string{"root.example.com", "leaf.example.com", "staging"}
+
+	// A one-character typo resolves to the intended cluster.
+	require.Equal(t, "leaf.example.com", closestClusterName("laef.example.com", candidates))
+	// Names nothing like the candidates produce no suggestion.
+	require.Equal(t, "", closestClusterName("production", candidates))
+	require.Equal(t, "", closestClusterName("x", nil))
+}
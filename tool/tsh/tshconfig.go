@@ -21,6 +21,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gravitational/teleport/api/profile"
 
@@ -41,6 +42,63 @@ type TshConfig struct {
 	// ExtraHeaders are additional http headers to be included in
 	// webclient requests.
 	ExtraHeaders []ExtraProxyHeaders `yaml:"add_headers,omitempty"`
+	// SessionEnv configures injection of session metadata and user traits
+	// as environment variables into started sessions.
+	SessionEnv *SessionEnv `yaml:"session_env,omitempty"`
+	// Telemetry configures the opt-in, local-only usage metrics collector.
+	Telemetry *TelemetryConfig `yaml:"telemetry,omitempty"`
+	// DevicePosture configures a device posture check binary that runs
+	// before login and whose attestation is attached to the login request.
+	DevicePosture *DevicePostureConfig `yaml:"device_posture,omitempty"`
+	// CommandGuard configures confirmation prompts for remote commands
+	// matching dangerous patterns.
+	CommandGuard *CommandGuardConfig `yaml:"command_guard,omitempty"`
+}
+
+// CommandGuardConfig configures the client-side guard that asks for
+// confirmation before running remote commands matching dangerous patterns
+// such as "rm -rf /" or "DROP DATABASE". The guard is on by default.
+type CommandGuardConfig struct {
+	// Disabled turns the guard off entirely.
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Patterns are additional regular expressions checked on top of the
+	// built-in dangerous command patterns.
+	Patterns []string `yaml:"patterns,omitempty"`
+}
+
+// DevicePostureConfig configures an external binary that collects device
+// posture information (disk encryption, OS version, EDR presence, etc) on
+// login. Its standard output is attached to the login request so the proxy
+// can gate certificate issuance on it.
+type DevicePostureConfig struct {
+	// Command is the posture check binary to run.
+	Command string `yaml:"command"`
+	// Args are optional arguments passed to the command.
+	Args []string `yaml:"args,omitempty"`
+	// Timeout bounds the posture check run time. Defaults to 30 seconds.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Required makes login fail when the posture check cannot be run or
+	// exits with an error. When unset, a failed check is only logged.
+	Required bool `yaml:"required,omitempty"`
+}
+
+// TelemetryConfig controls the opt-in local usage metrics collector. Metrics
+// are aggregated into a file in the tsh profile directory and never leave
+// the machine.
+type TelemetryConfig struct {
+	// Enabled turns on local aggregation of command usage metrics.
+	Enabled bool `yaml:"enabled"`
+}
+
+// SessionEnv configures injection of Teleport session metadata and user
+// traits as environment variables into started sessions.
+type SessionEnv struct {
+	// Enabled turns on injection of the TELEPORT_USER, TELEPORT_ROLES and
+	// TELEPORT_CLUSTER variables.
+	Enabled bool `yaml:"enabled"`
+	// TraitMap maps user trait names to environment variable names, e.g.
+	// {team: TELEPORT_TRAIT_TEAM}.
+	TraitMap map[string]string `yaml:"trait_map,omitempty"`
 }
 
 // ExtraProxyHeaders represents the headers to include with the
@@ -68,6 +126,30 @@ func (config *TshConfig) Merge(otherConfig *TshConfig) TshConfig {
 	// extra headers
 	newConfig.ExtraHeaders = append(baseConfig.ExtraHeaders, otherConfig.ExtraHeaders...)
 
+	// session environment injection
+	newConfig.SessionEnv = baseConfig.SessionEnv
+	if otherConfig.SessionEnv != nil {
+		newConfig.SessionEnv = otherConfig.SessionEnv
+	}
+
+	// telemetry
+	newConfig.Telemetry = baseConfig.Telemetry
+	if otherConfig.Telemetry != nil {
+		newConfig.Telemetry = otherConfig.Telemetry
+	}
+
+	// device posture
+	newConfig.DevicePosture = baseConfig.DevicePosture
+	if otherConfig.DevicePosture != nil {
+		newConfig.DevicePosture = otherConfig.DevicePosture
+	}
+
+	// command guard
+	newConfig.CommandGuard = baseConfig.CommandGuard
+	if otherConfig.CommandGuard != nil {
+		newConfig.CommandGuard = otherConfig.CommandGuard
+	}
+
 	return newConfig
 }
 
@@ -0,0 +1,259 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/api/client/webclient"
+	"github.com/gravitational/teleport/api/constants"
+	"github.com/gravitational/teleport/lib/client"
+
+	"github.com/gravitational/trace"
+)
+
+// updateDownloadBaseURL is where release tarballs and their checksums are
+// published.
+const updateDownloadBaseURL = "https://get.gravitational.com"
+
+// onUpdate implements "tsh update". It downloads the tsh binary matching the
+// version advertised by the proxy, verifies the published checksum and swaps
+// the running binary, keeping the previous one next to it for rollback.
+func onUpdate(cf *CLIConf) error {
+	if runtime.GOOS == constants.WindowsOS {
+		return trace.NotImplemented("automatic updates are not supported on Windows, download the client matching your proxy from %v", updateDownloadBaseURL)
+	}
+
+	proxyAddr := cf.Proxy
+	if proxyAddr == "" {
+		profile, err := client.StatusCurrent(cf.HomePath, cf.Proxy)
+		if err != nil {
+			return trace.Wrap(err, "no proxy specified, use --proxy or log in first")
+		}
+		proxyAddr = profile.ProxyURL.Host
+	}
+
+	ping, err := webclient.Find(&webclient.Config{
+		Context:   cf.Context,
+		ProxyAddr: proxyAddr,
+		Insecure:  cf.InsecureSkipVerify,
+	})
+	if err != nil {
+		return trace.Wrap(err, "failed to fetch the version of proxy %v", proxyAddr)
+	}
+	targetVersion := ping.ServerVersion
+	if targetVersion == teleport.Version {
+		fmt.Printf("Client version %v already matches proxy %v.\n", teleport.Version, proxyAddr)
+		return nil
+	}
+	if cf.UpdateCheck {
+		fmt.Printf("Proxy %v is running Teleport %v, this client is %v. Run 'tsh update' to update.\n",
+			proxyAddr, targetVersion, teleport.Version)
+		return nil
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	exePath, err = filepath.EvalSymlinks(exePath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	url := updateDownloadURL(targetVersion, runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("Downloading %v...\n", url)
+	tarballPath, actualChecksum, err := downloadToTemp(cf, url)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer os.Remove(tarballPath)
+
+	expectedChecksum, err := fetchChecksum(cf, url+".sha256")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if actualChecksum != expectedChecksum {
+		return trace.BadParameter("checksum mismatch for %v: expected %v, got %v", url, expectedChecksum, actualChecksum)
+	}
+
+	// Extract the new binary into the directory of the current one so the
+	// final swap is an atomic rename on the same filesystem.
+	newPath := exePath + ".new"
+	if err := extractTarballFile(tarballPath, "teleport/tsh", newPath); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := replaceBinary(exePath, newPath); err != nil {
+		os.Remove(newPath)
+		return trace.Wrap(err)
+	}
+
+	fmt.Printf("Updated %v to Teleport %v. The previous binary was kept at %v.\n",
+		exePath, targetVersion, exePath+updateBackupSuffix)
+	return nil
+}
+
+// updateBackupSuffix is appended to the path of the binary being replaced so
+// a failed or regretted update can be rolled back by renaming it back.
+const updateBackupSuffix = ".old"
+
+// updateDownloadURL returns the download URL of the client tarball for the
+// given version and platform.
+func updateDownloadURL(version, goos, goarch string) string {
+	return fmt.Sprintf("%s/teleport-v%s-%s-%s-bin.tar.gz",
+		updateDownloadBaseURL, strings.TrimPrefix(version, "v"), goos, goarch)
+}
+
+// downloadToTemp downloads the given URL into a temporary file and returns
+// its path along with the hex-encoded SHA256 checksum of the content.
+func downloadToTemp(cf *CLIConf, url string) (path string, checksum string, err error) {
+	req, err := http.NewRequestWithContext(cf.Context, http.MethodGet, url, nil)
+	if err != nil {
+		return "", "", trace.Wrap(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", trace.BadParameter("failed to download %v: %v", url, resp.Status)
+	}
+
+	f, err := os.CreateTemp("", "tsh-update-*.tar.gz")
+	if err != nil {
+		return "", "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		os.Remove(f.Name())
+		return "", "", trace.Wrap(err)
+	}
+	return f.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fetchChecksum downloads and parses a published SHA256 checksum file.
+func fetchChecksum(cf *CLIConf, url string) (string, error) {
+	req, err := http.NewRequestWithContext(cf.Context, http.MethodGet, url, nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", trace.BadParameter("failed to download checksum %v: %v", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return parseChecksum(data)
+}
+
+// parseChecksum extracts the hex-encoded SHA256 checksum from the content of
+// a checksum file formatted as "<checksum>  <filename>".
+func parseChecksum(data []byte) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", trace.BadParameter("empty checksum file")
+	}
+	checksum := strings.ToLower(fields[0])
+	if len(checksum) != sha256.Size*2 {
+		return "", trace.BadParameter("malformed SHA256 checksum %q", checksum)
+	}
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", trace.BadParameter("malformed SHA256 checksum %q", checksum)
+	}
+	return checksum, nil
+}
+
+// extractTarballFile extracts a single file from a gzipped tarball into
+// dstPath, marked executable.
+func extractTarballFile(tarballPath, name, dstPath string) error {
+	f, err := os.Open(tarballPath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return trace.NotFound("%v not found in tarball", name)
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if header.Name != name || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		dst, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		if _, err := io.Copy(dst, tr); err != nil {
+			dst.Close()
+			os.Remove(dstPath)
+			return trace.Wrap(err)
+		}
+		return trace.ConvertSystemError(dst.Close())
+	}
+}
+
+// replaceBinary swaps exePath with newPath, keeping the previous binary at
+// exePath + updateBackupSuffix. If the swap fails midway, the previous
+// binary is restored.
+func replaceBinary(exePath, newPath string) error {
+	backupPath := exePath + updateBackupSuffix
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(exePath, backupPath); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(newPath, exePath); err != nil {
+		// Roll back so the user is not left without a working binary.
+		if restoreErr := os.Rename(backupPath, exePath); restoreErr != nil {
+			return trace.NewAggregate(trace.ConvertSystemError(err), trace.ConvertSystemError(restoreErr))
+		}
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
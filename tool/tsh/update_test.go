@@ -0,0 +1,110 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDownloadURL(t *testing.T) {
+	require.Equal(t,
+		"https://get.gravitational.com/teleport-v9.3.2-linux-amd64-bin.tar.gz",
+		updateDownloadURL("9.3.2", "linux", "amd64"))
+	// A leading "v" in the advertised version is tolerated.
+	require.Equal(t,
+		"https://get.gravitational.com/teleport-v9.3.2-darwin-arm64-bin.tar.gz",
+		updateDownloadURL("v9.3.2", "darwin", "arm64"))
+}
+
+func TestParseChecksum(t *testing.T) {
+	checksum := strings.Repeat("ab", 32)
+	parsed, err := parseChecksum([]byte(checksum + "  teleport-v9.3.2-linux-amd64-bin.tar.gz\n"))
+	require.NoError(t, err)
+	require.Equal(t, checksum, parsed)
+
+	// Bare checksum without a filename works too.
+	parsed, err = parseChecksum([]byte(strings.ToUpper(checksum)))
+	require.NoError(t, err)
+	require.Equal(t, checksum, parsed)
+
+	_, err = parseChecksum([]byte(""))
+	require.True(t, trace.IsBadParameter(err))
+	_, err = parseChecksum([]byte("tooshort file.tar.gz"))
+	require.True(t, trace.IsBadParameter(err))
+	_, err = parseChecksum([]byte(strings.Repeat("zz", 32)))
+	require.True(t, trace.IsBadParameter(err))
+}
+
+func TestExtractTarballFile(t *testing.T) {
+	dir := t.TempDir()
+	tarballPath := filepath.Join(dir, "bundle.tar.gz")
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"teleport/README.md": "docs",
+		"teleport/tsh":       "tsh binary",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0755,
+			Size:     int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, os.WriteFile(tarballPath, buf.Bytes(), 0644))
+
+	dstPath := filepath.Join(dir, "tsh.new")
+	require.NoError(t, extractTarballFile(tarballPath, "teleport/tsh", dstPath))
+	content, err := os.ReadFile(dstPath)
+	require.NoError(t, err)
+	require.Equal(t, "tsh binary", string(content))
+
+	err = extractTarballFile(tarballPath, "teleport/tctl", filepath.Join(dir, "tctl.new"))
+	require.True(t, trace.IsNotFound(err))
+}
+
+func TestReplaceBinary(t *testing.T) {
+	dir := t.TempDir()
+	exePath := filepath.Join(dir, "tsh")
+	newPath := exePath + ".new"
+	require.NoError(t, os.WriteFile(exePath, []byte("old"), 0755))
+	require.NoError(t, os.WriteFile(newPath, []byte("new"), 0755))
+
+	require.NoError(t, replaceBinary(exePath, newPath))
+
+	content, err := os.ReadFile(exePath)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(content))
+	backup, err := os.ReadFile(exePath + updateBackupSuffix)
+	require.NoError(t, err)
+	require.Equal(t, "old", string(backup))
+}